@@ -3,27 +3,157 @@ package agentbill
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+const (
+	sdkServiceName = "agentbill-go-sdk"
+	sdkVersion     = "1.0.0"
 )
 
 // Config represents the AgentBill SDK configuration
 type Config struct {
-	APIKey     string
+	APIKey string
+	// BaseURL may name a unix socket, as in unix:///var/run/agentbill.sock, to
+	// send AgentBill traffic through a sidecar agent on the same host
 	BaseURL    string
 	CustomerID string
 	Debug      bool
+
+	// Pricing overrides or extends the built-in model price table
+	Pricing map[string]ModelPrice
+
+	// Cache enables completion caching; hits are recorded as zero-cost spans
+	Cache    Cache
+	CacheTTL time.Duration
+
+	// Transport tunes the shared connection pools
+	Transport TransportConfig
+	// ProviderTransport replaces the round tripper used for provider calls,
+	// e.g. to record and replay fixtures in tests
+	ProviderTransport http.RoundTripper
+
+	// MaxExportBytes caps the body size of a single export request (default 4 MiB)
+	MaxExportBytes int
+
+	// SignalDedupWindow suppresses identical signals tracked again within the window.
+	// Signals match on event, customer and idempotency key, or on full content when no key is set.
+	SignalDedupWindow time.Duration
+
+	// FXRates, when set, converts signal revenue in other currencies to
+	// ReportingCurrency (default USD) as signals are tracked
+	FXRates           FXRateProvider
+	ReportingCurrency string
+
+	// Retry retries provider calls and span exports that fail with a retryable
+	// status, backing off between attempts; by default nothing is retried
+	Retry RetryPolicy
+	// RateLimitRetries is shorthand, used when Retry is unset, for retrying
+	// provider calls and exports rejected with 429 up to this many times, waiting
+	// as long as Retry-After asks (at most a minute) or backing off exponentially
+	// from one second
+	RateLimitRetries int
+
+	// EntitlementTTL is how long CheckEntitlement reuses an answer (default 30s);
+	// negative disables the cache
+	EntitlementTTL time.Duration
+
+	// AggregateUsage replaces per-call provider spans with usage records summed
+	// per customer, model and minute; spans started directly are still exported
+	AggregateUsage bool
+
+	// SampleRate exports this fraction of traces (default 1). Token and cost totals
+	// of unsampled provider calls are still reported through usage records, so
+	// billing stays exact at any rate.
+	SampleRate float64
+
+	// MaxQueueSize caps the number of buffered spans (default 2048). Reaching it
+	// starts a background flush; spans arriving before that frees space are
+	// handled by Backpressure.
+	MaxQueueSize int
+	// Backpressure selects what happens to new spans when the buffer is full
+	Backpressure BackpressurePolicy
+	// BlockTimeout bounds how long BackpressureBlock waits for space (default 100ms)
+	BlockTimeout time.Duration
+	// MaxBufferBytes caps the estimated memory held by buffered spans; 0 means no limit.
+	// Exceeding it triggers the same backpressure policy as a full queue.
+	MaxBufferBytes int
+
+	// IDGenerator and Clock replace random IDs and wall-clock time so exported
+	// payloads are reproducible in tests
+	IDGenerator IDGenerator
+	Clock       Clock
+	// SyncClock measures the clock's offset from AgentBill's servers when the
+	// client starts and corrects timestamps by it; see Client.SyncClock
+	SyncClock bool
+
+	// ExportCredentials authenticates span exports per request instead of with
+	// the API key, e.g. with short-lived tokens for a self-hosted collector
+	ExportCredentials PerRPCCredentials
+	// ExportTLS configures TLS for span exports (custom CAs, client certificates)
+	ExportTLS *tls.Config
+
+	// ExportProtocol selects the wire protocol for span exports; when empty,
+	// AGENTBILL_EXPORT_PROTOCOL is consulted and OTLP/JSON over HTTP is the default
+	ExportProtocol ExportProtocol
+	// ExportEndpoint overrides where spans are sent: the full collector URL for
+	// HTTP protocols, or the collector's https:// address for gRPC
+	ExportEndpoint string
+	// ExportCompression compresses export bodies with every protocol
+	ExportCompression Compression
+	// BulkExport sends spans, signals and usage records together to the bulk-ingest
+	// endpoint, as NDJSON or MessagePack when ExportProtocol selects them and as
+	// OTLP/JSON otherwise. Signals are buffered until Flush instead of being sent
+	// as they are tracked; beyond MaxQueueSize buffered signals the oldest are
+	// discarded and counted in Stats.DroppedSignals.
+	BulkExport bool
+	// ExportWriter receives spans, signals and usage records as NDJSON lines instead
+	// of the network, e.g. a file picked up by data lake ingestion
+	ExportWriter io.Writer
+	// Publisher receives spans, signals and usage records instead of the network,
+	// e.g. to route telemetry through a Kafka, NATS, SQS, Kinesis or Pub/Sub bus
+	Publisher Publisher
+
+	// DryRun runs the full pipeline (costing, batching, payload building) but logs
+	// each export instead of sending it; provider calls are still made. With Debug
+	// set the payloads are logged as well.
+	DryRun bool
+
+	// Disabled turns the client into a mock: nothing is sent over the network,
+	// exports are recorded in memory and provider calls get simulated responses
+	Disabled bool
 }
 
 // Client is the main AgentBill SDK client
 type Client struct {
 	config Config
 	tracer *Tracer
+
+	// Separate pools so slow provider calls never starve telemetry exports
+	providerHTTP *http.Client
+	apiHTTP      *http.Client
+
+	dedup  *dedupWindow
+	usage  *usageAggregator
+	ledger *localLedger
+	mock   *Mock
+
+	entitlements *entitlementCache
+	aliases      *aliasTable
+	trials       *trialTable
+	serverClock  *serverClock
 }
 
 // Init initializes a new AgentBill client
@@ -31,15 +161,69 @@ func Init(config Config) *Client {
 	if config.BaseURL == "" {
 		config.BaseURL = "https://uenhjwdtnxtchlmqarjo.supabase.co"
 	}
-	return &Client{
-		config: config,
-		tracer: NewTracer(config),
+	config.useUnixSocket()
+	apiHTTP := newHTTPClient(config.Transport, 10*time.Second)
+	// Provider calls leave the host directly; only AgentBill traffic uses the socket
+	providerTransport := config.Transport
+	providerTransport.socket = ""
+	var synced *serverClock
+	if config.SyncClock {
+		synced = &serverClock{base: config.clock()}
+		config.Clock = synced
+	}
+	aliases := newAliasTable()
+	tracer := NewTracer(config)
+	tracer.httpClient = exportHTTPClient(config, apiHTTP)
+	tracer.aliases = aliases
+	client := &Client{
+		config:       config,
+		tracer:       tracer,
+		providerHTTP: newHTTPClient(providerTransport, 30*time.Second),
+		apiHTTP:      apiHTTP,
+		usage:        newUsageAggregator(),
+		ledger:       newLocalLedger(),
+		entitlements: newEntitlementCache(),
+		aliases:      aliases,
+		trials:       newTrialTable(),
+		serverClock:  synced,
+	}
+	if config.SignalDedupWindow > 0 {
+		client.dedup = newDedupWindow(config.SignalDedupWindow, config.stopwatch())
+	}
+	if config.ProviderTransport != nil {
+		client.providerHTTP.Transport = config.ProviderTransport
+	}
+	if config.DryRun {
+		apiHTTP.Transport = dryRunTransport{debug: config.Debug}
+		tracer.httpClient = apiHTTP
+	}
+	if config.Disabled {
+		client.mock = newMock(config.BaseURL)
+		apiHTTP.Transport = client.mock
+		client.providerHTTP.Transport = client.mock
+		tracer.httpClient = apiHTTP
+	}
+	if synced != nil && !config.Disabled && !config.DryRun {
+		client.syncClockAsync()
 	}
+	return client
+}
+
+// providerKey reads a provider API key from the environment; mock clients never need one
+func (c *Client) providerKey(env string) (string, error) {
+	if key := os.Getenv(env); key != "" {
+		return key, nil
+	}
+	if c.mock != nil {
+		return "mock", nil
+	}
+	return "", fmt.Errorf("%s environment variable not set", env)
 }
 
 // OpenAIWrapper wraps OpenAI client calls
 type OpenAIWrapper struct {
-	client *Client
+	client    *Client
+	guardrail GuardrailHook
 }
 
 // WrapOpenAI wraps an OpenAI client for tracking
@@ -49,245 +233,657 @@ func (c *Client) WrapOpenAI() *OpenAIWrapper {
 
 // ChatCompletion tracks an OpenAI chat completion call
 func (w *OpenAIWrapper) ChatCompletion(ctx context.Context, model string, messages []map[string]string) (map[string]interface{}, error) {
-	startTime := time.Now()
+	return w.client.chatCompletion(ctx, openAIProvider, w.guardrail, model, messages)
+}
 
-	span := w.client.tracer.StartSpan("openai.chat.completion", map[string]interface{}{
-		"model":    model,
-		"provider": "openai",
-	})
+// post sends a JSON request to the OpenAI API and decodes the JSON response
+func (w *OpenAIWrapper) post(ctx context.Context, path string, requestBody interface{}) (map[string]interface{}, error) {
+	return w.client.providerPost(ctx, openAIProvider, path, requestBody)
+}
 
-	defer func() {
-		latency := time.Since(startTime).Milliseconds()
-		span.SetAttribute("latency_ms", latency)
-		span.End()
-	}()
+// Signal represents a custom event with revenue. CustomerID defaults to the client's customer.
+// Amounts are in Currency, an ISO 4217 code, or USD when it is empty. With Config.FXRates
+// set, amounts in other currencies are converted when tracked, keeping the original revenue
+// and currency in OriginalRevenue and OriginalCurrency with the ExchangeRate applied.
+// Revenue excludes tax; TaxAmount is the tax collected on top of it, at TaxRate (0.2 for 20%)
+// in TaxJurisdiction, e.g. "GB" or "US-CA". Revenue is after discounts; DiscountAmount is
+// the amount taken off by CouponCode or DiscountPercent (20 for 20% off). OrganizationID,
+// ProjectID and UserID default to those set with WithOrganization, WithProject and WithUser.
+//
+// For revenue recognition, ServicePeriodStart and ServicePeriodEnd (unix seconds) bound
+// the service the revenue pays for, and Deferred marks revenue recognized over that
+// period rather than when it is tracked; see Signal.RecognizeOver.
+type Signal struct {
+	EventName          string                 `json:"event_name"`
+	Revenue            float64                `json:"revenue"`
+	Currency           string                 `json:"currency,omitempty"`
+	OriginalRevenue    float64                `json:"original_revenue,omitempty"`
+	OriginalCurrency   string                 `json:"original_currency,omitempty"`
+	ExchangeRate       float64                `json:"exchange_rate,omitempty"`
+	TaxAmount          float64                `json:"tax_amount,omitempty"`
+	TaxRate            float64                `json:"tax_rate,omitempty"`
+	TaxJurisdiction    string                 `json:"tax_jurisdiction,omitempty"`
+	CouponCode         string                 `json:"coupon_code,omitempty"`
+	DiscountAmount     float64                `json:"discount_amount,omitempty"`
+	DiscountPercent    float64                `json:"discount_percent,omitempty"`
+	ServicePeriodStart int64                  `json:"service_period_start,omitempty"`
+	ServicePeriodEnd   int64                  `json:"service_period_end,omitempty"`
+	Deferred           bool                   `json:"deferred,omitempty"`
+	CustomerID         string                 `json:"customer_id"`
+	TraceID            string                 `json:"trace_id,omitempty"`
+	IdempotencyKey     string                 `json:"idempotency_key,omitempty"`
+	Experiment         string                 `json:"experiment,omitempty"`
+	Variant            string                 `json:"variant,omitempty"`
+	OrganizationID     string                 `json:"organization_id,omitempty"`
+	ProjectID          string                 `json:"project_id,omitempty"`
+	UserID             string                 `json:"user_id,omitempty"`
+	Timestamp          int64                  `json:"timestamp"`
+	Data               map[string]interface{} `json:"data"`
+
+	// messageID identifies the signal to message buses across publish attempts
+	messageID string
+}
 
-	// Build request payload
-	requestBody := map[string]interface{}{
-		"model":    model,
-		"messages": messages,
-	}
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		span.SetStatus(1, err.Error())
-		return nil, err
-	}
+// RecognizeOver returns a copy of the signal whose revenue is deferred and
+// recognized over the service period from start to end
+func (s Signal) RecognizeOver(start, end time.Time) Signal {
+	s.ServicePeriodStart = start.Unix()
+	s.ServicePeriodEnd = end.Unix()
+	s.Deferred = true
+	return s
+}
 
-	// Make actual OpenAI API call
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		err := fmt.Errorf("OPENAI_API_KEY environment variable not set")
-		span.SetStatus(1, err.Error())
-		return nil, err
-	}
+// TrackSignal tracks a custom signal/event with revenue
+func (c *Client) TrackSignal(ctx context.Context, signal Signal) (err error) {
+	url := fmt.Sprintf("%s/functions/v1/record-signals", c.config.BaseURL)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
-	if err != nil {
-		span.SetStatus(1, err.Error())
-		return nil, err
+	signal.CustomerID = c.customerID(signal.CustomerID)
+	signal.Timestamp = c.config.clock().Now().Unix()
+	AttributionFromContext(ctx).applyToSignal(&signal)
+	if signal.TraceID == "" {
+		signal.TraceID = TraceContextFromContext(ctx).TraceID
 	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		span.SetStatus(1, err.Error())
-		return nil, err
+	if signal.Data == nil {
+		signal.Data = make(map[string]interface{})
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		err := fmt.Errorf("OpenAI API returned status: %d", resp.StatusCode)
-		span.SetStatus(1, err.Error())
-		return nil, err
+	if c.dedup != nil {
+		key := signalDedupKey(signal)
+		if !c.dedup.claim(key) {
+			if c.config.Debug {
+				fmt.Printf("[AgentBill] Duplicate signal suppressed: %s\n", signal.EventName)
+			}
+			return nil
+		}
+		// A failed send must not suppress the caller's retry
+		defer func() {
+			if err != nil {
+				c.dedup.forget(key)
+			}
+		}()
 	}
 
-	// Parse response
-	var response map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		span.SetStatus(1, err.Error())
-		return nil, err
-	}
+	c.convertCurrency(ctx, &signal)
+	signal.messageID = signalMessageID(signal)
 
-	// Extract token usage
-	if usage, ok := response["usage"].(map[string]interface{}); ok {
-		if promptTokens, ok := usage["prompt_tokens"].(float64); ok {
-			span.SetAttribute("response.prompt_tokens", int(promptTokens))
-		}
-		if completionTokens, ok := usage["completion_tokens"].(float64); ok {
-			span.SetAttribute("response.completion_tokens", int(completionTokens))
+	// The signal is sent even if the caller's request ends first
+	ctx, cancel := detachedContext(ctx)
+	defer cancel()
+
+	if c.tracer.redirected() {
+		return c.tracer.deliver(ctx, nil, []Signal{signal}, nil)
+	}
+	if c.tracer.bulk != nil {
+		dropped := c.tracer.bulk.addSignal(signal)
+		if dropped > 0 {
+			atomic.AddUint64(&c.tracer.droppedSignals, uint64(dropped))
 		}
-		if totalTokens, ok := usage["total_tokens"].(float64); ok {
-			span.SetAttribute("response.total_tokens", int(totalTokens))
+		if c.config.Debug {
+			fmt.Printf("[AgentBill] Signal buffered for bulk export: %s\n", signal.EventName)
+			if dropped > 0 {
+				fmt.Printf("[AgentBill] Bulk buffer full, discarded %d oldest signals\n", dropped)
+			}
 		}
+		return nil
 	}
 
-	span.SetStatus(0, "")
-	return response, nil
-}
-
-// Signal represents a custom event with revenue
-type Signal struct {
-	EventName  string                 `json:"event_name"`
-	Revenue    float64                `json:"revenue"`
-	CustomerID string                 `json:"customer_id"`
-	Timestamp  int64                  `json:"timestamp"`
-	Data       map[string]interface{} `json:"data"`
-}
-
-// TrackSignal tracks a custom signal/event with revenue
-func (c *Client) TrackSignal(ctx context.Context, signal Signal) error {
-	url := fmt.Sprintf("%s/functions/v1/record-signals", c.config.BaseURL)
-	
-	signal.CustomerID = c.config.CustomerID
-	signal.Timestamp = time.Now().Unix()
-	if signal.Data == nil {
-		signal.Data = make(map[string]interface{})
-	}
-	
 	jsonData, err := json.Marshal(signal)
 	if err != nil {
 		return err
 	}
-	
+
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return err
 	}
-	
+
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
 	req.Header.Set("Content-Type", "application/json")
-	
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+
+	resp, err := c.apiHTTP.Do(req)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	
+	defer closeBody(resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return newAPIError("AgentBill", resp, c.config.clock().Now())
+	}
+
 	if c.config.Debug {
 		fmt.Printf("[AgentBill] Signal tracked: %s, revenue: $%.2f\n", signal.EventName, signal.Revenue)
 	}
-	
+
 	return nil
 }
 
+// Tracer returns the tracer used for the client's spans
+func (c *Client) Tracer() *Tracer {
+	return c.tracer
+}
+
+// Shutdown stops background flushes, canceling one in progress, and makes a
+// final flush of pending telemetry within ctx's deadline. Spans started after
+// Shutdown are only sent by explicit calls to Flush.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.tracer.Shutdown()
+	return c.Flush(ctx)
+}
+
+// Shutdown stops the flushes the tracer starts when its buffer fills, canceling one in progress
+func (t *Tracer) Shutdown() {
+	t.stopBackground()
+}
+
 // Flush flushes pending telemetry data
 func (c *Client) Flush(ctx context.Context) error {
-	return c.tracer.Flush(ctx)
+	if c.tracer.bulk != nil {
+		return c.flushBulk(ctx)
+	}
+	err := c.tracer.Flush(ctx)
+	if usageErr := c.flushUsage(ctx); err == nil {
+		err = usageErr
+	}
+	return err
 }
 
 // Tracer handles OpenTelemetry tracing
 type Tracer struct {
-	config Config
-	spans  []*Span
+	config     Config
+	httpClient *http.Client
+	bulk       *bulkBuffer // set when spans share requests with signals and usage
+	writeMu    sync.Mutex  // serializes batches written to Config.ExportWriter
+	aliases    *aliasTable // the client's, resolving Config.CustomerID; nil when standalone
+
+	msgpackRejected int32 // set once the collector answers 415 to MessagePack
+
+	mu            sync.Mutex
+	spans         []*Span
+	bufferedBytes int64
+	space         chan struct{} // closed whenever a flush frees buffer space
+	seen          uint64        // spans offered since the buffer last had room, for sampling
+	dropped       uint64
+	// droppedSignals counts signals discarded from a full bulk buffer
+	droppedSignals uint64
+
+	metrics exportMetrics
+
+	// flushLock holds one token, taken by the running flush; unlike a mutex,
+	// waiting for it gives up when the waiter's context ends
+	flushLock chan struct{}
+	flushing  int32
+	// background is the context of flushes the tracer starts itself, canceled by Shutdown
+	background     context.Context
+	stopBackground context.CancelFunc
 }
 
-// Span represents an OpenTelemetry span
+// Span represents an OpenTelemetry span. Its methods are safe for concurrent use;
+// the exported fields must not be read while other goroutines mutate the span.
 type Span struct {
-	Name       string
-	TraceID    string
-	SpanID     string
+	Name         string
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	StartTime    int64
+	EndTime      int64
+	Status       map[string]interface{}
+	Events       []SpanEvent
+	// Attributes mirrors the attributes of spans from Tracer.Start and
+	// Tracer.StartSpan for code written against the map API; entries written to
+	// it before the span is exported are exported too. SDK-owned spans leave it nil.
+	//
+	// Deprecated: use SetAttributes and AttributeMap.
 	Attributes map[string]interface{}
-	StartTime  int64
-	EndTime    int64
-	Status     map[string]interface{}
+
+	mu         sync.Mutex
+	attributes []attribute.KeyValue
+	recyclable bool
+	sampled    bool // exported individually rather than folded into usage aggregates
+	clock      Clock
+	started    time.Time // keeps the monotonic reading that StartTime drops
+
+	bufferedSize int
+
+	// version counts mutations so a cached encoding is reused only while current
+	version        uint64
+	encoded        []byte
+	encodedVersion uint64
+}
+
+// SpanEvent is a timestamped annotation on a span
+type SpanEvent struct {
+	Name       string
+	Time       int64
+	Attributes []attribute.KeyValue
 }
 
 // NewTracer creates a new tracer
 func NewTracer(config Config) *Tracer {
-	return &Tracer{
-		config: config,
-		spans:  make([]*Span, 0),
+	config.useUnixSocket()
+	httpClient := exportHTTPClient(config, newHTTPClient(config.Transport, 10*time.Second))
+	if config.DryRun {
+		httpClient.Transport = dryRunTransport{debug: config.Debug}
+	}
+	if config.Disabled {
+		httpClient.Transport = newMock(config.BaseURL)
 	}
+	t := &Tracer{
+		config:     config,
+		spans:      make([]*Span, 0),
+		space:      make(chan struct{}),
+		httpClient: httpClient,
+		flushLock:  make(chan struct{}, 1),
+	}
+	t.background, t.stopBackground = context.WithCancel(context.Background())
+	if config.BulkExport && config.ExportWriter == nil && config.Publisher == nil {
+		t.bulk = &bulkBuffer{limit: t.maxQueueSize()}
+	}
+	return t
 }
 
-// StartSpan starts a new span
+// StartSpan starts a new span from untyped attributes
 func (t *Tracer) StartSpan(name string, attributes map[string]interface{}) *Span {
-	traceID := uuid.New().String()
-	spanID := uuid.New().String()[:16]
+	return t.StartSpanContext(context.Background(), name, attributes)
+}
 
-	attributes["service.name"] = "agentbill-go-sdk"
-	if t.config.CustomerID != "" {
-		attributes["customer.id"] = t.config.CustomerID
+// StartSpanContext starts a new span from untyped attributes, carrying the attribution stored in ctx
+func (t *Tracer) StartSpanContext(ctx context.Context, name string, attributes map[string]interface{}) *Span {
+	kvs := make([]attribute.KeyValue, 0, len(attributes))
+	for k, v := range attributes {
+		kvs = append(kvs, attribute.Any(k, v))
+	}
+	return t.Start(ctx, name, kvs...)
+}
+
+// Start starts a new span with typed attributes, carrying the attribution stored in ctx
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...attribute.KeyValue) *Span {
+	return t.start(ctx, name, false, attrs)
+}
+
+// startInternal starts a span owned by the SDK; it is returned to the span pool once
+// exported, so callers must not retain it past End
+func (t *Tracer) startInternal(ctx context.Context, name string, attrs ...attribute.KeyValue) *Span {
+	return t.start(ctx, name, true, attrs)
+}
+
+func (t *Tracer) start(ctx context.Context, name string, recyclable bool, attrs []attribute.KeyValue) *Span {
+	ids := t.config.idGenerator()
+
+	span := newSpan()
+	span.Name = name
+	span.SpanID = ids.NewSpanID()
+	span.clock = t.config.stopwatch()
+	span.started = span.clock.Now()
+	span.StartTime = span.started.Add(t.config.clockOffset()).UnixNano()
+	span.Status = map[string]interface{}{"code": 0}
+	span.recyclable = recyclable
+	if !recyclable {
+		span.Attributes = make(map[string]interface{}, len(attrs)+4)
+	}
+	span.SetAttributes(attrs...)
+
+	tc := TraceContextFromContext(ctx)
+	if tc.TraceID != "" {
+		span.TraceID = tc.TraceID
+		span.ParentSpanID = tc.SpanID
+	} else {
+		span.TraceID = ids.NewTraceID()
+	}
+	span.sampled = t.shouldSample(span.TraceID) && !(recyclable && t.config.AggregateUsage)
+	if tc.SessionID != "" {
+		span.SetAttributes(attribute.String("session.id", tc.SessionID))
 	}
 
-	span := &Span{
-		Name:       name,
-		TraceID:    traceID,
-		SpanID:     spanID,
-		Attributes: attributes,
-		StartTime:  time.Now().UnixNano(),
-		Status:     map[string]interface{}{"code": 0},
+	span.SetAttributes(attribute.String("service.name", sdkServiceName))
+	if t.config.CustomerID != "" {
+		span.SetAttributes(attribute.String("customer.id", t.aliases.resolve(t.config.CustomerID)))
 	}
+	applyContextAttributes(ctx, span)
 
-	t.spans = append(t.spans, span)
+	if span.sampled {
+		t.enqueue(ctx, span)
+	}
 	return span
 }
 
-// SetAttribute sets an attribute on the span
+// SetAttributes sets typed attributes on the span, replacing existing keys
+func (s *Span) SetAttributes(attrs ...attribute.KeyValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, kv := range attrs {
+		if s.Attributes != nil {
+			s.Attributes[kv.Key] = kv.Value.AsInterface()
+		}
+		replaced := false
+		for i := range s.attributes {
+			if s.attributes[i].Key == kv.Key {
+				s.attributes[i].Value = kv.Value
+				s.version++
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.attributes = append(s.attributes, kv)
+			s.version++
+		}
+	}
+}
+
+// SetAttribute sets an untyped attribute on the span
 func (s *Span) SetAttribute(key string, value interface{}) {
-	s.Attributes[key] = value
+	s.SetAttributes(attribute.Any(key, value))
+}
+
+// AttributeMap returns a copy of the span's attributes as an untyped map
+func (s *Span) AttributeMap() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.syncAttributeMap()
+	attributes := make(map[string]interface{}, len(s.attributes))
+	for _, kv := range s.attributes {
+		attributes[kv.Key] = kv.Value.AsInterface()
+	}
+	return attributes
+}
+
+// syncAttributeMap takes in writes made directly to the deprecated Attributes
+// map: its values replace typed ones, keys missing from it are removed and new
+// keys are appended in sorted order. s.mu must be held.
+func (s *Span) syncAttributeMap() {
+	if s.Attributes == nil {
+		return
+	}
+	synced := make([]attribute.KeyValue, 0, len(s.Attributes))
+	for _, kv := range s.attributes {
+		if value, ok := s.Attributes[kv.Key]; ok {
+			synced = append(synced, attribute.Any(kv.Key, value))
+		}
+	}
+	if len(synced) < len(s.Attributes) {
+		added := make([]string, 0, len(s.Attributes)-len(synced))
+		for key := range s.Attributes {
+			if !s.hasAttribute(synced, key) {
+				added = append(added, key)
+			}
+		}
+		sort.Strings(added)
+		for _, key := range added {
+			synced = append(synced, attribute.Any(key, s.Attributes[key]))
+		}
+	}
+	s.attributes = synced
+}
+
+func (s *Span) hasAttribute(attrs []attribute.KeyValue, key string) bool {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return true
+		}
+	}
+	return false
 }
 
 // SetStatus sets the status of the span
 func (s *Span) SetStatus(code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	s.Status = map[string]interface{}{
 		"code":    code,
 		"message": message,
 	}
+	s.version++
+}
+
+// AddEvent records a named event on the span
+func (s *Span) AddEvent(name string, attrs ...attribute.KeyValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Events = append(s.Events, SpanEvent{
+		Name:       name,
+		Time:       s.now(),
+		Attributes: attrs,
+	})
+	s.version++
 }
 
-// End ends the span
+// End ends the span; calls after the first have no effect
 func (s *Span) End() {
-	s.EndTime = time.Now().UnixNano()
+	s.mu.Lock()
+	if s.EndTime != 0 {
+		s.mu.Unlock()
+		return
+	}
+	s.EndTime = s.now()
+	s.version++
+	sampled := s.sampled
+	s.mu.Unlock()
+
+	if !sampled {
+		s.release()
+	}
+}
+
+// now returns the current time on the span's timeline. Offsets are measured
+// from the start time, so a wall-clock step mid-span cannot distort its
+// duration; spans built by hand fall back to wall time.
+func (s *Span) now() int64 {
+	if s.clock == nil || s.started.IsZero() {
+		return time.Now().UnixNano()
+	}
+	return s.StartTime + int64(s.clock.Now().Sub(s.started))
+}
+
+// ended reports whether the span has ended, or has been open too long to keep waiting on
+func (s *Span) ended(now int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.EndTime != 0 || now-s.StartTime >= int64(maxOpenSpanAge)
 }
 
-// Flush sends spans to AgentBill
+// Flush sends spans to AgentBill, splitting batches larger than Config.MaxExportBytes
+// into several requests. It returns ctx's error without sending if ctx ends
+// while an earlier flush is still running; spans an interrupted export did not
+// deliver stay buffered for the next flush.
 func (t *Tracer) Flush(ctx context.Context) error {
-	if len(t.spans) == 0 {
+	select {
+	case t.flushLock <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-t.flushLock }()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = make([]*Span, 0, len(spans))
+	t.bufferedBytes = 0
+	close(t.space) // wake callers blocked on a full buffer
+	t.space = make(chan struct{})
+	t.mu.Unlock()
+
+	if len(spans) == 0 {
 		return nil
 	}
 
-	payload := t.buildOTLPPayload()
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return err
+	limit := t.maxExportBytes()
+	overhead := otlpEnvelopeSize()
+	now := t.config.clock().Now().UnixNano()
+	open := make([]*Span, 0)
+	pending := make([]*Span, 0, len(spans))
+	encoded := make([]json.RawMessage, 0, len(spans))
+	for _, span := range spans {
+		// Open spans stay queued so attributes set before End still land;
+		// spans that are never ended are exported once they go stale
+		if !span.ended(now) {
+			open = append(open, span)
+			continue
+		}
+
+		data, err := t.encodeSpan(span)
+		if err != nil {
+			t.requeue(spans)
+			return err
+		}
+		if overhead+len(data) > limit {
+			// A span that can never fit would otherwise block every later flush
+			if t.config.Debug {
+				fmt.Printf("[AgentBill] Dropping span %s: %d bytes exceeds export limit of %d\n", span.Name, len(data), limit)
+			}
+			span.release()
+			continue
+		}
+		pending = append(pending, span)
+		encoded = append(encoded, data)
 	}
 
-	url := fmt.Sprintf("%s/functions/v1/otel-collector", t.config.BaseURL)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
+	var exportErr error
+	remaining := open
+	for _, chunk := range chunkEncodedSpans(encoded, limit-overhead) {
+		if exportErr == nil {
+			exportErr = ctx.Err()
+		}
+		if exportErr != nil {
+			remaining = append(remaining, pending[chunk[0]:chunk[1]]...)
+			continue
+		}
+		sent, err := t.exportChunk(ctx, encoded[chunk[0]:chunk[1]])
+		for _, span := range pending[chunk[0] : chunk[0]+sent] {
+			span.release()
+		}
+		if err != nil {
+			exportErr = err
+			remaining = append(remaining, pending[chunk[0]+sent:chunk[1]]...)
+		}
 	}
+	t.requeue(remaining)
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.config.APIKey))
-	req.Header.Set("Content-Type", "application/json")
+	return exportErr
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+// exportChunk sends encoded spans with the configured protocol and returns how many
+// leading spans are done with, delivered or discarded. Chunks rejected as too large
+// are split in half until they are accepted; chunks the collector refuses outright
+// are discarded, so they cannot block every later flush.
+func (t *Tracer) exportChunk(ctx context.Context, spans []json.RawMessage) (int, error) {
+	response, err := t.exportWithRetry(ctx, spans)
+	status := response.status
 	if err != nil {
-		return err
+		t.metrics.exportError()
+		return 0, err
 	}
-	defer resp.Body.Close()
 
 	if t.config.Debug {
-		fmt.Printf("AgentBill flush: %d\n", resp.StatusCode)
+		fmt.Printf("AgentBill flush: %d\n", status)
+	}
+	if status != http.StatusOK {
+		t.metrics.exportError()
 	}
 
-	if resp.StatusCode == 200 {
-		t.spans = make([]*Span, 0)
+	switch {
+	case status == http.StatusRequestEntityTooLarge && len(spans) > 1:
+		mid := len(spans) / 2
+		sent, err := t.exportChunk(ctx, spans[:mid])
+		if err != nil {
+			return sent, err
+		}
+		sent, err = t.exportChunk(ctx, spans[mid:])
+		return mid + sent, err
+	case status == http.StatusRequestEntityTooLarge:
+		if t.config.Debug {
+			fmt.Printf("[AgentBill] Dropping span rejected as too large (%d bytes)\n", len(spans[0]))
+		}
+		atomic.AddUint64(&t.dropped, 1)
+		return 1, nil
+	case permanentRejection(status, t.config.retryPolicy()):
+		if t.config.Debug {
+			fmt.Printf("[AgentBill] Dropping %d spans rejected with status %d\n", len(spans), status)
+		}
+		atomic.AddUint64(&t.dropped, uint64(len(spans)))
+		return len(spans), response.apiError(t.config.clock().Now())
+	case status != http.StatusOK:
+		return 0, response.apiError(t.config.clock().Now())
 	}
+	t.metrics.exported(len(spans))
+	return len(spans), nil
+}
 
-	return nil
+// permanentRejection reports whether a collector status means the spans will never
+// be accepted: a client error that policy does not retry, other than an
+// authentication failure or rate limit, which resending can outlast
+func permanentRejection(status int, policy RetryPolicy) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return status >= 400 && status < 500 && !policy.retryable(status)
 }
 
-func (t *Tracer) buildOTLPPayload() map[string]interface{} {
-	spans := make([]map[string]interface{}, len(t.spans))
-	for i, span := range t.spans {
-		spans[i] = t.spanToOTLP(span)
+// exportWithRetry exports spans, retrying transport errors and retryable statuses
+// as Config.Retry directs, after the collector's Retry-After delay or, without
+// one, the policy's backoff
+func (t *Tracer) exportWithRetry(ctx context.Context, spans []json.RawMessage) (exportResponse, error) {
+	policy := t.config.retryPolicy()
+	clock := t.config.stopwatch()
+	for attempt := 1; ; attempt++ {
+		started := clock.Now()
+		response, err := t.exporter().export(ctx, spans)
+		t.metrics.observeExport(clock.Now().Sub(started))
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || (err == nil && !policy.retryable(response.status)) {
+			return response, err
+		}
+		wait := retryAfter(response.header, t.config.clock().Now())
+		if wait > policy.MaxDelay {
+			return response, err
+		}
+		if wait == 0 {
+			wait = policy.backoff(attempt - 1)
+		}
+		if t.config.Debug {
+			fmt.Printf("[AgentBill] Retrying export in %v (attempt %d, status %d, error %v)\n", wait, attempt+1, response.status, err)
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return exportResponse{}, err
+		}
+	}
+}
+
+func (t *Tracer) maxExportBytes() int {
+	if t.config.MaxExportBytes > 0 {
+		return t.config.MaxExportBytes
+	}
+	return defaultMaxExportBytes
+}
+
+func buildOTLPPayload(spans []json.RawMessage) map[string]interface{} {
+	if spans == nil {
+		spans = []json.RawMessage{}
 	}
 
 	return map[string]interface{}{
@@ -295,13 +891,13 @@ func (t *Tracer) buildOTLPPayload() map[string]interface{} {
 			{
 				"resource": map[string]interface{}{
 					"attributes": []map[string]interface{}{
-						{"key": "service.name", "value": map[string]interface{}{"stringValue": "agentbill-go-sdk"}},
-						{"key": "service.version", "value": map[string]interface{}{"stringValue": "1.0.0"}},
+						{"key": "service.name", "value": map[string]interface{}{"stringValue": sdkServiceName}},
+						{"key": "service.version", "value": map[string]interface{}{"stringValue": sdkVersion}},
 					},
 				},
 				"scopeSpans": []map[string]interface{}{
 					{
-						"scope": map[string]interface{}{"name": "agentbill", "version": "1.0.0"},
+						"scope": map[string]interface{}{"name": "agentbill", "version": sdkVersion},
 						"spans": spans,
 					},
 				},
@@ -311,20 +907,23 @@ func (t *Tracer) buildOTLPPayload() map[string]interface{} {
 }
 
 func (t *Tracer) spanToOTLP(span *Span) map[string]interface{} {
-	attributes := make([]map[string]interface{}, 0, len(span.Attributes))
-	for k, v := range span.Attributes {
-		attributes = append(attributes, map[string]interface{}{
-			"key":   k,
-			"value": t.valueToOTLP(v),
-		})
-	}
+	attributes := t.attributesToOTLP(span.attributes)
 
 	endTime := span.EndTime
 	if endTime == 0 {
-		endTime = time.Now().UnixNano()
+		endTime = t.config.clock().Now().UnixNano()
 	}
 
-	return map[string]interface{}{
+	events := make([]map[string]interface{}, 0, len(span.Events))
+	for _, event := range span.Events {
+		events = append(events, map[string]interface{}{
+			"timeUnixNano": fmt.Sprintf("%d", event.Time),
+			"name":         event.Name,
+			"attributes":   t.attributesToOTLP(event.Attributes),
+		})
+	}
+
+	otlpSpan := map[string]interface{}{
 		"traceId":           span.TraceID,
 		"spanId":            span.SpanID,
 		"name":              span.Name,
@@ -332,19 +931,47 @@ func (t *Tracer) spanToOTLP(span *Span) map[string]interface{} {
 		"startTimeUnixNano": fmt.Sprintf("%d", span.StartTime),
 		"endTimeUnixNano":   fmt.Sprintf("%d", endTime),
 		"attributes":        attributes,
+		"events":            events,
 		"status":            span.Status,
 	}
+	if span.ParentSpanID != "" {
+		otlpSpan["parentSpanId"] = span.ParentSpanID
+	}
+	return otlpSpan
 }
 
-func (t *Tracer) valueToOTLP(value interface{}) map[string]interface{} {
-	switch v := value.(type) {
-	case string:
-		return map[string]interface{}{"stringValue": v}
-	case int, int64:
-		return map[string]interface{}{"intValue": v}
-	case bool:
-		return map[string]interface{}{"boolValue": v}
+func (t *Tracer) attributesToOTLP(attrs []attribute.KeyValue) []map[string]interface{} {
+	attributes := make([]map[string]interface{}, 0, len(attrs))
+	for _, kv := range attrs {
+		attributes = append(attributes, map[string]interface{}{
+			"key":   kv.Key,
+			"value": t.valueToOTLP(kv.Value),
+		})
+	}
+	return attributes
+}
+
+func (t *Tracer) valueToOTLP(value attribute.Value) map[string]interface{} {
+	switch value.Type() {
+	case attribute.STRING:
+		return map[string]interface{}{"stringValue": value.AsString()}
+	case attribute.INT64:
+		return map[string]interface{}{"intValue": value.AsInt64()}
+	case attribute.FLOAT64:
+		f := value.AsFloat64()
+		// JSON has no literal for these; OTLP/JSON spells them as strings
+		switch {
+		case math.IsNaN(f):
+			return map[string]interface{}{"doubleValue": "NaN"}
+		case math.IsInf(f, 1):
+			return map[string]interface{}{"doubleValue": "Infinity"}
+		case math.IsInf(f, -1):
+			return map[string]interface{}{"doubleValue": "-Infinity"}
+		}
+		return map[string]interface{}{"doubleValue": f}
+	case attribute.BOOL:
+		return map[string]interface{}{"boolValue": value.AsBool()}
 	default:
-		return map[string]interface{}{"stringValue": fmt.Sprintf("%v", v)}
+		return map[string]interface{}{"stringValue": value.Emit()}
 	}
 }
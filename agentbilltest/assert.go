@@ -0,0 +1,135 @@
+package agentbilltest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	agentbill "github.com/agentbill/agentbill-go"
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// SpanMatcher checks one property of a span, returning a description of the mismatch or ""
+type SpanMatcher func(span agentbill.SpanRecord) string
+
+// WithName matches spans with the given name
+func WithName(name string) SpanMatcher {
+	return func(span agentbill.SpanRecord) string {
+		if span.Name != name {
+			return fmt.Sprintf("name is %q, want %q", span.Name, name)
+		}
+		return ""
+	}
+}
+
+// WithAttr matches spans whose attribute key equals value. Values are compared
+// after the same conversion the SDK applies on export, so ints match int64s.
+func WithAttr(key string, value interface{}) SpanMatcher {
+	want := attribute.Any(key, value).Value.AsInterface()
+	return func(span agentbill.SpanRecord) string {
+		got, ok := span.Attributes[key]
+		if !ok {
+			return fmt.Sprintf("attribute %q is missing", key)
+		}
+		if got != want {
+			return fmt.Sprintf("attribute %q is %v, want %v", key, got, want)
+		}
+		return ""
+	}
+}
+
+// WithAttrKey matches spans that carry the attribute key with any value
+func WithAttrKey(key string) SpanMatcher {
+	return func(span agentbill.SpanRecord) string {
+		if _, ok := span.Attributes[key]; !ok {
+			return fmt.Sprintf("attribute %q is missing", key)
+		}
+		return ""
+	}
+}
+
+// WithStatus matches spans with the given status code (0 ok, 1 error)
+func WithStatus(code int) SpanMatcher {
+	return func(span agentbill.SpanRecord) string {
+		if span.StatusCode != code {
+			return fmt.Sprintf("status is %d, want %d", span.StatusCode, code)
+		}
+		return ""
+	}
+}
+
+// WithParent matches spans whose parent is the given span ID
+func WithParent(spanID string) SpanMatcher {
+	return func(span agentbill.SpanRecord) string {
+		if span.ParentSpanID != spanID {
+			return fmt.Sprintf("parent is %q, want %q", span.ParentSpanID, spanID)
+		}
+		return ""
+	}
+}
+
+// WithEvent matches spans that recorded an event with the given name
+func WithEvent(name string) SpanMatcher {
+	return func(span agentbill.SpanRecord) string {
+		for _, event := range span.Events {
+			if event == name {
+				return ""
+			}
+		}
+		return fmt.Sprintf("event %q is missing", name)
+	}
+}
+
+// FindSpans returns the spans satisfying every matcher
+func FindSpans(spans []agentbill.SpanRecord, matchers ...SpanMatcher) []agentbill.SpanRecord {
+	var found []agentbill.SpanRecord
+	for _, span := range spans {
+		if len(mismatches(span, matchers)) == 0 {
+			found = append(found, span)
+		}
+	}
+	return found
+}
+
+// AssertSpan fails the test unless some span satisfies every matcher, and returns
+// the first match. On failure the closest candidate's mismatches are reported.
+func AssertSpan(t testing.TB, spans []agentbill.SpanRecord, matchers ...SpanMatcher) agentbill.SpanRecord {
+	t.Helper()
+
+	var closest []string
+	closestName := ""
+	for _, span := range spans {
+		problems := mismatches(span, matchers)
+		if len(problems) == 0 {
+			return span
+		}
+		if closest == nil || len(problems) < len(closest) {
+			closest, closestName = problems, span.Name
+		}
+	}
+
+	if len(spans) == 0 {
+		t.Errorf("no span matched: no spans were recorded")
+	} else {
+		t.Errorf("no span matched among %d spans; closest %q: %s", len(spans), closestName, strings.Join(closest, "; "))
+	}
+	return agentbill.SpanRecord{}
+}
+
+// AssertNoSpan fails the test if any span satisfies every matcher
+func AssertNoSpan(t testing.TB, spans []agentbill.SpanRecord, matchers ...SpanMatcher) {
+	t.Helper()
+	if found := FindSpans(spans, matchers...); len(found) > 0 {
+		t.Errorf("expected no matching span, found %d (first %q)", len(found), found[0].Name)
+	}
+}
+
+func mismatches(span agentbill.SpanRecord, matchers []SpanMatcher) []string {
+	var problems []string
+	for _, matcher := range matchers {
+		if problem := matcher(span); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
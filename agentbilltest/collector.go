@@ -0,0 +1,213 @@
+// Package agentbilltest provides helpers for testing code instrumented with agentbill
+package agentbilltest
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	agentbill "github.com/agentbill/agentbill-go"
+)
+
+// Request is a raw request received by the collector
+type Request struct {
+	Path          string
+	Authorization string
+	Body          []byte
+	Status        int
+}
+
+// CollectorServer is an httptest server implementing the AgentBill collector,
+// signal and usage endpoints. Point Config.BaseURL at URL.
+type CollectorServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []Request
+	spans    []agentbill.SpanRecord
+	signals  []agentbill.Signal
+	usage    []agentbill.UsageRecord
+	failures []int
+	latency  time.Duration
+}
+
+// NewCollectorServer starts a collector; call Close when done
+func NewCollectorServer() *CollectorServer {
+	c := &CollectorServer{}
+	c.Server = httptest.NewServer(http.HandlerFunc(c.handle))
+	return c
+}
+
+// Config returns a client config pointed at the collector
+func (c *CollectorServer) Config() agentbill.Config {
+	return agentbill.Config{APIKey: "test-key", BaseURL: c.URL, CustomerID: "test-customer"}
+}
+
+// FailNext answers the next n requests with status instead of recording them
+func (c *CollectorServer) FailNext(n int, status int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < n; i++ {
+		c.failures = append(c.failures, status)
+	}
+}
+
+// SetLatency delays every response by d, for exercising timeouts and backpressure
+func (c *CollectorServer) SetLatency(d time.Duration) {
+	c.mu.Lock()
+	c.latency = d
+	c.mu.Unlock()
+}
+
+// Requests returns every request received, including failed ones
+func (c *CollectorServer) Requests() []Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Request(nil), c.requests...)
+}
+
+// Spans returns the spans accepted by the collector endpoint
+func (c *CollectorServer) Spans() []agentbill.SpanRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]agentbill.SpanRecord(nil), c.spans...)
+}
+
+// Signals returns the signals accepted by the signal endpoint
+func (c *CollectorServer) Signals() []agentbill.Signal {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]agentbill.Signal(nil), c.signals...)
+}
+
+// UsageRecords returns the usage records accepted by the usage endpoint
+func (c *CollectorServer) UsageRecords() []agentbill.UsageRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]agentbill.UsageRecord(nil), c.usage...)
+}
+
+// Reset clears recorded payloads and pending failures
+func (c *CollectorServer) Reset() {
+	c.mu.Lock()
+	c.requests, c.spans, c.signals, c.usage, c.failures = nil, nil, nil, nil, nil
+	c.mu.Unlock()
+}
+
+func (c *CollectorServer) handle(w http.ResponseWriter, r *http.Request) {
+	// Preflight requests are answered like the hosted endpoints, without recording
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reader = gr
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Header.Get("Content-Type") == "application/msgpack" {
+		if body, err = agentbill.MessagePackToJSON(body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	c.mu.Lock()
+	latency := c.latency
+	status := http.StatusOK
+	if len(c.failures) > 0 {
+		status = c.failures[0]
+		c.failures = c.failures[1:]
+	}
+	c.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if status == http.StatusOK {
+		status = c.record(r.URL.Path, r.Header.Get("Content-Type"), body)
+	}
+
+	c.mu.Lock()
+	c.requests = append(c.requests, Request{
+		Path:          r.URL.Path,
+		Authorization: r.Header.Get("Authorization"),
+		Body:          body,
+		Status:        status,
+	})
+	c.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte("{}"))
+}
+
+func (c *CollectorServer) record(path, contentType string, body []byte) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if contentType == "application/x-ndjson" {
+		spans, signals, usage, err := agentbill.DecodeNDJSON(body)
+		if err != nil {
+			return http.StatusBadRequest
+		}
+		c.spans = append(c.spans, spans...)
+		c.signals = append(c.signals, signals...)
+		c.usage = append(c.usage, usage...)
+		return http.StatusOK
+	}
+
+	switch strings.TrimPrefix(path, "/functions/v1/") {
+	case "otel-collector":
+		spans, err := agentbill.DecodeOTLP(body)
+		if err != nil {
+			return http.StatusBadRequest
+		}
+		c.spans = append(c.spans, spans...)
+	case "record-signals":
+		var signal agentbill.Signal
+		if err := json.Unmarshal(body, &signal); err != nil {
+			return http.StatusBadRequest
+		}
+		c.signals = append(c.signals, signal)
+	case "record-usage":
+		var payload struct {
+			Records []agentbill.UsageRecord `json:"records"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return http.StatusBadRequest
+		}
+		c.usage = append(c.usage, payload.Records...)
+	case "bulk-ingest":
+		spans, signals, usage, err := agentbill.DecodeBulk(body)
+		if err != nil {
+			return http.StatusBadRequest
+		}
+		c.spans = append(c.spans, spans...)
+		c.signals = append(c.signals, signals...)
+		c.usage = append(c.usage, usage...)
+	default:
+		return http.StatusNotFound
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,201 @@
+package agentbilltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RecordMode selects whether a Recorder talks to the real provider
+type RecordMode int
+
+const (
+	// ModeReplay serves responses from the fixture and fails on unknown requests
+	ModeReplay RecordMode = iota
+	// ModeRecord forwards every request and overwrites the fixture
+	ModeRecord
+	// ModeAuto replays when the fixture exists and records otherwise
+	ModeAuto
+)
+
+// Interaction is one recorded provider request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the part of a request used to match replays. Headers are
+// never stored, so fixtures do not leak API keys.
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// RecordedResponse is a stored provider response
+type RecordedResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records provider calls to a fixture file
+// and replays them, so instrumented code can be tested without spending tokens.
+// Install it with Config.ProviderTransport. Replaying still requires a provider
+// key variable such as OPENAI_API_KEY to be set; any placeholder works.
+type Recorder struct {
+	path string
+	mode RecordMode
+	base http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder creates a recorder for the fixture at path. base performs real
+// requests while recording and defaults to http.DefaultTransport.
+func NewRecorder(path string, mode RecordMode, base http.RoundTripper) (*Recorder, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if mode == ModeAuto {
+		mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			mode = ModeReplay
+		}
+	}
+
+	r := &Recorder{path: path, mode: mode, base: base}
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+		}
+		// Fixtures are stored indented and may be edited by hand
+		for i := range r.interactions {
+			r.interactions[i].Request.Body = normalizeJSON(r.interactions[i].Request.Body)
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+	return r, nil
+}
+
+// RoundTrip replays or records a single request
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := RecordedRequest{Method: req.Method, URL: req.URL.String(), Body: normalizeJSON(body)}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, body, recorded)
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Identical requests replay in recorded order
+	for i, interaction := range r.interactions {
+		if r.used[i] || !sameRequest(interaction.Request, recorded) {
+			continue
+		}
+		r.used[i] = true
+		return newResponse(req, interaction.Response), nil
+	}
+	return nil, fmt.Errorf("no recorded interaction for %s %s in %s", recorded.Method, recorded.URL, r.path)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, recorded RecordedRequest) (*http.Response, error) {
+	forwarded := req.Clone(req.Context())
+	forwarded.Body = io.NopCloser(bytes.NewReader(body))
+	forwarded.ContentLength = int64(len(body))
+
+	resp, err := r.base.RoundTrip(forwarded)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	response := RecordedResponse{Status: resp.StatusCode, Body: normalizeJSON(respBody)}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{Request: recorded, Response: response})
+	err = r.save()
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// save rewrites the fixture after every interaction so an aborted test still leaves a usable file
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+func sameRequest(a, b RecordedRequest) bool {
+	return a.Method == b.Method && a.URL == b.URL && bytes.Equal(a.Body, b.Body)
+}
+
+// normalizeJSON compacts JSON bodies so formatting differences do not break matching.
+// Non-JSON bodies are stored as JSON strings.
+func normalizeJSON(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		quoted, _ := json.Marshal(string(body))
+		return quoted
+	}
+	normalized, _ := json.Marshal(value)
+	return normalized
+}
+
+func newResponse(req *http.Request, recorded RecordedResponse) *http.Response {
+	body := []byte(recorded.Body)
+	var text string
+	if json.Unmarshal(body, &text) == nil {
+		body = []byte(text)
+	}
+	return &http.Response{
+		StatusCode:    recorded.Status,
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
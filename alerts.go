@@ -0,0 +1,68 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+)
+
+// AlertSensitivity sets how far spend must stray from its usual pattern before
+// an anomaly alert fires
+type AlertSensitivity string
+
+// Alert sensitivities
+const (
+	SensitivityLow    AlertSensitivity = "low"
+	SensitivityMedium AlertSensitivity = "medium"
+	SensitivityHigh   AlertSensitivity = "high"
+)
+
+// AlertChannel is where an alert is delivered
+type AlertChannel struct {
+	// Type is "email", "slack", "webhook" or "pagerduty"
+	Type string `json:"type"`
+	// Target is the address, channel, URL or routing key for Type
+	Target string `json:"target"`
+}
+
+// AlertRule notifies channels when spend is anomalous or passes a threshold
+type AlertRule struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+	// CustomerID limits the rule to one customer; empty watches every customer
+	CustomerID string `json:"customer_id,omitempty"`
+	// ThresholdUSD fires the alert when daily spend exceeds it, anomalous or not; 0 disables it
+	ThresholdUSD float64          `json:"threshold_usd,omitempty"`
+	Sensitivity  AlertSensitivity `json:"sensitivity,omitempty"`
+	Channels     []AlertChannel   `json:"channels"`
+	CreatedAt    time.Time        `json:"created_at,omitempty"`
+}
+
+// CreateAlertRule creates a spend-anomaly alert rule and returns it with its ID
+func (c *Client) CreateAlertRule(ctx context.Context, rule AlertRule) (*AlertRule, error) {
+	if len(rule.Channels) == 0 {
+		return nil, errors.New("alert rule needs at least one channel")
+	}
+	var created AlertRule
+	if err := c.callJSON(ctx, "POST", "alert-rules", nil, rule, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ListAlertRules returns the alert rules visible to the API key
+func (c *Client) ListAlertRules(ctx context.Context) ([]AlertRule, error) {
+	var response struct {
+		Rules []AlertRule `json:"rules"`
+	}
+	if err := c.getJSON(ctx, "alert-rules", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Rules, nil
+}
+
+// DeleteAlertRule deletes an alert rule by ID
+func (c *Client) DeleteAlertRule(ctx context.Context, id string) error {
+	return c.callJSON(ctx, "DELETE", "alert-rules", url.Values{"id": {id}}, nil, nil)
+}
@@ -0,0 +1,116 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+)
+
+// CustomerAlias links an AgentBill customer to the IDs other systems know it by
+type CustomerAlias struct {
+	CustomerID string `json:"customer_id"`
+	// InternalID is the ID in your own user or account tables
+	InternalID string `json:"internal_id,omitempty"`
+	// StripeID is the Stripe customer ID, cus_...
+	StripeID string `json:"stripe_id,omitempty"`
+}
+
+// aliasTable maps known aliases to AgentBill customer IDs
+type aliasTable struct {
+	mu  sync.RWMutex
+	ids map[string]string
+}
+
+func newAliasTable() *aliasTable {
+	return &aliasTable{ids: make(map[string]string)}
+}
+
+func (t *aliasTable) add(alias CustomerAlias) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, id := range []string{alias.CustomerID, alias.InternalID, alias.StripeID} {
+		if id != "" {
+			t.ids[id] = alias.CustomerID
+		}
+	}
+}
+
+// resolve returns the AgentBill customer ID for a known alias, else id unchanged;
+// a nil table, as on a standalone Tracer, resolves nothing
+func (t *aliasTable) resolve(id string) string {
+	if t == nil || id == "" {
+		return id
+	}
+	if customerID, ok := t.lookup(id); ok {
+		return customerID
+	}
+	return id
+}
+
+func (t *aliasTable) lookup(id string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	customerID, ok := t.ids[id]
+	return customerID, ok
+}
+
+// RegisterAlias records the internal and Stripe IDs of an AgentBill customer.
+// Once registered, any of the IDs may be passed wherever the SDK takes a
+// customer ID, including Signal.CustomerID and Config.CustomerID, and is sent
+// as the AgentBill ID.
+func (c *Client) RegisterAlias(ctx context.Context, alias CustomerAlias) error {
+	if alias.CustomerID == "" {
+		return errors.New("alias customer ID is required")
+	}
+	if alias.InternalID == "" && alias.StripeID == "" {
+		return errors.New("alias needs an internal or Stripe ID")
+	}
+	if err := c.callJSON(ctx, "POST", "customer-aliases", nil, alias, nil); err != nil {
+		return err
+	}
+	c.aliases.add(alias)
+	return nil
+}
+
+// LoadAliases fetches every registered alias, so IDs registered by other
+// services resolve without a lookup. Call it at startup.
+func (c *Client) LoadAliases(ctx context.Context) error {
+	var response struct {
+		Aliases []CustomerAlias `json:"aliases"`
+	}
+	if err := c.getJSON(ctx, "customer-aliases", nil, &response); err != nil {
+		return err
+	}
+	for _, alias := range response.Aliases {
+		c.aliases.add(alias)
+	}
+	return nil
+}
+
+// ResolveCustomer returns the AgentBill customer ID for an AgentBill, internal
+// or Stripe ID, asking AgentBill about IDs not yet known locally. An ID that is
+// not an alias is returned unchanged.
+func (c *Client) ResolveCustomer(ctx context.Context, id string) (string, error) {
+	if customerID, ok := c.aliases.lookup(id); ok {
+		return customerID, nil
+	}
+	var alias CustomerAlias
+	if err := c.getJSON(ctx, "customer-aliases", url.Values{"id": {id}}, &alias); err != nil {
+		return "", err
+	}
+	if alias.CustomerID == "" {
+		return id, nil
+	}
+	c.aliases.add(alias)
+	return alias.CustomerID, nil
+}
+
+// customerID resolves a customer ID from the caller against the registered
+// aliases, defaulting to the client's customer, which is resolved the same way
+func (c *Client) customerID(id string) string {
+	if id == "" {
+		id = c.config.CustomerID
+	}
+	return c.aliases.resolve(id)
+}
@@ -0,0 +1,29 @@
+package agentbill
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConfigCustomerIDResolvesAliases(t *testing.T) {
+	client := Init(Config{APIKey: "mock", CustomerID: "user-42", Disabled: true, AggregateUsage: true})
+	client.aliases.add(CustomerAlias{CustomerID: "cust_1", InternalID: "user-42", StripeID: "cus_9"})
+
+	span := client.tracer.startInternal(context.Background(), "openai.chat.completion")
+	if got := span.AttributeMap()["customer.id"]; got != "cust_1" {
+		t.Errorf("span customer.id = %v, want cust_1", got)
+	}
+
+	client.recordUsage(span, "gpt-4o", "openai", 10, 5)
+	span.End()
+	records := client.usage.drain()
+	if len(records) != 1 || records[0].CustomerID != "cust_1" {
+		t.Errorf("usage records = %+v, want one for cust_1", records)
+	}
+
+	for id, want := range map[string]string{"": "cust_1", "cus_9": "cust_1", "other": "other"} {
+		if got := client.customerID(id); got != want {
+			t.Errorf("customerID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
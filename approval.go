@@ -0,0 +1,109 @@
+package agentbill
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Approval describes a human review an agent is waiting on
+type Approval struct {
+	ID      string
+	TraceID string
+	Data    map[string]interface{}
+}
+
+// ApprovalDecision records who resolved an approval and any billable reviewer time
+type ApprovalDecision struct {
+	Reviewer string
+	Reason   string
+	Revenue  float64
+}
+
+// PendingApproval is an approval that has been requested but not yet resolved.
+// Persist ID, TraceID and RequestedAt and rebuild it with Client.ResumeApproval
+// when the decision arrives in another process.
+type PendingApproval struct {
+	ID          string
+	TraceID     string
+	RequestedAt time.Time
+	EscalatedAt time.Time
+
+	client *Client
+}
+
+// RequestApproval records that an agent paused for human review
+func (c *Client) RequestApproval(ctx context.Context, approval Approval) (*PendingApproval, error) {
+	if approval.ID == "" {
+		return nil, fmt.Errorf("approval ID is required")
+	}
+
+	data := make(map[string]interface{}, len(approval.Data)+1)
+	for k, v := range approval.Data {
+		data[k] = v
+	}
+	data["approval_id"] = approval.ID
+
+	pending := c.ResumeApproval(approval.ID, approval.TraceID, c.config.clock().Now())
+	if err := c.TrackSignal(ctx, Signal{EventName: "approval_requested", TraceID: approval.TraceID, Data: data}); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// ResumeApproval rebuilds a pending approval from persisted state
+func (c *Client) ResumeApproval(id, traceID string, requestedAt time.Time) *PendingApproval {
+	return &PendingApproval{
+		ID:          id,
+		TraceID:     traceID,
+		RequestedAt: requestedAt,
+		client:      c,
+	}
+}
+
+// Approve records that the reviewer approved the request
+func (p *PendingApproval) Approve(ctx context.Context, decision ApprovalDecision) error {
+	return p.resolve(ctx, "approval_approved", decision)
+}
+
+// Reject records that the reviewer rejected the request
+func (p *PendingApproval) Reject(ctx context.Context, decision ApprovalDecision) error {
+	return p.resolve(ctx, "approval_rejected", decision)
+}
+
+// Escalate records that the request was escalated to another reviewer
+func (p *PendingApproval) Escalate(ctx context.Context, escalatedTo string) error {
+	p.EscalatedAt = p.client.config.clock().Now()
+	return p.client.TrackSignal(ctx, Signal{
+		EventName: "approval_escalated",
+		TraceID:   p.TraceID,
+		Data: map[string]interface{}{
+			"approval_id":        p.ID,
+			"escalated_to":       escalatedTo,
+			"escalation_seconds": p.EscalatedAt.Sub(p.RequestedAt).Seconds(),
+		},
+	})
+}
+
+func (p *PendingApproval) resolve(ctx context.Context, eventName string, decision ApprovalDecision) error {
+	data := map[string]interface{}{
+		"approval_id":  p.ID,
+		"wait_seconds": p.client.config.clock().Now().Sub(p.RequestedAt).Seconds(),
+	}
+	if decision.Reviewer != "" {
+		data["reviewer"] = decision.Reviewer
+	}
+	if decision.Reason != "" {
+		data["reason"] = decision.Reason
+	}
+	if !p.EscalatedAt.IsZero() {
+		data["escalation_seconds"] = p.EscalatedAt.Sub(p.RequestedAt).Seconds()
+	}
+
+	return p.client.TrackSignal(ctx, Signal{
+		EventName: eventName,
+		Revenue:   decision.Revenue,
+		TraceID:   p.TraceID,
+		Data:      data,
+	})
+}
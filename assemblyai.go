@@ -0,0 +1,112 @@
+package agentbill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultAssemblyAIModel        = "best"
+	defaultAssemblyAIPollInterval = 3 * time.Second
+)
+
+var assemblyAIProvider = &chatProvider{
+	name:       "assemblyai",
+	service:    "AssemblyAI",
+	baseURL:    "https://api.assemblyai.com/v2",
+	keyEnv:     "ASSEMBLYAI_API_KEY",
+	authHeader: "Authorization",
+}
+
+// AssemblyAIWrapper wraps AssemblyAI transcription jobs
+type AssemblyAIWrapper struct {
+	client       *Client
+	signals      bool
+	pollInterval time.Duration
+}
+
+// WrapAssemblyAI wraps the AssemblyAI API for tracking. The API key is read from ASSEMBLYAI_API_KEY.
+func (c *Client) WrapAssemblyAI() *AssemblyAIWrapper {
+	return &AssemblyAIWrapper{client: c, pollInterval: defaultAssemblyAIPollInterval}
+}
+
+// WithUsageSignals returns a copy of the wrapper that sends a transcription_usage signal per job
+func (w *AssemblyAIWrapper) WithUsageSignals() *AssemblyAIWrapper {
+	wrapped := *w
+	wrapped.signals = true
+	return &wrapped
+}
+
+// WithPollInterval returns a copy of the wrapper that checks queued jobs every interval
+func (w *AssemblyAIWrapper) WithPollInterval(interval time.Duration) *AssemblyAIWrapper {
+	wrapped := *w
+	wrapped.pollInterval = interval
+	return &wrapped
+}
+
+// Transcribe submits a transcription job and polls until it completes, returning
+// the transcript. Uploaded audio is sent to /upload first. The job is metered in
+// audio minutes from audio_duration, priced for the speech model that ran.
+func (w *AssemblyAIWrapper) Transcribe(ctx context.Context, r TranscriptionRequest) (map[string]interface{}, error) {
+	if r.Model == "" {
+		r.Model = defaultAssemblyAIModel
+	}
+	c := w.client
+	return c.trackTranscription(ctx, assemblyAIProvider, r, w.signals, func(ctx context.Context) (transcriptionResult, error) {
+		var result transcriptionResult
+		audioURL := r.AudioURL
+		if r.Audio != nil {
+			resp, err := c.providerRequest(ctx, c.providerHTTP, assemblyAIProvider, "POST", assemblyAIProvider.baseURL+"/upload", "application/octet-stream", r.Audio)
+			if err != nil {
+				return result, err
+			}
+			var upload struct {
+				UploadURL string `json:"upload_url"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&upload)
+			resp.Body.Close()
+			if err != nil {
+				return result, err
+			}
+			audioURL = upload.UploadURL
+		}
+
+		transcript, err := c.providerPost(ctx, assemblyAIProvider, "/transcript", map[string]string{
+			"audio_url":    audioURL,
+			"speech_model": r.Model,
+		})
+		if err != nil {
+			return result, err
+		}
+		id, _ := transcript["id"].(string)
+		for assemblyAIQueued(transcript) {
+			timer := time.NewTimer(w.pollInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return result, ctx.Err()
+			case <-timer.C:
+			}
+			if transcript, err = c.providerGet(ctx, assemblyAIProvider, "/transcript/"+id); err != nil {
+				return result, err
+			}
+		}
+
+		result.response = transcript
+		result.id = id
+		result.model, _ = transcript["speech_model"].(string)
+		result.seconds, _ = transcript["audio_duration"].(float64)
+		if status, _ := transcript["status"].(string); status == "error" {
+			message, _ := transcript["error"].(string)
+			return result, fmt.Errorf("assemblyai: transcript %s failed: %s", id, message)
+		}
+		return result, nil
+	})
+}
+
+func assemblyAIQueued(transcript map[string]interface{}) bool {
+	status, _ := transcript["status"].(string)
+	return status == "queued" || status == "processing"
+}
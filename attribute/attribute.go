@@ -0,0 +1,162 @@
+// Package attribute provides typed span attributes that avoid interface boxing
+// and map allocation on the instrumentation hot path.
+package attribute
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Type identifies the kind of value held by a Value
+type Type int
+
+// Supported attribute value types
+const (
+	INVALID Type = iota
+	STRING
+	INT64
+	FLOAT64
+	BOOL
+)
+
+// Value is a typed attribute value
+type Value struct {
+	vtype   Type
+	numeric uint64
+	str     string
+}
+
+// KeyValue is a typed attribute key and value pair
+type KeyValue struct {
+	Key   string
+	Value Value
+}
+
+// String creates a string attribute
+func String(key, value string) KeyValue {
+	return KeyValue{Key: key, Value: Value{vtype: STRING, str: value}}
+}
+
+// Int creates an integer attribute
+func Int(key string, value int) KeyValue {
+	return Int64(key, int64(value))
+}
+
+// Int64 creates a 64-bit integer attribute
+func Int64(key string, value int64) KeyValue {
+	return KeyValue{Key: key, Value: Value{vtype: INT64, numeric: uint64(value)}}
+}
+
+// Float64 creates a floating point attribute
+func Float64(key string, value float64) KeyValue {
+	return KeyValue{Key: key, Value: Value{vtype: FLOAT64, numeric: math.Float64bits(value)}}
+}
+
+// Bool creates a boolean attribute
+func Bool(key string, value bool) KeyValue {
+	var numeric uint64
+	if value {
+		numeric = 1
+	}
+	return KeyValue{Key: key, Value: Value{vtype: BOOL, numeric: numeric}}
+}
+
+// Any converts an untyped value into an attribute, formatting unsupported types as
+// strings. Unsigned integers above math.MaxInt64 are kept exact as strings.
+func Any(key string, value interface{}) KeyValue {
+	switch v := value.(type) {
+	case string:
+		return String(key, v)
+	case int:
+		return Int64(key, int64(v))
+	case int8:
+		return Int64(key, int64(v))
+	case int16:
+		return Int64(key, int64(v))
+	case int32:
+		return Int64(key, int64(v))
+	case int64:
+		return Int64(key, v)
+	case uint:
+		return Any(key, uint64(v))
+	case uint8:
+		return Int64(key, int64(v))
+	case uint16:
+		return Int64(key, int64(v))
+	case uint32:
+		return Int64(key, int64(v))
+	case uint64:
+		if v > math.MaxInt64 {
+			return String(key, strconv.FormatUint(v, 10))
+		}
+		return Int64(key, int64(v))
+	case float32:
+		return Float64(key, float64(v))
+	case float64:
+		return Float64(key, v)
+	case bool:
+		return Bool(key, v)
+	case KeyValue:
+		return KeyValue{Key: key, Value: v.Value}
+	case Value:
+		return KeyValue{Key: key, Value: v}
+	default:
+		return String(key, fmt.Sprintf("%v", v))
+	}
+}
+
+// Type returns the type of the value
+func (v Value) Type() Type {
+	return v.vtype
+}
+
+// AsString returns the string held by a STRING value
+func (v Value) AsString() string {
+	return v.str
+}
+
+// AsInt64 returns the integer held by an INT64 value
+func (v Value) AsInt64() int64 {
+	return int64(v.numeric)
+}
+
+// AsFloat64 returns the float held by a FLOAT64 value
+func (v Value) AsFloat64() float64 {
+	return math.Float64frombits(v.numeric)
+}
+
+// AsBool returns the boolean held by a BOOL value
+func (v Value) AsBool() bool {
+	return v.numeric != 0
+}
+
+// AsInterface returns the value as an untyped Go value
+func (v Value) AsInterface() interface{} {
+	switch v.vtype {
+	case STRING:
+		return v.str
+	case INT64:
+		return v.AsInt64()
+	case FLOAT64:
+		return v.AsFloat64()
+	case BOOL:
+		return v.AsBool()
+	}
+	return nil
+}
+
+// Emit returns the value formatted as a string
+func (v Value) Emit() string {
+	switch v.vtype {
+	case STRING:
+		return v.str
+	case INT64:
+		return fmt.Sprintf("%d", v.AsInt64())
+	case FLOAT64:
+		return fmt.Sprintf("%g", v.AsFloat64())
+	case BOOL:
+		return fmt.Sprintf("%t", v.AsBool())
+	}
+	return ""
+}
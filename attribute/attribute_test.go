@@ -0,0 +1,32 @@
+package attribute
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAny(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		want  KeyValue
+	}{
+		{"string", "v", String("k", "v")},
+		{"int", -3, Int64("k", -3)},
+		{"uint8", uint8(200), Int64("k", 200)},
+		{"uint64 in range", uint64(math.MaxInt64), Int64("k", math.MaxInt64)},
+		{"uint64 above int64", uint64(math.MaxUint64), String("k", "18446744073709551615")},
+		{"uint above int64", uint(math.MaxInt64) + 1, String("k", "9223372036854775808")},
+		{"float32", float32(0.5), Float64("k", 0.5)},
+		{"bool", true, Bool("k", true)},
+		{"value", Int64("other", 7).Value, Int64("k", 7)},
+		{"other", []int{1, 2}, String("k", "[1 2]")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Any("k", tc.value); got != tc.want {
+				t.Errorf("Any(%#v) = %+v, want %+v", tc.value, got, tc.want)
+			}
+		})
+	}
+}
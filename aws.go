@@ -0,0 +1,313 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+// AWS publishers hand batches to a client the application supplies, normally a thin
+// wrapper over aws-sdk-go-v2. Requests are then signed with the IAM credentials that
+// client resolves (role, instance profile, IRSA), so no AgentBill key is involved.
+
+const (
+	maxSQSBatchEntries   = 10
+	maxSQSBatchBytes     = 256 << 10
+	maxKinesisBatch      = 500
+	maxKinesisBatchBytes = 5 << 20
+
+	defaultKinesisAggregateBytes = 50 << 10
+
+	// defaultPartitionKey stands in for a customer ID where SQS FIFO or Kinesis needs a key
+	defaultPartitionKey = "agentbill"
+)
+
+// SQSMessage is one entry of an SQS SendMessageBatch request
+type SQSMessage struct {
+	// ID is unique within its batch
+	ID   string
+	Body string
+	// GroupID and DeduplicationID are set for FIFO queues only
+	GroupID         string
+	DeduplicationID string
+	// Attributes become String message attributes
+	Attributes map[string]string
+}
+
+// SQSSender sends one SendMessageBatch request of at most ten entries. It should
+// return an error when any entry failed, e.g.
+//
+//	out, err := client.SendMessageBatch(ctx, input)
+//	if err == nil && len(out.Failed) > 0 { err = fmt.Errorf("%d messages failed", len(out.Failed)) }
+type SQSSender interface {
+	SendMessageBatch(ctx context.Context, queueURL string, messages []SQSMessage) error
+}
+
+// SQSOptions configures an SQS publisher
+type SQSOptions struct {
+	Sender   SQSSender
+	QueueURL string
+	// FIFO groups messages by customer ID, keeping each customer's records in
+	// order, and sets each message's Message.ID as its deduplication ID, so
+	// records republished after a failure are not stored twice
+	FIFO bool
+}
+
+type sqsPublisher struct {
+	opts SQSOptions
+}
+
+// NewSQSPublisher returns a Publisher that sends records to an SQS queue, one
+// message per record in batches of up to ten
+func NewSQSPublisher(opts SQSOptions) (Publisher, error) {
+	if opts.Sender == nil {
+		return nil, errors.New("sqs: Sender is required")
+	}
+	if opts.QueueURL == "" {
+		return nil, errors.New("sqs: QueueURL is required")
+	}
+	return sqsPublisher{opts: opts}, nil
+}
+
+func (p sqsPublisher) Publish(ctx context.Context, messages []Message) error {
+	var batch []SQSMessage
+	var size int
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := p.opts.Sender.SendMessageBatch(ctx, p.opts.QueueURL, batch)
+		batch, size = nil, 0
+		return err
+	}
+
+	for _, message := range messages {
+		if len(batch) == maxSQSBatchEntries || size+len(message.Value) > maxSQSBatchBytes {
+			if err := send(); err != nil {
+				return err
+			}
+		}
+		entry := SQSMessage{
+			ID:         strconv.Itoa(len(batch)),
+			Body:       string(message.Value),
+			Attributes: map[string]string{"type": message.Type},
+		}
+		if message.Key != "" {
+			entry.Attributes["customer_id"] = message.Key
+		}
+		if p.opts.FIFO {
+			entry.GroupID = partitionKey(message)
+			entry.DeduplicationID = message.ID
+		}
+		batch = append(batch, entry)
+		size += len(message.Value)
+	}
+	return send()
+}
+
+// KinesisRecord is one record of a Kinesis PutRecords request
+type KinesisRecord struct {
+	PartitionKey string
+	Data         []byte
+}
+
+// KinesisPutter sends one PutRecords request of at most 500 records. It should
+// return an error when FailedRecordCount is non-zero.
+type KinesisPutter interface {
+	PutRecords(ctx context.Context, stream string, records []KinesisRecord) error
+}
+
+// KinesisOptions configures a Kinesis publisher
+type KinesisOptions struct {
+	Putter KinesisPutter
+	Stream string
+	// Aggregate packs each customer's records into KPL aggregated records, so
+	// consumers using the KCL or a KPL deaggregator see the individual records
+	// while far fewer Kinesis records are billed
+	Aggregate bool
+	// MaxAggregateBytes caps the size of an aggregated record (default 50 KiB)
+	MaxAggregateBytes int
+}
+
+type kinesisPublisher struct {
+	opts KinesisOptions
+}
+
+// NewKinesisPublisher returns a Publisher that puts records on a Kinesis data
+// stream partitioned by customer ID
+func NewKinesisPublisher(opts KinesisOptions) (Publisher, error) {
+	if opts.Putter == nil {
+		return nil, errors.New("kinesis: Putter is required")
+	}
+	if opts.Stream == "" {
+		return nil, errors.New("kinesis: Stream is required")
+	}
+	if opts.MaxAggregateBytes <= 0 {
+		opts.MaxAggregateBytes = defaultKinesisAggregateBytes
+	}
+	return kinesisPublisher{opts: opts}, nil
+}
+
+func (p kinesisPublisher) Publish(ctx context.Context, messages []Message) error {
+	var records []KinesisRecord
+	if p.opts.Aggregate {
+		records = aggregateKinesis(messages, p.opts.MaxAggregateBytes)
+	} else {
+		records = make([]KinesisRecord, len(messages))
+		for i, message := range messages {
+			records[i] = KinesisRecord{PartitionKey: partitionKey(message), Data: message.Value}
+		}
+	}
+
+	for len(records) > 0 {
+		n, size := 0, 0
+		for n < len(records) && n < maxKinesisBatch {
+			size += len(records[n].Data) + len(records[n].PartitionKey)
+			if n > 0 && size > maxKinesisBatchBytes {
+				break
+			}
+			n++
+		}
+		if err := p.opts.Putter.PutRecords(ctx, p.opts.Stream, records[:n]); err != nil {
+			return err
+		}
+		records = records[n:]
+	}
+	return nil
+}
+
+func partitionKey(message Message) string {
+	if message.Key == "" {
+		return defaultPartitionKey
+	}
+	return message.Key
+}
+
+// kplMagic prefixes every KPL aggregated record
+var kplMagic = []byte{0xf3, 0x89, 0x9a, 0xc2}
+
+// aggregateKinesis packs messages sharing a partition key into KPL aggregated
+// records of at most maxBytes, preserving their order within each key
+func aggregateKinesis(messages []Message, maxBytes int) []KinesisRecord {
+	var keys []string
+	groups := make(map[string][]Message)
+	for _, message := range messages {
+		key := partitionKey(message)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], message)
+	}
+
+	var records []KinesisRecord
+	for _, key := range keys {
+		var aggregate protoBuffer
+		flush := func() {
+			if len(aggregate) == 0 {
+				return
+			}
+			var header protoBuffer
+			header.string(1, key) // partition_key_table
+			payload := append(header, aggregate...)
+			sum := md5.Sum(payload)
+			data := append(append(append([]byte(nil), kplMagic...), payload...), sum[:]...)
+			records = append(records, KinesisRecord{PartitionKey: key, Data: data})
+			aggregate = nil
+		}
+		for _, message := range groups[key] {
+			var record protoBuffer
+			record.message(3, func(r *protoBuffer) {
+				r.uint(1, 0) // partition_key_index
+				r.bytes(3, message.Value)
+			})
+			if len(aggregate) > 0 && len(aggregate)+len(record)+len(key)+len(kplMagic)+md5.Size+8 > maxBytes {
+				flush()
+			}
+			aggregate = append(aggregate, record...)
+		}
+		flush()
+	}
+	return records
+}
+
+// DeaggregateKinesis returns the records packed into a KPL aggregated record, or
+// data itself when it is not aggregated, for consumers that read Kinesis without
+// the KCL before passing values to Client.Forward
+func DeaggregateKinesis(data []byte) ([][]byte, error) {
+	if len(data) < len(kplMagic)+md5.Size || !bytes.HasPrefix(data, kplMagic) {
+		return [][]byte{data}, nil
+	}
+	payload := data[len(kplMagic) : len(data)-md5.Size]
+	if sum := md5.Sum(payload); !bytes.Equal(sum[:], data[len(data)-md5.Size:]) {
+		return [][]byte{data}, nil
+	}
+
+	var records [][]byte
+	err := readProtoFields(payload, func(field int, value []byte) error {
+		if field != 3 {
+			return nil
+		}
+		return readProtoFields(value, func(field int, value []byte) error {
+			if field == 3 {
+				records = append(records, value)
+			}
+			return nil
+		})
+	})
+	return records, err
+}
+
+var errProtoTruncated = errors.New("protobuf: unexpected end of data")
+
+// readProtoFields calls visit with each length-delimited field of a protobuf
+// message, skipping varint and fixed-width fields
+func readProtoFields(b []byte, visit func(field int, value []byte) error) error {
+	return walkProtoFields(b, nil, visit)
+}
+
+// walkProtoFields is readProtoFields that also passes varint fields to varint when it is set
+func walkProtoFields(b []byte, varint func(field int, value uint64), visit func(field int, value []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errProtoTruncated
+		}
+		b = b[n:]
+		switch key & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return errProtoTruncated
+			}
+			if varint != nil {
+				varint(int(key>>3), v)
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return errProtoTruncated
+			}
+			b = b[8:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return errProtoTruncated
+			}
+			if err := visit(int(key>>3), b[n:n+int(size)]); err != nil {
+				return err
+			}
+			b = b[n+int(size):]
+		case 5:
+			if len(b) < 4 {
+				return errProtoTruncated
+			}
+			b = b[4:]
+		default:
+			return errors.New("protobuf: unsupported wire type")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type recordingPutter struct {
+	records []KinesisRecord
+}
+
+func (p *recordingPutter) PutRecords(_ context.Context, _ string, records []KinesisRecord) error {
+	p.records = append(p.records, records...)
+	return nil
+}
+
+func kinesisMessages(key string, n, size int) []Message {
+	messages := make([]Message, n)
+	for i := range messages {
+		value := bytes.Repeat([]byte{byte('a' + i%26)}, size)
+		messages[i] = Message{Key: key, Value: append([]byte(fmt.Sprintf("%s-%d:", key, i)), value...)}
+	}
+	return messages
+}
+
+func TestKinesisAggregateRoundTrip(t *testing.T) {
+	a := kinesisMessages("customer-a", 3, 10)
+	b := kinesisMessages("customer-b", 2, 10)
+	unkeyed := []Message{{Value: []byte("no key")}}
+	messages := []Message{a[0], b[0], a[1], unkeyed[0], b[1], a[2]}
+
+	records := aggregateKinesis(messages, defaultKinesisAggregateBytes)
+	if len(records) != 3 {
+		t.Fatalf("got %d aggregated records, want one per partition key", len(records))
+	}
+	want := []struct {
+		key      string
+		messages []Message
+	}{
+		{"customer-a", a},
+		{"customer-b", b},
+		{defaultPartitionKey, unkeyed},
+	}
+	for i, record := range records {
+		if record.PartitionKey != want[i].key {
+			t.Errorf("record %d partition key = %q, want %q", i, record.PartitionKey, want[i].key)
+		}
+		if !bytes.HasPrefix(record.Data, kplMagic) {
+			t.Errorf("record %d is missing the KPL magic prefix", i)
+		}
+		values, err := DeaggregateKinesis(record.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expected [][]byte
+		for _, message := range want[i].messages {
+			expected = append(expected, message.Value)
+		}
+		if !reflect.DeepEqual(values, expected) {
+			t.Errorf("record %d deaggregated to %q, want %q", i, values, expected)
+		}
+	}
+}
+
+func TestKinesisAggregateSplitsAtMaxBytes(t *testing.T) {
+	const maxBytes = 512
+	messages := kinesisMessages("customer-a", 20, 100)
+
+	putter := &recordingPutter{}
+	publisher, err := NewKinesisPublisher(KinesisOptions{
+		Putter:            putter,
+		Stream:            "usage",
+		Aggregate:         true,
+		MaxAggregateBytes: maxBytes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := publisher.Publish(context.Background(), messages); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(putter.records) < 2 {
+		t.Fatalf("got %d records, want the batch split across several", len(putter.records))
+	}
+	var values [][]byte
+	for i, record := range putter.records {
+		if len(record.Data) > maxBytes {
+			t.Errorf("record %d is %d bytes, over the %d byte cap", i, len(record.Data), maxBytes)
+		}
+		got, err := DeaggregateKinesis(record.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, got...)
+	}
+	if len(values) != len(messages) {
+		t.Fatalf("deaggregated %d values, want %d", len(values), len(messages))
+	}
+	for i, message := range messages {
+		if !bytes.Equal(values[i], message.Value) {
+			t.Errorf("value %d = %q, want %q", i, values[i], message.Value)
+		}
+	}
+}
+
+func TestDeaggregateKinesisPassthrough(t *testing.T) {
+	aggregated := aggregateKinesis([]Message{{Key: "k", Value: []byte("value")}}, defaultKinesisAggregateBytes)[0].Data
+	corrupt := append([]byte(nil), aggregated...)
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"plain record", []byte(`{"customer_id":"c"}`)},
+		{"shorter than trailer", kplMagic},
+		{"bad checksum", corrupt},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DeaggregateKinesis(tc.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || !bytes.Equal(got[0], tc.data) {
+				t.Errorf("got %q, want the data returned unchanged", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package agentbill
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// BackpressurePolicy controls what happens to new spans when the buffer is full
+type BackpressurePolicy int
+
+const (
+	// BackpressureDrop discards new spans and counts them as dropped. Under every
+	// policy a full buffer starts a background flush.
+	BackpressureDrop BackpressurePolicy = iota
+	// BackpressureBlock makes callers wait up to Config.BlockTimeout for a flush to free space
+	BackpressureBlock
+	// BackpressureSample keeps a uniform sample of overflow spans by randomly replacing buffered ones
+	BackpressureSample
+)
+
+const (
+	defaultMaxQueueSize = 2048
+	defaultBlockTimeout = 100 * time.Millisecond
+)
+
+// DroppedSpans returns the number of spans discarded because the buffer was full
+// or the collector refused them outright
+func (t *Tracer) DroppedSpans() uint64 {
+	return atomic.LoadUint64(&t.dropped)
+}
+
+func (t *Tracer) maxQueueSize() int {
+	if t.config.MaxQueueSize > 0 {
+		return t.config.MaxQueueSize
+	}
+	return defaultMaxQueueSize
+}
+
+// enqueue buffers a span for export, applying the configured backpressure policy
+func (t *Tracer) enqueue(ctx context.Context, span *Span) {
+	span.bufferedSize = span.estimateSize()
+
+	t.mu.Lock()
+	if t.hasRoom(span) {
+		t.push(span)
+		t.seen = 0
+		t.mu.Unlock()
+		return
+	}
+
+	switch t.config.Backpressure {
+	case BackpressureBlock:
+		t.mu.Unlock()
+		t.enqueueBlocking(ctx, span)
+		return
+	case BackpressureSample:
+		t.seen++
+		var evicted *Span
+		if n := rand.Int63n(int64(len(t.spans)) + int64(t.seen)); n < int64(len(t.spans)) && t.fitsInPlaceOf(span, t.spans[n]) {
+			evicted = t.spans[n]
+			t.bufferedBytes += int64(span.bufferedSize - evicted.bufferedSize)
+			t.spans[n] = span
+		}
+		t.mu.Unlock()
+		if evicted != nil {
+			evicted.release()
+		}
+		atomic.AddUint64(&t.dropped, 1)
+	default:
+		t.mu.Unlock()
+		atomic.AddUint64(&t.dropped, 1)
+	}
+	// A full buffer is flushed in the background so spans keep flowing
+	// without waiting for the next Flush
+	t.flushAsync()
+}
+
+// fitsInPlaceOf reports whether replacing old with span keeps the buffer within
+// MaxBufferBytes; t.mu must be held
+func (t *Tracer) fitsInPlaceOf(span, old *Span) bool {
+	maxBytes := int64(t.config.MaxBufferBytes)
+	return maxBytes <= 0 || t.bufferedBytes+int64(span.bufferedSize-old.bufferedSize) <= maxBytes
+}
+
+// hasRoom reports whether span fits within the count and memory limits; t.mu must be held
+func (t *Tracer) hasRoom(span *Span) bool {
+	if len(t.spans) >= t.maxQueueSize() {
+		return false
+	}
+	maxBytes := int64(t.config.MaxBufferBytes)
+	return maxBytes <= 0 || len(t.spans) == 0 || t.bufferedBytes+int64(span.bufferedSize) <= maxBytes
+}
+
+// push appends span to the buffer; t.mu must be held
+func (t *Tracer) push(span *Span) {
+	t.spans = append(t.spans, span)
+	t.bufferedBytes += int64(span.bufferedSize)
+}
+
+func (t *Tracer) enqueueBlocking(ctx context.Context, span *Span) {
+	timeout := t.config.BlockTimeout
+	if timeout <= 0 {
+		timeout = defaultBlockTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		t.mu.Lock()
+		if t.hasRoom(span) {
+			t.push(span)
+			t.mu.Unlock()
+			return
+		}
+		space := t.space
+		t.mu.Unlock()
+
+		t.flushAsync()
+
+		select {
+		case <-space:
+		case <-timer.C:
+			atomic.AddUint64(&t.dropped, 1)
+			return
+		case <-ctx.Done():
+			atomic.AddUint64(&t.dropped, 1)
+			return
+		}
+	}
+}
+
+// flushAsync starts a background flush unless one is already running or the
+// tracer has shut down
+func (t *Tracer) flushAsync() {
+	if t.background.Err() != nil || !atomic.CompareAndSwapInt32(&t.flushing, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&t.flushing, 0)
+		ctx, cancel := context.WithTimeout(t.background, exportTimeout)
+		defer cancel()
+		t.Flush(ctx)
+	}()
+}
+
+// requeue returns unsent spans to the front of the buffer, keeping the newest
+// spans when the combined buffer would exceed its count or memory limit
+func (t *Tracer) requeue(spans []*Span) {
+	if len(spans) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	combined := append(spans, t.spans...)
+	var size int64
+	for _, span := range combined {
+		size += int64(span.bufferedSize)
+	}
+
+	maxBytes := int64(t.config.MaxBufferBytes)
+	overflow := 0
+	for overflow < len(combined)-1 &&
+		(len(combined)-overflow > t.maxQueueSize() || (maxBytes > 0 && size > maxBytes)) {
+		size -= int64(combined[overflow].bufferedSize)
+		overflow++
+	}
+	if overflow > 0 {
+		atomic.AddUint64(&t.dropped, uint64(overflow))
+	}
+	t.spans = combined[overflow:]
+	t.bufferedBytes = size
+}
+
+// Rough per-span overhead covering the struct, status map and attributes set after enqueue
+const spanBaseSize = 512
+
+// estimateSize approximates the memory held by a buffered span
+func (s *Span) estimateSize() int {
+	size := spanBaseSize + len(s.Name) + len(s.TraceID) + len(s.SpanID) + len(s.ParentSpanID) + cap(s.encoded)
+	for _, kv := range s.attributes {
+		size += 48 + len(kv.Key) + len(kv.Value.AsString())
+	}
+	for _, event := range s.Events {
+		size += 64 + len(event.Name)
+		for _, kv := range event.Attributes {
+			size += 48 + len(kv.Key) + len(kv.Value.AsString())
+		}
+	}
+	return size
+}
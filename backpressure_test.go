@@ -0,0 +1,63 @@
+package agentbill
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+func TestBackpressureDropFlushesFullBuffer(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(Config{APIKey: "test", BaseURL: server.URL, MaxQueueSize: 4, Backpressure: BackpressureDrop})
+	defer tracer.Shutdown()
+	for i := 0; i < 5; i++ {
+		tracer.startInternal(context.Background(), "span").End()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&requests) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("a full buffer did not start a background flush")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBackpressureSampleRespectsMaxBufferBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	const maxBytes = 8 << 10
+	tracer := NewTracer(Config{APIKey: "test", BaseURL: server.URL, MaxBufferBytes: maxBytes, Backpressure: BackpressureSample})
+	defer tracer.Shutdown()
+	ctx := context.Background()
+	for i := 0; i < 50; i++ {
+		tracer.startInternal(ctx, "small").End()
+	}
+	large := strings.Repeat("x", 2<<10)
+	for i := 0; i < 50; i++ {
+		tracer.startInternal(ctx, "large", attribute.String("payload", large)).End()
+
+		tracer.mu.Lock()
+		buffered, spans := tracer.bufferedBytes, len(tracer.spans)
+		tracer.mu.Unlock()
+		if spans > 1 && buffered > maxBytes {
+			t.Fatalf("buffer holds %d bytes after sampling, over MaxBufferBytes %d", buffered, maxBytes)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package agentbill
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+func BenchmarkStartSpan(b *testing.B) {
+	tracer := NewTracer(Config{CustomerID: "customer-123", MaxQueueSize: b.N + 1})
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tracer.startInternal(ctx, "openai.chat.completion",
+			attribute.String("model", "gpt-4o-mini"),
+			attribute.String("provider", "openai"),
+		).End()
+	}
+}
+
+func BenchmarkSetAttributes(b *testing.B) {
+	tracer := NewTracer(Config{})
+	span := tracer.Start(context.Background(), "bench")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		span.SetAttributes(
+			attribute.Int("response.prompt_tokens", i),
+			attribute.Int("response.completion_tokens", i),
+			attribute.Int64("latency_ms", int64(i)),
+		)
+	}
+}
+
+func BenchmarkSetAttributeMap(b *testing.B) {
+	tracer := NewTracer(Config{})
+	span := tracer.Start(context.Background(), "bench")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		span.SetAttribute("response.prompt_tokens", i)
+		span.SetAttribute("response.completion_tokens", i)
+		span.SetAttribute("latency_ms", int64(i))
+	}
+}
+
+func BenchmarkFlush(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	client := Init(Config{APIKey: "test", BaseURL: server.URL, CustomerID: "customer-123"})
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			span := client.tracer.startInternal(ctx, "openai.chat.completion",
+				attribute.String("model", "gpt-4o-mini"),
+				attribute.String("provider", "openai"),
+			)
+			span.SetAttributes(
+				attribute.Int("response.prompt_tokens", 120),
+				attribute.Int("response.completion_tokens", 48),
+				attribute.Int64("latency_ms", 350),
+			)
+			span.End()
+		}
+		if err := client.Flush(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package agentbill
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// BudgetEnforcement is what AgentBill does about a customer's spend against budget
+type BudgetEnforcement string
+
+// Budget enforcement states
+const (
+	// EnforcementNone means no budget applies or spend is within it
+	EnforcementNone BudgetEnforcement = "none"
+	// EnforcementWarn means spend passed the warning threshold
+	EnforcementWarn BudgetEnforcement = "warn"
+	// EnforcementBlock means the budget is exhausted and calls should be refused
+	EnforcementBlock BudgetEnforcement = "block"
+)
+
+// BudgetStatus is a customer's spend against budget for the current budget period
+type BudgetStatus struct {
+	CustomerID   string            `json:"customer_id"`
+	LimitUSD     float64           `json:"limit_usd"`
+	SpentUSD     float64           `json:"spent_usd"`
+	RemainingUSD float64           `json:"remaining_usd"`
+	ResetsAt     time.Time         `json:"resets_at"`
+	Enforcement  BudgetEnforcement `json:"enforcement"`
+	AsOf         time.Time         `json:"as_of"`
+}
+
+// GetBudgetStatus returns a customer's current spend, remaining budget, reset
+// date and enforcement state. customerID defaults to the client's customer.
+func (c *Client) GetBudgetStatus(ctx context.Context, customerID string) (*BudgetStatus, error) {
+	params := url.Values{}
+	c.setCustomer(params, customerID)
+
+	var status BudgetStatus
+	if err := c.getJSON(ctx, "budget-status", params, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// BudgetGuardrail returns a hook that blocks calls while the client's customer
+// is in EnforcementBlock, as reported by GetBudgetStatus. Statuses are reused
+// for up to maxAge; if a refresh fails, the last known status stands, and with
+// none known the error fails the call.
+func (c *Client) BudgetGuardrail(maxAge time.Duration) GuardrailHook {
+	var mu sync.Mutex
+	var status *BudgetStatus
+	var fetched time.Time
+
+	return func(ctx context.Context, model string, messages []map[string]string) (*GuardrailEvent, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if now := c.config.clock().Now(); status == nil || now.Sub(fetched) >= maxAge {
+			latest, err := c.GetBudgetStatus(ctx, "")
+			switch {
+			case err == nil:
+				status, fetched = latest, now
+			case status == nil:
+				return nil, err
+			}
+		}
+		if status.Enforcement != EnforcementBlock {
+			return nil, nil
+		}
+		return &GuardrailEvent{
+			Policy: "budget",
+			Action: GuardrailBlockedPrompt,
+			Reason: fmt.Sprintf("budget of $%.2f exhausted until %s", status.LimitUSD, status.ResetsAt.UTC().Format(time.RFC3339)),
+		}, nil
+	}
+}
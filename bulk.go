@@ -0,0 +1,209 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// bulkBuffer holds signals and usage records until they ride along with the next
+// span export, or are sent on their own at the end of a flush
+type bulkBuffer struct {
+	limit int
+
+	mu      sync.Mutex
+	signals []Signal
+	usage   []UsageRecord
+}
+
+// addSignal buffers a signal, discarding the oldest once limit signals are
+// waiting, and returns how many were discarded
+func (b *bulkBuffer) addSignal(signal Signal) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.signals = append(b.signals, signal)
+	overflow := len(b.signals) - b.limit
+	if overflow <= 0 {
+		return 0
+	}
+	b.signals = append(b.signals[:0:0], b.signals[overflow:]...)
+	return overflow
+}
+
+// addUsage keeps usage records without a bound; they are already aggregated per minute
+func (b *bulkBuffer) addUsage(records ...UsageRecord) {
+	b.mu.Lock()
+	b.usage = append(b.usage, records...)
+	b.mu.Unlock()
+}
+
+func (b *bulkBuffer) take() ([]Signal, []UsageRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	signals, usage := b.signals, b.usage
+	b.signals, b.usage = nil, nil
+	return signals, usage
+}
+
+// restore puts back items whose export failed, ahead of anything added since
+func (b *bulkBuffer) restore(signals []Signal, usage []UsageRecord) {
+	b.mu.Lock()
+	b.signals = append(signals, b.signals...)
+	b.usage = append(usage, b.usage...)
+	b.mu.Unlock()
+}
+
+func (b *bulkBuffer) pending() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.signals) > 0 || len(b.usage) > 0
+}
+
+// bulkExporter posts spans together with any buffered signals and usage records
+type bulkExporter struct {
+	tracer *Tracer
+}
+
+func (e bulkExporter) export(ctx context.Context, spans []json.RawMessage) (exportResponse, error) {
+	t := e.tracer
+	signals, usage := t.bulk.take()
+	response, err := e.send(ctx, spans, signals, usage)
+	if err != nil || response.status != http.StatusOK {
+		t.bulk.restore(signals, usage)
+	}
+	return response, err
+}
+
+func (e bulkExporter) send(ctx context.Context, spans []json.RawMessage, signals []Signal, usage []UsageRecord) (exportResponse, error) {
+	t := e.tracer
+	buf := getBuffer()
+	defer putBuffer(buf)
+	protocol := t.exportProtocol()
+	contentType := "application/json"
+	if protocol == ExportHTTPNDJSON {
+		contentType = "application/x-ndjson"
+		if err := writeNDJSON(buf, spans, signals, usage); err != nil {
+			return exportResponse{}, err
+		}
+	} else if err := writeBulkJSON(buf, spans, signals, usage); err != nil {
+		return exportResponse{}, err
+	}
+
+	payload := buf.Bytes()
+	if protocol == ExportHTTPMsgPack {
+		contentType = "application/msgpack"
+		var err error
+		if payload, err = jsonToMsgPack(payload); err != nil {
+			return exportResponse{}, err
+		}
+	}
+	compress := t.config.ExportCompression == CompressionGzip
+	if compress {
+		var err error
+		if payload, err = gzipBytes(payload); err != nil {
+			return exportResponse{}, err
+		}
+	}
+	// The transport may still be reading the body after Do returns, when buf is
+	// already back in the pool; MessagePack and gzip payloads are copies already
+	if protocol != ExportHTTPMsgPack && !compress {
+		payload = bytes.Clone(payload)
+	}
+
+	url := fmt.Sprintf("%s/functions/v1/bulk-ingest", t.config.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return exportResponse{}, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if err := t.setExportAuth(req); err != nil {
+		return exportResponse{}, err
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return exportResponse{}, err
+	}
+	closeBody(resp.Body)
+
+	if resp.StatusCode == http.StatusUnsupportedMediaType && protocol == ExportHTTPMsgPack {
+		t.rejectMsgPack()
+		return e.send(ctx, spans, signals, usage)
+	}
+	if t.config.Debug && resp.StatusCode == http.StatusOK && (len(signals) > 0 || len(usage) > 0) {
+		fmt.Printf("[AgentBill] Bulk export: %d spans, %d signals, %d usage records\n", len(spans), len(signals), len(usage))
+	}
+	return exportResponse{status: resp.StatusCode, header: resp.Header}, nil
+}
+
+func writeBulkJSON(w io.Writer, spans []json.RawMessage, signals []Signal, usage []UsageRecord) error {
+	if signals == nil {
+		signals = []Signal{}
+	}
+	if usage == nil {
+		usage = []UsageRecord{}
+	}
+	io.WriteString(w, `{"traces":`)
+	if err := writeOTLPPayload(w, spans); err != nil {
+		return err
+	}
+	io.WriteString(w, `,"signals":`)
+	if err := json.NewEncoder(w).Encode(signals); err != nil {
+		return err
+	}
+	io.WriteString(w, `,"usage_records":`)
+	if err := json.NewEncoder(w).Encode(usage); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "}")
+	return err
+}
+
+// flushBulk sends everything buffered in as few bulk requests as the export size
+// limit allows; signals and usage records travel with the first span batch
+func (c *Client) flushBulk(ctx context.Context) error {
+	c.tracer.bulk.addUsage(c.usage.drain()...)
+	if err := c.tracer.Flush(ctx); err != nil {
+		return err
+	}
+	if !c.tracer.bulk.pending() {
+		return nil
+	}
+
+	// No span batch was left to carry them
+	response, err := bulkExporter{tracer: c.tracer}.export(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if response.status != http.StatusOK {
+		return response.apiError(c.config.clock().Now())
+	}
+	return nil
+}
+
+// DecodeBulk parses a bulk-ingest request into its spans, signals and usage records
+func DecodeBulk(data []byte) ([]SpanRecord, []Signal, []UsageRecord, error) {
+	var payload struct {
+		Traces       json.RawMessage `json:"traces"`
+		Signals      []Signal        `json:"signals"`
+		UsageRecords []UsageRecord   `json:"usage_records"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, nil, nil, err
+	}
+	var spans []SpanRecord
+	if len(payload.Traces) > 0 {
+		var err error
+		if spans, err = DecodeOTLP(payload.Traces); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return spans, payload.Signals, payload.UsageRecords, nil
+}
@@ -0,0 +1,158 @@
+package agentbill
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// Cache stores completion responses keyed by normalized prompt.
+// Implementations backed by Redis or similar stores must be safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// MemoryCache is an in-process LRU cache
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type memoryCacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryCache creates an in-memory cache holding at most maxEntries responses
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the cached value for key if present and unexpired
+func (m *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		m.order.Remove(elem)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	m.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set stores value under key, evicting the least recently used entry when full
+func (m *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if elem, ok := m.entries[key]; ok {
+		entry := elem.Value.(*memoryCacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(elem)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryCacheEntry{key: key, value: value, expiresAt: expiresAt})
+	for m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
+
+// CacheKey returns the cache key for a model and prompt, ignoring insignificant whitespace
+func CacheKey(model string, messages []map[string]string) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	for _, message := range messages {
+		h.Write([]byte{0})
+		h.Write([]byte(message["role"]))
+		h.Write([]byte{0})
+		h.Write([]byte(strings.Join(strings.Fields(message["content"]), " ")))
+	}
+	return "agentbill:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedCompletion returns a cached response and marks the span as a zero-cost hit
+func (c *Client) cachedCompletion(ctx context.Context, key string, span *Span) (map[string]interface{}, bool) {
+	if c.config.Cache == nil {
+		return nil, false
+	}
+
+	data, ok, err := c.config.Cache.Get(ctx, key)
+	if err != nil && c.config.Debug {
+		fmt.Printf("[AgentBill] Cache get failed: %v\n", err)
+	}
+	if !ok {
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		return nil, false
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		return nil, false
+	}
+
+	span.SetAttributes(
+		attribute.Bool("cache.hit", true),
+		attribute.Int("response.prompt_tokens", 0),
+		attribute.Int("response.completion_tokens", 0),
+		attribute.Int("response.total_tokens", 0),
+	)
+	if usage, ok := response["usage"].(map[string]interface{}); ok {
+		if promptTokens, ok := usage["prompt_tokens"].(float64); ok {
+			span.SetAttributes(attribute.Int("cache.saved_prompt_tokens", int(promptTokens)))
+		}
+		if completionTokens, ok := usage["completion_tokens"].(float64); ok {
+			span.SetAttributes(attribute.Int("cache.saved_completion_tokens", int(completionTokens)))
+		}
+	}
+	return response, true
+}
+
+func (c *Client) storeCompletion(ctx context.Context, key string, response map[string]interface{}) {
+	if c.config.Cache == nil {
+		return
+	}
+	data, err := json.Marshal(response)
+	if err == nil {
+		err = c.config.Cache.Set(ctx, key, data, c.config.CacheTTL)
+	}
+	if err != nil && c.config.Debug {
+		fmt.Printf("[AgentBill] Cache set failed: %v\n", err)
+	}
+}
@@ -0,0 +1,143 @@
+package agentbill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ChatCompletionResponse is a chat completion in OpenAI's response shape, which
+// the other OpenAI-compatible providers share
+type ChatCompletionResponse struct {
+	ID                string       `json:"id"`
+	Object            string       `json:"object"`
+	Created           int64        `json:"created"`
+	Model             string       `json:"model"`
+	SystemFingerprint string       `json:"system_fingerprint,omitempty"`
+	Choices           []ChatChoice `json:"choices"`
+	Usage             ChatUsage    `json:"usage"`
+	// Raw is the response body as the provider sent it, for fields the struct
+	// does not cover or cannot represent
+	Raw json.RawMessage `json:"-"`
+}
+
+// ChatChoice is one of a completion's alternative answers
+type ChatChoice struct {
+	Index   int         `json:"index"`
+	Message ChatMessage `json:"message"`
+	// FinishReason is why generation stopped: stop, length, tool_calls or content_filter
+	FinishReason string `json:"finish_reason"`
+}
+
+// ChatMessage is a message generated by the model. Content given as an array of
+// parts is read as the concatenation of its text parts.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Refusal is set instead of Content when the model declines to answer
+	Refusal string `json:"refusal,omitempty"`
+	// ReasoningContent is reasoning produced ahead of the answer, e.g. by deepseek-reasoner
+	ReasoningContent string         `json:"reasoning_content,omitempty"`
+	ToolCalls        []ChatToolCall `json:"tool_calls,omitempty"`
+}
+
+// UnmarshalJSON reads content given either as a string or as an array of parts
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	type message ChatMessage
+	var decoded struct {
+		message
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*m = ChatMessage(decoded.message)
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(decoded.Content, &m.Content) != nil && json.Unmarshal(decoded.Content, &parts) == nil {
+		var text strings.Builder
+		for _, part := range parts {
+			text.WriteString(part.Text)
+		}
+		m.Content = text.String()
+	}
+	return nil
+}
+
+// ChatToolCall is a function call requested by the model
+type ChatToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+		// Arguments is the call's arguments as a JSON object string
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// ChatUsage is the token usage reported with a chat completion
+type ChatUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+// Content returns the first choice's message content, or "" when there are no choices
+func (r *ChatCompletionResponse) Content() string {
+	if len(r.Choices) == 0 {
+		return ""
+	}
+	return r.Choices[0].Message.Content
+}
+
+// ParseChatCompletion converts a response returned by a ChatCompletion method
+// into a ChatCompletionResponse. Raw holds the response re-encoded, since the
+// map no longer has the original body; use ChatCompletionTyped for that.
+func ParseChatCompletion(response map[string]interface{}) (*ChatCompletionResponse, error) {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	return parseChatCompletion(raw), nil
+}
+
+// parseChatCompletion decodes as much of raw as fits the typed response; a field
+// of an unexpected type is left empty rather than failing the whole response
+func parseChatCompletion(raw []byte) *ChatCompletionResponse {
+	var typed ChatCompletionResponse
+	if err := json.Unmarshal(raw, &typed); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			typed = ChatCompletionResponse{}
+		}
+	}
+	typed.Raw = raw
+	return &typed
+}
+
+// ChatCompletionTyped is ChatCompletion returning a typed response, with the
+// provider's response body in Raw. Fields that don't fit the struct are left
+// empty instead of failing a call that has already been made and billed.
+func (w *OpenAIWrapper) ChatCompletionTyped(ctx context.Context, model string, messages []map[string]string) (*ChatCompletionResponse, error) {
+	var raw []byte
+	response, err := w.client.chatCompletionRaw(ctx, openAIProvider, w.guardrail, model, messages, &raw)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		// Responses served from the cache are only kept decoded
+		if raw, err = json.Marshal(response); err != nil {
+			return nil, err
+		}
+	}
+	return parseChatCompletion(raw), nil
+}
@@ -0,0 +1,103 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Offsets below this are within the resolution of the server's Date header and are ignored
+const clockSyncThreshold = 2 * time.Second
+
+// serverClock reads the configured clock shifted by its measured offset from
+// AgentBill's servers. Shifting keeps the monotonic reading, so latencies
+// measured between two readings are unaffected.
+type serverClock struct {
+	base   Clock
+	offset int64 // nanoseconds, accessed atomically
+}
+
+func (c *serverClock) Now() time.Time {
+	return c.base.Now().Add(time.Duration(atomic.LoadInt64(&c.offset)))
+}
+
+// ClockOffset returns the offset applied to timestamps by Config.SyncClock,
+// positive when the local clock is behind the server's
+func (c *Client) ClockOffset() time.Duration {
+	return c.config.clockOffset()
+}
+
+func (c Config) clockOffset() time.Duration {
+	synced, ok := c.Clock.(*serverClock)
+	if !ok {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&synced.offset))
+}
+
+// stopwatch returns the clock durations are measured with: the configured clock
+// without the SyncClock correction, so that a resync never shows up as latency
+func (c Config) stopwatch() Clock {
+	if synced, ok := c.Clock.(*serverClock); ok {
+		return synced.base
+	}
+	return c.clock()
+}
+
+// SyncClock measures the local clock against AgentBill's servers and shifts
+// span, signal and usage timestamps by the difference from then on, so usage
+// from a machine with a skewed clock lands in the right billing period. Offsets
+// under two seconds are treated as none. It requires Config.SyncClock, which
+// also syncs once in the background when the client starts; call SyncClock
+// periodically on long-running hosts whose clocks drift.
+func (c *Client) SyncClock(ctx context.Context) (time.Duration, error) {
+	if c.serverClock == nil {
+		return 0, errors.New("clock sync is not enabled; set Config.SyncClock")
+	}
+	// OPTIONS reaches the signal endpoint without recording a signal
+	url := fmt.Sprintf("%s/functions/v1/record-signals", c.config.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodOptions, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
+
+	sent := c.serverClock.base.Now()
+	resp, err := c.apiHTTP.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	received := c.serverClock.base.Now()
+	closeBody(resp.Body)
+
+	serverDate, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, errors.New("clock sync: server did not report its time")
+	}
+	// The Date header is truncated to the second and was stamped about half
+	// way through the round trip
+	local := sent.Add(received.Sub(sent) / 2)
+	offset := serverDate.Add(500 * time.Millisecond).Sub(local).Round(time.Millisecond)
+	if offset < clockSyncThreshold && offset > -clockSyncThreshold {
+		offset = 0
+	}
+	atomic.StoreInt64(&c.serverClock.offset, int64(offset))
+	if c.config.Debug && offset != 0 {
+		fmt.Printf("[AgentBill] Local clock is %v off server time; adjusting timestamps\n", -offset)
+	}
+	return offset, nil
+}
+
+// syncClockAsync runs the startup clock sync without delaying Init
+func (c *Client) syncClockAsync() {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if _, err := c.SyncClock(ctx); err != nil && c.config.Debug {
+			fmt.Printf("[AgentBill] Clock sync failed: %v\n", err)
+		}
+	}()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/agentbill/agentbill-go/devcollector"
+)
+
+func runDev(args []string) int {
+	fs := flag.NewFlagSet("dev", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:4318", "listen address")
+	file := fs.String("file", "", "persist events to this NDJSON file")
+	fs.Parse(args)
+
+	var store devcollector.Store
+	if *file != "" {
+		fileStore, err := devcollector.OpenFileStore(*file, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "agentbill dev: %v\n", err)
+			return 1
+		}
+		defer fileStore.Close()
+		store = fileStore
+	}
+
+	server, err := devcollector.Start(*addr, devcollector.Options{Store: store})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "agentbill dev: %v\n", err)
+		return 1
+	}
+	defer server.Close()
+
+	fmt.Printf("Dev collector listening on %s\n", server.URL)
+	fmt.Printf("Set AGENTBILL_BASE_URL=%s (or Config.BaseURL) and open it in a browser.\n", server.URL)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+	return 0
+}
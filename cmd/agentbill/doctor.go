@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	config := clientFlags(fs)
+	timeout := fs.Duration("timeout", 10*time.Second, "overall time limit for the checks")
+	fs.Parse(args)
+
+	client := agentbill.Init(*config)
+	effective := client.EffectiveConfig()
+
+	fmt.Println("Effective configuration:")
+	fmt.Printf("  api key:          %s\n", maskKey(effective.APIKey))
+	fmt.Printf("  base url:         %s\n", effective.BaseURL)
+	fmt.Printf("  customer:         %s\n", orNone(effective.CustomerID))
+	fmt.Printf("  transport preset: %s\n", effective.Transport.Preset)
+	fmt.Printf("  max idle conns:   %d (%d per host)\n", effective.Transport.MaxIdleConns, effective.Transport.MaxIdleConnsPerHost)
+	fmt.Printf("  export protocol:  %s -> %s\n", effective.ExportProtocol, effective.ExportEndpoint)
+	fmt.Printf("  max queue size:   %d spans\n", effective.MaxQueueSize)
+	fmt.Printf("  max export size:  %d bytes\n", effective.MaxExportBytes)
+	fmt.Printf("  sample rate:      %g\n", effective.SampleRate)
+	fmt.Println()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	failed := false
+	fmt.Println("Checks:")
+	for _, result := range client.Diagnose(ctx) {
+		status := "ok  "
+		if !result.OK {
+			status = "FAIL"
+			failed = true
+		}
+		detail := result.Detail
+		if result.Latency > 0 {
+			detail = fmt.Sprintf("%s in %v", detail, result.Latency.Round(time.Millisecond))
+		}
+		fmt.Printf("  [%s] %-11s %s\n", status, result.Name, detail)
+	}
+
+	if failed {
+		fmt.Fprintln(os.Stderr, "\nSome checks failed.")
+		return 1
+	}
+	return 0
+}
+
+func maskKey(key string) string {
+	if key == "" {
+		return "(not set)"
+	}
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:4] + "…" + key[len(key)-4:]
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
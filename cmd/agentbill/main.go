@@ -0,0 +1,59 @@
+// Command agentbill is a companion CLI for the AgentBill Go SDK.
+//
+// Configuration is read from AGENTBILL_API_KEY, AGENTBILL_BASE_URL and
+// AGENTBILL_CUSTOMER_ID, and can be overridden with flags.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{"doctor", "validate the API key, connectivity and clock skew", runDoctor},
+	{"tail", "follow recent spans and signals for a customer", runTail},
+	{"dev", "run a local collector with a web UI for offline development", runDev},
+	{"whatif", "replay historical usage against hypothetical pricing", runWhatIf},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "agentbill: unknown command %q\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: agentbill <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// clientFlags registers the connection flags shared by every command
+func clientFlags(fs *flag.FlagSet) *agentbill.Config {
+	config := &agentbill.Config{}
+	fs.StringVar(&config.APIKey, "api-key", os.Getenv("AGENTBILL_API_KEY"), "AgentBill API key")
+	fs.StringVar(&config.BaseURL, "base-url", os.Getenv("AGENTBILL_BASE_URL"), "AgentBill API base URL")
+	fs.StringVar(&config.CustomerID, "customer", os.Getenv("AGENTBILL_CUSTOMER_ID"), "customer ID")
+	return config
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+func runTail(args []string) int {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	config := clientFlags(fs)
+	since := fs.Duration("since", 5*time.Minute, "show events from this far back before following")
+	interval := fs.Duration("interval", 2*time.Second, "polling interval")
+	asJSON := fs.Bool("json", false, "print one JSON object per event")
+	fs.Parse(args)
+
+	if config.CustomerID == "" {
+		fmt.Fprintln(os.Stderr, "agentbill tail: --customer is required")
+		return 2
+	}
+
+	client := agentbill.Init(*config)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cursor := time.Now().Add(-*since)
+	// Events sharing the cursor timestamp are returned again; skip the ones already printed
+	seen := make(map[string]bool)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		events, err := client.RecentEvents(ctx, agentbill.EventQuery{Since: cursor})
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "agentbill tail: %v\n", err)
+		}
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+
+		for _, event := range events {
+			if seen[event.ID] {
+				continue
+			}
+			seen[event.ID] = true
+			printEvent(event, *asJSON)
+			if event.Time.After(cursor) {
+				cursor = event.Time
+				for id := range seen {
+					delete(seen, id)
+				}
+				seen[event.ID] = true
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+func printEvent(event agentbill.Event, asJSON bool) {
+	if asJSON {
+		data, _ := json.Marshal(event)
+		fmt.Println(string(data))
+		return
+	}
+
+	fields := []string{event.Time.Local().Format("15:04:05.000"), fmt.Sprintf("%-6s", event.Type), event.Name}
+	if event.TraceID != "" {
+		fields = append(fields, "trace="+event.TraceID)
+	}
+	if event.Model != "" {
+		fields = append(fields, "model="+event.Model)
+	}
+	if event.CostUSD != 0 {
+		fields = append(fields, fmt.Sprintf("cost=$%.6f", event.CostUSD))
+	}
+	if event.Revenue != 0 {
+		fields = append(fields, fmt.Sprintf("revenue=$%.2f", event.Revenue))
+	}
+	for _, key := range []string{"experiment.name", "experiment.variant", "session.id"} {
+		if value, ok := event.Attributes[key]; ok {
+			fields = append(fields, fmt.Sprintf("%s=%v", key, value))
+		}
+	}
+	fmt.Println(strings.Join(fields, "  "))
+}
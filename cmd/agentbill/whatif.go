@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+// whatIfPlan is a hypothetical pricing configuration. Pricing changes what
+// calls cost us; the remaining fields, when any is set, replace how customers
+// are charged.
+type whatIfPlan struct {
+	Pricing          map[string]agentbill.ModelPrice `json:"pricing"`
+	Markup           float64                         `json:"markup"`
+	PerRequest       float64                         `json:"per_request"`
+	PerMillionTokens float64                         `json:"per_million_tokens"`
+}
+
+func (p whatIfPlan) chargesRevenue() bool {
+	return p.Markup != 0 || p.PerRequest != 0 || p.PerMillionTokens != 0
+}
+
+// usageRow is one historical usage entry, whatever file format it came from
+type usageRow struct {
+	customerID       string
+	model            string
+	requests         int64
+	promptTokens     int64
+	completionTokens int64
+	costUSD          float64
+	revenue          float64
+}
+
+type whatIfTotals struct {
+	key              string
+	requests         int64
+	tokens           int64
+	baselineCost     float64
+	whatIfCost       float64
+	baselineRevenue  float64
+	whatIfRevenue    float64
+	unpricedRequests int64
+}
+
+func runWhatIf(args []string) int {
+	fs := flag.NewFlagSet("whatif", flag.ExitOnError)
+	usagePath := fs.String("usage", "", "historical usage as NDJSON (usage records or exported events) or CSV")
+	planPath := fs.String("plan", "", "JSON file with the hypothetical pricing")
+	groupBy := fs.String("group", "customer", "group results by customer or model")
+	fs.Parse(args)
+
+	if *usagePath == "" || *planPath == "" {
+		fmt.Fprintln(os.Stderr, "agentbill whatif: --usage and --plan are required")
+		return 2
+	}
+	if *groupBy != "customer" && *groupBy != "model" {
+		fmt.Fprintln(os.Stderr, "agentbill whatif: --group must be customer or model")
+		return 2
+	}
+
+	plan, err := loadPlan(*planPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "agentbill whatif: %v\n", err)
+		return 1
+	}
+	rows, err := loadUsage(*usagePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "agentbill whatif: %v\n", err)
+		return 1
+	}
+
+	// Disabled clients are used purely for their price tables
+	current := agentbill.Init(agentbill.Config{Disabled: true})
+	hypothetical := agentbill.Init(agentbill.Config{Disabled: true, Pricing: plan.Pricing})
+
+	groups := make(map[string]*whatIfTotals)
+	total := &whatIfTotals{key: "TOTAL"}
+	for _, row := range rows {
+		key := row.customerID
+		if *groupBy == "model" {
+			key = row.model
+		}
+		if key == "" {
+			key = "(none)"
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &whatIfTotals{key: key}
+			groups[key] = g
+		}
+
+		baselineCost := row.costUSD
+		if baselineCost == 0 && row.model != "" {
+			baselineCost = current.EstimateCost(row.model, int(row.promptTokens), int(row.completionTokens))
+		}
+		whatIfCost := 0.0
+		var unpriced int64
+		if row.model != "" {
+			if _, ok := hypothetical.PriceFor(row.model); ok {
+				whatIfCost = hypothetical.EstimateCost(row.model, int(row.promptTokens), int(row.completionTokens))
+			} else {
+				// Unknown models keep their recorded cost rather than dropping to zero
+				whatIfCost = baselineCost
+				unpriced = row.requests
+			}
+		}
+		tokens := row.promptTokens + row.completionTokens
+		whatIfRevenue := row.revenue
+		if plan.chargesRevenue() {
+			whatIfRevenue = whatIfCost*plan.Markup + float64(row.requests)*plan.PerRequest + float64(tokens)/1e6*plan.PerMillionTokens
+		}
+
+		for _, t := range []*whatIfTotals{g, total} {
+			t.requests += row.requests
+			t.tokens += tokens
+			t.baselineCost += baselineCost
+			t.whatIfCost += whatIfCost
+			t.baselineRevenue += row.revenue
+			t.whatIfRevenue += whatIfRevenue
+			t.unpricedRequests += unpriced
+		}
+	}
+
+	sorted := make([]*whatIfTotals, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return math.Abs(sorted[i].marginDelta()) > math.Abs(sorted[j].marginDelta())
+	})
+
+	fmt.Printf("%-24s %10s %12s %12s %12s %12s %12s %12s %12s\n",
+		*groupBy, "requests", "tokens", "cost", "cost'", "Δcost", "revenue", "revenue'", "Δmargin")
+	for _, g := range append(sorted, total) {
+		fmt.Printf("%-24s %10d %12d %12.4f %12.4f %+12.4f %12.4f %12.4f %+12.4f\n",
+			truncate(g.key, 24), g.requests, g.tokens, g.baselineCost, g.whatIfCost, g.whatIfCost-g.baselineCost,
+			g.baselineRevenue, g.whatIfRevenue, g.marginDelta())
+	}
+	if total.unpricedRequests > 0 {
+		fmt.Printf("\n%d requests used models missing from the plan's price table and kept their recorded cost.\n", total.unpricedRequests)
+	}
+	if !plan.chargesRevenue() {
+		fmt.Println("\nThe plan sets no markup or per-unit charges, so revenue is unchanged.")
+	}
+	return 0
+}
+
+func (t *whatIfTotals) marginDelta() float64 {
+	return (t.whatIfRevenue - t.whatIfCost) - (t.baselineRevenue - t.baselineCost)
+}
+
+func loadPlan(path string) (whatIfPlan, error) {
+	var plan whatIfPlan
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return plan, err
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return plan, fmt.Errorf("%s: %w", path, err)
+	}
+	return plan, nil
+}
+
+func loadUsage(path string) ([]usageRow, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return readUsageCSV(file)
+	}
+	return readUsageNDJSON(file)
+}
+
+// readUsageNDJSON accepts usage records and events exported by the dev collector or RecentEvents
+func readUsageNDJSON(r io.Reader) ([]usageRow, error) {
+	var rows []usageRow
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var entry struct {
+			agentbill.UsageRecord
+			Type       string                 `json:"type"`
+			Revenue    float64                `json:"revenue"`
+			Attributes map[string]interface{} `json:"attributes"`
+		}
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		row := usageRow{
+			customerID:       entry.CustomerID,
+			model:            entry.Model,
+			requests:         entry.Requests,
+			promptTokens:     entry.PromptTokens,
+			completionTokens: entry.CompletionTokens,
+			costUSD:          entry.CostUSD,
+			revenue:          entry.Revenue,
+		}
+		switch entry.Type {
+		case "span":
+			if row.model == "" {
+				continue
+			}
+			row.requests = 1
+			row.promptTokens = int64Attr(entry.Attributes, "response.prompt_tokens")
+			row.completionTokens = int64Attr(entry.Attributes, "response.completion_tokens")
+		case "usage":
+			row.requests = int64Attr(entry.Attributes, "requests")
+			row.promptTokens = int64Attr(entry.Attributes, "prompt_tokens")
+			row.completionTokens = int64Attr(entry.Attributes, "completion_tokens")
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+// readUsageCSV reads a header row naming any of customer_id, model, requests,
+// prompt_tokens, completion_tokens, cost_usd and revenue
+func readUsageCSV(r io.Reader) ([]usageRow, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []usageRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := usageRow{customerID: field(record, "customer_id"), model: field(record, "model"), requests: 1}
+		for name, dest := range map[string]*int64{
+			"requests":          &row.requests,
+			"prompt_tokens":     &row.promptTokens,
+			"completion_tokens": &row.completionTokens,
+		} {
+			if value := field(record, name); value != "" {
+				if *dest, err = strconv.ParseInt(value, 10, 64); err != nil {
+					return nil, fmt.Errorf("line %d: %s: %w", line, name, err)
+				}
+			}
+		}
+		for name, dest := range map[string]*float64{"cost_usd": &row.costUSD, "revenue": &row.revenue} {
+			if value := field(record, name); value != "" {
+				if *dest, err = strconv.ParseFloat(value, 64); err != nil {
+					return nil, fmt.Errorf("line %d: %s: %w", line, name, err)
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func int64Attr(attributes map[string]interface{}, key string) int64 {
+	switch v := attributes[key].(type) {
+	case float64:
+		return int64(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return n
+	}
+	return 0
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
@@ -0,0 +1,98 @@
+package agentbill
+
+import (
+	"context"
+	"math"
+	"unicode/utf8"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+const (
+	// A search unit covers one query over up to this many documents (or chunks)
+	cohereDocumentsPerSearchUnit = 100
+	// Documents longer than this many tokens, query included, count as several chunks
+	cohereChunkTokens = 500
+)
+
+var cohereProvider = &chatProvider{
+	name:    "cohere",
+	service: "Cohere",
+	baseURL: "https://api.cohere.com/v2",
+	keyEnv:  "CO_API_KEY",
+}
+
+// CohereWrapper wraps Cohere rerank calls
+type CohereWrapper struct {
+	client *Client
+}
+
+// WrapCohere wraps the Cohere API for tracking. The API key is read from CO_API_KEY.
+func (c *Client) WrapCohere() *CohereWrapper {
+	return &CohereWrapper{client: c}
+}
+
+// Rerank tracks a rerank call. Cohere bills rerank in search units rather than
+// tokens, so the span is metered in UnitSearchUnits using the billed_units Cohere
+// reports, or an estimate from the document count and length when it doesn't.
+// topN limits the results returned; 0 returns all documents.
+func (w *CohereWrapper) Rerank(ctx context.Context, model, query string, documents []string, topN int) (map[string]interface{}, error) {
+	c := w.client
+	clock := c.config.stopwatch()
+	startTime := clock.Now()
+
+	span := c.tracer.startInternal(ctx, "cohere.rerank",
+		attribute.String("model", model),
+		attribute.String("provider", cohereProvider.name),
+		attribute.Int("rerank.documents", len(documents)),
+	)
+	ctx = c.withRequestTiming(ctx, span)
+
+	defer func() {
+		latency := clock.Now().Sub(startTime).Milliseconds()
+		span.SetAttributes(attribute.Int64("latency_ms", latency))
+		span.End()
+	}()
+
+	requestBody := map[string]interface{}{
+		"model":     model,
+		"query":     query,
+		"documents": documents,
+	}
+	if topN > 0 {
+		requestBody["top_n"] = topN
+	}
+	response, err := c.providerPost(ctx, cohereProvider, "/rerank", requestBody)
+	if err != nil {
+		recordCallError(span, err)
+		return nil, err
+	}
+
+	meta, _ := response["meta"].(map[string]interface{})
+	billed, _ := meta["billed_units"].(map[string]interface{})
+	units, ok := billed["search_units"].(float64)
+	if !ok {
+		units = float64(estimateSearchUnits(query, documents))
+		span.SetAttributes(attribute.Bool("usage.estimated", true))
+	}
+	c.recordMeteredUsage(span, model, cohereProvider.name, UnitSearchUnits, units, c.EstimateUnitCost(model, units))
+
+	span.SetStatus(0, "")
+	return response, nil
+}
+
+// estimateSearchUnits applies Cohere's search unit rules with tokens estimated
+// at four characters each
+func estimateSearchUnits(query string, documents []string) int {
+	queryTokens := utf8.RuneCountInString(query) / 4
+	chunkTokens := cohereChunkTokens - queryTokens
+	if chunkTokens < 1 {
+		chunkTokens = 1
+	}
+	chunks := 0
+	for _, document := range documents {
+		tokens := utf8.RuneCountInString(document) / 4
+		chunks += int(math.Max(1, math.Ceil(float64(tokens)/float64(chunkTokens))))
+	}
+	return int(math.Max(1, math.Ceil(float64(chunks)/cohereDocumentsPerSearchUnit)))
+}
@@ -0,0 +1,192 @@
+package agentbill
+
+import (
+	"context"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+type contextKey int
+
+const (
+	attributionKey contextKey = iota
+	spanAttributesKey
+	traceContextKey
+)
+
+// Attribution holds request-scoped metadata applied to spans and signals
+type Attribution struct {
+	Experiment string
+	Variant    string
+	// Organization, Project and User attribute usage to a customer's own
+	// organization > project > user hierarchy, for B2B customers' team breakdowns
+	Organization string
+	Project      string
+	User         string
+	// Tags label spans and signals for cost allocation, e.g. by feature, team or environment
+	Tags map[string]string
+}
+
+// WithExperiment returns a context whose spans and signals are tagged with the experiment and variant
+func WithExperiment(ctx context.Context, experiment, variant string) context.Context {
+	attribution := AttributionFromContext(ctx)
+	attribution.Experiment = experiment
+	attribution.Variant = variant
+	return context.WithValue(ctx, attributionKey, attribution)
+}
+
+// WithOrganization returns a context whose spans and signals are attributed to
+// an organization within the customer
+func WithOrganization(ctx context.Context, organization string) context.Context {
+	attribution := AttributionFromContext(ctx)
+	attribution.Organization = organization
+	return context.WithValue(ctx, attributionKey, attribution)
+}
+
+// WithProject returns a context whose spans and signals are attributed to a
+// project within the context's organization
+func WithProject(ctx context.Context, project string) context.Context {
+	attribution := AttributionFromContext(ctx)
+	attribution.Project = project
+	return context.WithValue(ctx, attributionKey, attribution)
+}
+
+// WithUser returns a context whose spans and signals are attributed to a user
+// within the context's organization and project
+func WithUser(ctx context.Context, user string) context.Context {
+	attribution := AttributionFromContext(ctx)
+	attribution.User = user
+	return context.WithValue(ctx, attributionKey, attribution)
+}
+
+// WithTags returns a context whose spans and signals carry tags, added to any the
+// context already has. Spans record them as tag.<key> attributes.
+func WithTags(ctx context.Context, tags map[string]string) context.Context {
+	attribution := AttributionFromContext(ctx)
+	merged := make(map[string]string, len(attribution.Tags)+len(tags))
+	for k, v := range attribution.Tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	attribution.Tags = merged
+	return context.WithValue(ctx, attributionKey, attribution)
+}
+
+// AttributionFromContext returns the attribution carried by ctx
+func AttributionFromContext(ctx context.Context) Attribution {
+	if ctx == nil {
+		return Attribution{}
+	}
+	attribution, _ := ctx.Value(attributionKey).(Attribution)
+	return attribution
+}
+
+// TraceContext identifies the trace, parent span and session a unit of work belongs to
+type TraceContext struct {
+	TraceID   string
+	SpanID    string
+	SessionID string
+}
+
+// WithTraceContext returns a context whose spans join the given trace
+func WithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey, tc)
+}
+
+// TraceContextFromContext returns the trace context carried by ctx
+func TraceContextFromContext(ctx context.Context) TraceContext {
+	if ctx == nil {
+		return TraceContext{}
+	}
+	tc, _ := ctx.Value(traceContextKey).(TraceContext)
+	return tc
+}
+
+// WithSession returns a context whose spans are grouped under the session ID
+func WithSession(ctx context.Context, sessionID string) context.Context {
+	tc := TraceContextFromContext(ctx)
+	tc.SessionID = sessionID
+	return WithTraceContext(ctx, tc)
+}
+
+// ContextWithSpan returns a context whose spans become children of span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	tc := TraceContextFromContext(ctx)
+	tc.TraceID = span.TraceID
+	tc.SpanID = span.SpanID
+	return WithTraceContext(ctx, tc)
+}
+
+// withSpanAttributes returns a context whose spans carry the given extra attributes
+func withSpanAttributes(ctx context.Context, attrs ...attribute.KeyValue) context.Context {
+	parent, _ := ctx.Value(spanAttributesKey).([]attribute.KeyValue)
+	merged := make([]attribute.KeyValue, 0, len(parent)+len(attrs))
+	merged = append(merged, parent...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, spanAttributesKey, merged)
+}
+
+func applyContextAttributes(ctx context.Context, span *Span) {
+	if ctx == nil {
+		return
+	}
+	AttributionFromContext(ctx).applyToSpan(span)
+	if extra, ok := ctx.Value(spanAttributesKey).([]attribute.KeyValue); ok {
+		span.SetAttributes(extra...)
+	}
+}
+
+func (a Attribution) applyToSpan(span *Span) {
+	if a.Experiment != "" {
+		span.SetAttributes(attribute.String("experiment.name", a.Experiment))
+	}
+	if a.Variant != "" {
+		span.SetAttributes(attribute.String("experiment.variant", a.Variant))
+	}
+	if a.Organization != "" {
+		span.SetAttributes(attribute.String("organization.id", a.Organization))
+	}
+	if a.Project != "" {
+		span.SetAttributes(attribute.String("project.id", a.Project))
+	}
+	if a.User != "" {
+		span.SetAttributes(attribute.String("user.id", a.User))
+	}
+	for k, v := range a.Tags {
+		span.SetAttributes(attribute.String("tag."+k, v))
+	}
+}
+
+func (a Attribution) applyToSignal(signal *Signal) {
+	if signal.Experiment == "" {
+		signal.Experiment = a.Experiment
+	}
+	if signal.Variant == "" {
+		signal.Variant = a.Variant
+	}
+	if signal.OrganizationID == "" {
+		signal.OrganizationID = a.Organization
+	}
+	if signal.ProjectID == "" {
+		signal.ProjectID = a.Project
+	}
+	if signal.UserID == "" {
+		signal.UserID = a.User
+	}
+	if len(a.Tags) == 0 {
+		return
+	}
+	// Data belongs to the caller, who may share it between signals and goroutines
+	data := make(map[string]interface{}, len(signal.Data)+len(a.Tags))
+	for k, v := range signal.Data {
+		data[k] = v
+	}
+	signal.Data = data
+	for k, v := range a.Tags {
+		if _, ok := signal.Data["tag."+k]; !ok {
+			signal.Data["tag."+k] = v
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package agentbill_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	agentbill "github.com/agentbill/agentbill-go"
+	"github.com/agentbill/agentbill-go/agentbilltest"
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// The contract tests run real SDK calls against a local collector and validate
+// every request body against the published schemas in schema/.
+
+func TestOTLPExportMatchesSchema(t *testing.T) {
+	collector := agentbilltest.NewCollectorServer()
+	defer collector.Close()
+	client := agentbill.Init(collector.Config())
+	ctx := context.Background()
+
+	parent := client.Tracer().Start(ctx, "agent.run",
+		attribute.String("string", "value"),
+		attribute.Int64("int", math.MaxInt64),
+		attribute.Float64("float", 0.25),
+		attribute.Bool("bool", true),
+		attribute.Any("other", []string{"a", "b"}),
+	)
+	child := client.Tracer().Start(agentbill.ContextWithSpan(ctx, parent), "agent.step")
+	child.AddEvent("retry", attribute.Int("attempt", 2))
+	child.SetStatus(1, "failed")
+	child.End()
+	parent.RecordGuardrail(agentbill.GuardrailEvent{Policy: "pii", Action: agentbill.GuardrailFlagged, Categories: []string{"email"}})
+	parent.End()
+	client.Tracer().StartSpan("agent.empty", nil).End()
+
+	if err := client.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	schema := loadSchema(t, "schema/otlp-traces.schema.json")
+	bodies := requestBodies(collector, "/functions/v1/otel-collector")
+	if len(bodies) == 0 {
+		t.Fatal("no trace export was sent")
+	}
+	for _, body := range bodies {
+		schema.validate(t, body)
+	}
+	if spans := collector.Spans(); len(spans) != 3 {
+		t.Fatalf("collector decoded %d spans, want 3", len(spans))
+	}
+}
+
+func TestSignalBodiesMatchSchema(t *testing.T) {
+	collector := agentbilltest.NewCollectorServer()
+	defer collector.Close()
+	client := agentbill.Init(collector.Config())
+	ctx := agentbill.WithExperiment(context.Background(), "prompt-v2", "treatment")
+
+	calls := []func() error{
+		func() error { return client.TrackSignal(ctx, agentbill.Signal{EventName: "purchase", Revenue: 9.99}) },
+		func() error {
+			return client.TrackSignal(ctx, agentbill.Signal{EventName: "purchase", Revenue: 10, TaxAmount: 2, TaxRate: 0.2, TaxJurisdiction: "GB"})
+		},
+		func() error {
+			return client.TrackSignal(ctx, agentbill.Signal{EventName: "purchase", Revenue: 8, CouponCode: "SPRING20", DiscountAmount: 2, DiscountPercent: 20})
+		},
+		func() error {
+			start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+			return client.TrackSignal(ctx, agentbill.Signal{EventName: "subscription", Revenue: 120}.RecognizeOver(start, start.AddDate(1, 0, 0)))
+		},
+		func() error {
+			config := collector.Config()
+			config.FXRates = agentbill.StaticFXRates{"EUR": 1.08}
+			return agentbill.Init(config).TrackSignal(ctx, agentbill.Signal{EventName: "purchase", Revenue: 100, Currency: "eur"})
+		},
+		func() error {
+			return client.TrackSignal(context.Background(), agentbill.Signal{EventName: "bare", IdempotencyKey: "k-1", TraceID: "t-1"})
+		},
+		func() error {
+			return client.TrackFeedback(ctx, agentbill.Feedback{TraceID: "t-1", Thumbs: agentbill.ThumbsDown, Comment: "wrong"})
+		},
+		func() error {
+			return client.TrackGuardrail(ctx, agentbill.GuardrailEvent{Policy: "pii", Action: agentbill.GuardrailBlockedPrompt})
+		},
+		func() error {
+			pending, err := client.RequestApproval(ctx, agentbill.Approval{ID: "a-1"})
+			if err != nil {
+				return err
+			}
+			return pending.Approve(ctx, agentbill.ApprovalDecision{Reviewer: "ops", Revenue: 2})
+		},
+	}
+	for _, call := range calls {
+		if err := call(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schema := loadSchema(t, "schema/signal.schema.json")
+	bodies := requestBodies(collector, "/functions/v1/record-signals")
+	if len(bodies) != 10 {
+		t.Fatalf("sent %d signals, want 10", len(bodies))
+	}
+	for _, body := range bodies {
+		schema.validate(t, body)
+	}
+}
+
+func requestBodies(collector *agentbilltest.CollectorServer, path string) [][]byte {
+	var bodies [][]byte
+	for _, req := range collector.Requests() {
+		if req.Path == path {
+			bodies = append(bodies, req.Body)
+		}
+	}
+	return bodies
+}
+
+// jsonSchema validates the subset of JSON Schema used by the published schemas
+type jsonSchema struct {
+	root map[string]interface{}
+}
+
+func loadSchema(t *testing.T, path string) jsonSchema {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return jsonSchema{root: root}
+}
+
+func (s jsonSchema) validate(t *testing.T, body []byte) {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+	for _, problem := range s.check(s.root, value, "$") {
+		t.Error(problem)
+	}
+}
+
+func (s jsonSchema) check(schema map[string]interface{}, value interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		return s.check(s.root["$defs"].(map[string]interface{})[name].(map[string]interface{}), value, path)
+	}
+
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if types, ok := schema["type"]; ok && !matchesType(types, value) {
+		fail("type %v, got %T", types, value)
+		return problems
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+			}
+		}
+		if !found {
+			fail("value %v not in %v", value, enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if min, ok := schema["minLength"].(float64); ok && len(v) < int(min) {
+			fail("shorter than %v", min)
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(v) {
+			fail("%q does not match %s", v, pattern)
+		}
+	case json.Number:
+		if min, ok := schema["minimum"].(float64); ok {
+			if f, _ := v.Float64(); f < min {
+				fail("%v below minimum %v", v, min)
+			}
+		}
+		if max, ok := schema["maximum"].(float64); ok {
+			if f, _ := v.Float64(); f > max {
+				fail("%v above maximum %v", v, max)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				problems = append(problems, s.check(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := v[name.(string)]; !ok {
+					fail("missing required field %q", name)
+				}
+			}
+		}
+		if min, ok := schema["minProperties"].(float64); ok && len(v) < int(min) {
+			fail("fewer than %v properties", min)
+		}
+		if max, ok := schema["maxProperties"].(float64); ok && len(v) > int(max) {
+			fail("more than %v properties", max)
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, known := properties[key].(map[string]interface{})
+			if !known {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					fail("unexpected field %q", key)
+				}
+				continue
+			}
+			problems = append(problems, s.check(property, v[key], path+"."+key)...)
+		}
+	}
+	return problems
+}
+
+func matchesType(types interface{}, value interface{}) bool {
+	if list, ok := types.([]interface{}); ok {
+		for _, t := range list {
+			if matchesType(t, value) {
+				return true
+			}
+		}
+		return false
+	}
+	switch types {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/url"
+	"time"
+)
+
+// Coupon is a discount code as validated by AgentBill
+type Coupon struct {
+	Code  string `json:"code"`
+	Valid bool   `json:"valid"`
+	// Reason explains why an invalid coupon was refused, e.g. "expired"
+	Reason string `json:"reason,omitempty"`
+	// PercentOff (20 for 20% off) or AmountOff is the discount the coupon gives
+	PercentOff float64   `json:"percent_off,omitempty"`
+	AmountOff  float64   `json:"amount_off,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+}
+
+// Apply returns a revenue signal for a purchase of amount with the coupon
+// applied: Revenue is the discounted amount, and the coupon and discount are
+// recorded with it. The discount never exceeds amount; an invalid coupon gives none.
+func (c Coupon) Apply(eventName string, amount float64) Signal {
+	signal := Signal{EventName: eventName, Revenue: amount}
+	if !c.Valid {
+		return signal
+	}
+	discount := c.AmountOff
+	if c.PercentOff > 0 {
+		discount = amount * c.PercentOff / 100
+	}
+	discount = roundCents(math.Min(discount, amount))
+	signal.Revenue = roundCents(amount - discount)
+	signal.CouponCode = c.Code
+	signal.DiscountAmount = discount
+	signal.DiscountPercent = c.PercentOff
+	return signal
+}
+
+// ValidateCoupon checks a coupon code for a customer before it is applied.
+// An unknown, expired or ineligible code is returned with Valid false and a
+// Reason rather than an error. customerID defaults to the client's customer.
+func (c *Client) ValidateCoupon(ctx context.Context, code, customerID string) (*Coupon, error) {
+	if code == "" {
+		return nil, errors.New("coupon code is required")
+	}
+	params := url.Values{"code": {code}}
+	c.setCustomer(params, customerID)
+
+	var coupon Coupon
+	if err := c.getJSON(ctx, "validate-coupon", params, &coupon); err != nil {
+		return nil, err
+	}
+	if coupon.Code == "" {
+		coupon.Code = code
+	}
+	return &coupon, nil
+}
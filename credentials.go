@@ -0,0 +1,76 @@
+package agentbill
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// PerRPCCredentials supplies authentication metadata for each export request.
+// Its method set matches google.golang.org/grpc/credentials.PerRPCCredentials,
+// so gRPC credential implementations (OAuth, STS, custom) can be used directly.
+type PerRPCCredentials interface {
+	GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error)
+	RequireTransportSecurity() bool
+}
+
+type bearerCredentials struct {
+	token func(ctx context.Context) (string, error)
+}
+
+func (b bearerCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := b.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (b bearerCredentials) RequireTransportSecurity() bool { return true }
+
+// BearerToken authenticates exports with a fixed bearer token
+func BearerToken(token string) PerRPCCredentials {
+	return bearerCredentials{token: func(context.Context) (string, error) { return token, nil }}
+}
+
+// BearerTokenSource authenticates exports with a token fetched per request,
+// for collectors that require short-lived tokens
+func BearerTokenSource(source func(ctx context.Context) (string, error)) PerRPCCredentials {
+	return bearerCredentials{token: source}
+}
+
+// setExportAuth adds export credentials to req, defaulting to the API key
+func (t *Tracer) setExportAuth(req *http.Request) error {
+	creds := t.config.ExportCredentials
+	if creds == nil {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.config.APIKey))
+		return nil
+	}
+	if creds.RequireTransportSecurity() && req.URL.Scheme != "https" {
+		return fmt.Errorf("export credentials require TLS but the collector URL is %s", req.URL.Scheme)
+	}
+	metadata, err := creds.GetRequestMetadata(req.Context(), req.URL.String())
+	if err != nil {
+		return fmt.Errorf("export credentials: %w", err)
+	}
+	for key, value := range metadata {
+		req.Header.Set(key, value)
+	}
+	return nil
+}
+
+// exportHTTPClient returns the client for span exports, which uses its own
+// pool when the exporter needs a dedicated TLS configuration
+func exportHTTPClient(config Config, shared *http.Client) *http.Client {
+	if config.ExportTLS == nil {
+		return shared
+	}
+	transport := newHTTPTransport(config.Transport)
+	transport.TLSClientConfig = config.ExportTLS.Clone()
+	if config.Transport.DisableHTTP2 {
+		transport.TLSClientConfig.NextProtos = nil
+	} else if len(transport.TLSClientConfig.NextProtos) == 0 {
+		transport.TLSClientConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+	return &http.Client{Transport: transport, Timeout: shared.Timeout}
+}
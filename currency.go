@@ -0,0 +1,83 @@
+package agentbill
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const defaultReportingCurrency = "USD"
+
+// FXRateProvider supplies exchange rates for converting signal revenue
+type FXRateProvider interface {
+	// Rate returns the number of units of to that one unit of from is worth
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// FXRateFunc adapts a function to FXRateProvider
+type FXRateFunc func(ctx context.Context, from, to string) (float64, error)
+
+// Rate implements FXRateProvider
+func (f FXRateFunc) Rate(ctx context.Context, from, to string) (float64, error) {
+	return f(ctx, from, to)
+}
+
+// StaticFXRates is an FXRateProvider with fixed rates, giving the value of one
+// unit of each currency in USD, e.g. {"EUR": 1.08, "JPY": 0.0067}. USD is 1
+// whether listed or not.
+type StaticFXRates map[string]float64
+
+// Rate implements FXRateProvider
+func (r StaticFXRates) Rate(ctx context.Context, from, to string) (float64, error) {
+	usd := func(currency string) (float64, error) {
+		if rate, ok := r[currency]; ok && rate > 0 {
+			return rate, nil
+		}
+		if currency == "USD" {
+			return 1, nil
+		}
+		return 0, fmt.Errorf("no exchange rate for %s", currency)
+	}
+	fromUSD, err := usd(from)
+	if err != nil {
+		return 0, err
+	}
+	toUSD, err := usd(to)
+	if err != nil {
+		return 0, err
+	}
+	return fromUSD / toUSD, nil
+}
+
+// convertCurrency converts a signal's amounts to the reporting currency with
+// Config.FXRates, keeping the original revenue, currency and rate. Without a
+// rate provider, or when the rate is unavailable, the signal keeps its own
+// currency and is converted by AgentBill.
+func (c *Client) convertCurrency(ctx context.Context, signal *Signal) {
+	if signal.Currency == "" {
+		return
+	}
+	signal.Currency = strings.ToUpper(signal.Currency)
+	reporting := strings.ToUpper(c.config.ReportingCurrency)
+	if reporting == "" {
+		reporting = defaultReportingCurrency
+	}
+	if c.config.FXRates == nil || signal.Currency == reporting {
+		return
+	}
+
+	rate, err := c.config.FXRates.Rate(ctx, signal.Currency, reporting)
+	if err != nil || rate <= 0 {
+		if c.config.Debug {
+			fmt.Printf("[AgentBill] Signal %s left in %s: %v\n", signal.EventName, signal.Currency, err)
+		}
+		return
+	}
+	signal.OriginalRevenue = signal.Revenue
+	signal.OriginalCurrency = signal.Currency
+	signal.ExchangeRate = rate
+	signal.Currency = reporting
+	signal.Revenue *= rate
+	signal.TaxAmount *= rate
+	signal.DiscountAmount *= rate
+}
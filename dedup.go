@@ -0,0 +1,72 @@
+package agentbill
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// dedupWindow remembers recently seen keys for a fixed window
+type dedupWindow struct {
+	window time.Duration
+	clock  Clock
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func newDedupWindow(window time.Duration, clock Clock) *dedupWindow {
+	return &dedupWindow{
+		window:    window,
+		clock:     clock,
+		seen:      make(map[string]time.Time),
+		lastSweep: clock.Now(),
+	}
+}
+
+// claim records key and reports whether it was not already seen within the window
+func (d *dedupWindow) claim(key string) bool {
+	now := d.clock.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.lastSweep) > d.window {
+		for k, at := range d.seen {
+			if now.Sub(at) > d.window {
+				delete(d.seen, k)
+			}
+		}
+		d.lastSweep = now
+	}
+
+	if at, ok := d.seen[key]; ok && now.Sub(at) <= d.window {
+		return false
+	}
+	d.seen[key] = now
+	return true
+}
+
+// forget releases a key so a retry of a failed call isn't suppressed
+func (d *dedupWindow) forget(key string) {
+	d.mu.Lock()
+	delete(d.seen, key)
+	d.mu.Unlock()
+}
+
+func signalDedupKey(signal Signal) string {
+	if signal.IdempotencyKey != "" {
+		return fmt.Sprintf("%s\x00%s\x00%s", signal.EventName, signal.CustomerID, signal.IdempotencyKey)
+	}
+
+	// encoding/json sorts map keys, so identical data hashes identically
+	data, _ := json.Marshal(signal.Data)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%g\x00", signal.EventName, signal.CustomerID, signal.TraceID, signal.Revenue)
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
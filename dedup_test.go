@@ -0,0 +1,65 @@
+package agentbill
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDedupWindow(t *testing.T) {
+	clock := NewManualClock(time.Unix(1700000000, 0))
+	d := newDedupWindow(time.Minute, clock)
+
+	steps := []struct {
+		advance time.Duration
+		key     string
+		want    bool
+	}{
+		{0, "a", true},
+		{0, "a", false},
+		{0, "b", true},
+		{59 * time.Second, "a", false},
+		{time.Second, "a", false},
+		{time.Second, "a", true},
+		{30 * time.Second, "b", true},
+		{0, "a", false},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		if got := d.claim(step.key); got != step.want {
+			t.Errorf("step %d: claim(%q) = %v, want %v", i, step.key, got, step.want)
+		}
+	}
+
+	clock.Advance(2 * time.Minute)
+	d.claim("c")
+	d.mu.Lock()
+	seen := len(d.seen)
+	d.mu.Unlock()
+	if seen != 1 {
+		t.Errorf("window holds %d keys after a sweep, want only the new one", seen)
+	}
+
+	d.forget("c")
+	if !d.claim("c") {
+		t.Error("a forgotten key was still suppressed")
+	}
+}
+
+func TestSignalDedupWindow(t *testing.T) {
+	clock := NewManualClock(time.Unix(1700000000, 0))
+	client := Init(Config{APIKey: "mock", CustomerID: "customer-123", Disabled: true,
+		Clock: clock, SignalDedupWindow: time.Minute})
+	ctx := context.Background()
+	signal := Signal{EventName: "purchase", Revenue: 5, IdempotencyKey: "order-1"}
+
+	for _, advance := range []time.Duration{0, 30 * time.Second, 31 * time.Second} {
+		clock.Advance(advance)
+		if err := client.TrackSignal(ctx, signal); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(client.Mock().Signals()); got != 2 {
+		t.Errorf("recorded %d signals, want the repeat within the window suppressed", got)
+	}
+}
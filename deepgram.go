@@ -0,0 +1,75 @@
+package agentbill
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+const defaultDeepgramModel = "nova-2"
+
+var deepgramProvider = &chatProvider{
+	name:       "deepgram",
+	service:    "Deepgram",
+	baseURL:    "https://api.deepgram.com/v1",
+	keyEnv:     "DEEPGRAM_API_KEY",
+	authScheme: "Token",
+}
+
+// DeepgramWrapper wraps Deepgram pre-recorded transcription calls
+type DeepgramWrapper struct {
+	client  *Client
+	signals bool
+}
+
+// WrapDeepgram wraps the Deepgram API for tracking. The API key is read from DEEPGRAM_API_KEY.
+func (c *Client) WrapDeepgram() *DeepgramWrapper {
+	return &DeepgramWrapper{client: c}
+}
+
+// WithUsageSignals returns a copy of the wrapper that sends a transcription_usage signal per job
+func (w *DeepgramWrapper) WithUsageSignals() *DeepgramWrapper {
+	wrapped := *w
+	wrapped.signals = true
+	return &wrapped
+}
+
+// Transcribe tracks a Deepgram transcription, metered in audio minutes from the
+// response's metadata.duration. options are added to the query, e.g. smart_format.
+func (w *DeepgramWrapper) Transcribe(ctx context.Context, r TranscriptionRequest, options url.Values) (map[string]interface{}, error) {
+	if r.Model == "" {
+		r.Model = defaultDeepgramModel
+	}
+	query := url.Values{}
+	for key, values := range options {
+		query[key] = values
+	}
+	query.Set("model", r.Model)
+	endpoint := deepgramProvider.baseURL + "/listen?" + query.Encode()
+
+	c := w.client
+	return c.trackTranscription(ctx, deepgramProvider, r, w.signals, func(ctx context.Context) (transcriptionResult, error) {
+		var result transcriptionResult
+		var response map[string]interface{}
+		var err error
+		if r.Audio != nil {
+			resp, sendErr := c.providerRequest(ctx, c.providerHTTP, deepgramProvider, "POST", endpoint, r.ContentType, r.Audio)
+			if sendErr != nil {
+				return result, sendErr
+			}
+			defer resp.Body.Close()
+			err = json.NewDecoder(resp.Body).Decode(&response)
+		} else {
+			response, err = c.providerPost(ctx, deepgramProvider, "/listen?"+query.Encode(), map[string]string{"url": r.AudioURL})
+		}
+		if err != nil {
+			return result, err
+		}
+
+		result.response = response
+		metadata, _ := response["metadata"].(map[string]interface{})
+		result.seconds, _ = metadata["duration"].(float64)
+		result.id, _ = metadata["request_id"].(string)
+		return result, nil
+	})
+}
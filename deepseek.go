@@ -0,0 +1,67 @@
+package agentbill
+
+import (
+	"context"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+var deepSeekProvider = &chatProvider{
+	name:    "deepseek",
+	service: "DeepSeek",
+	baseURL: "https://api.deepseek.com/v1",
+	keyEnv:  "DEEPSEEK_API_KEY",
+	record:  recordDeepSeek,
+}
+
+// DeepSeekWrapper wraps DeepSeek chat calls (deepseek-chat, deepseek-reasoner)
+type DeepSeekWrapper struct {
+	client    *Client
+	guardrail GuardrailHook
+}
+
+// WrapDeepSeek wraps the DeepSeek API for tracking. The API key is read from DEEPSEEK_API_KEY.
+func (c *Client) WrapDeepSeek() *DeepSeekWrapper {
+	return &DeepSeekWrapper{client: c}
+}
+
+// WithGuardrail returns a copy of the wrapper that runs hook before every call
+func (w *DeepSeekWrapper) WithGuardrail(hook GuardrailHook) *DeepSeekWrapper {
+	wrapped := *w
+	wrapped.guardrail = hook
+	return &wrapped
+}
+
+// ChatCompletion tracks a DeepSeek chat completion call. Prompt tokens DeepSeek
+// served from its context cache are priced at the model's cached input rate.
+func (w *DeepSeekWrapper) ChatCompletion(ctx context.Context, model string, messages []map[string]string) (map[string]interface{}, error) {
+	return w.client.chatCompletion(ctx, deepSeekProvider, w.guardrail, model, messages)
+}
+
+// ChatCompletionStream streams a DeepSeek chat completion. deepseek-reasoner's
+// reasoning arrives in StreamDelta.Reasoning and is kept as reasoning_content in
+// the assembled response.
+func (w *DeepSeekWrapper) ChatCompletionStream(ctx context.Context, model string, messages []map[string]string, onDelta StreamCallback) (map[string]interface{}, error) {
+	return w.client.chatCompletionStream(ctx, deepSeekProvider, w.guardrail, model, messages, onDelta)
+}
+
+// ReasoningContent returns the reasoning deepseek-reasoner produced before the
+// first choice's answer, or "" when the response has none
+func ReasoningContent(response map[string]interface{}) string {
+	choices, _ := response["choices"].([]interface{})
+	if len(choices) == 0 {
+		return ""
+	}
+	choice, _ := choices[0].(map[string]interface{})
+	message, _ := choice["message"].(map[string]interface{})
+	reasoning, _ := message["reasoning_content"].(string)
+	return reasoning
+}
+
+// recordDeepSeek records the cache misses DeepSeek reports next to its cache hits
+func recordDeepSeek(span *Span, response map[string]interface{}) {
+	usage, _ := response["usage"].(map[string]interface{})
+	if misses, ok := usage["prompt_cache_miss_tokens"].(float64); ok {
+		span.SetAttributes(attribute.Int("response.cache_miss_tokens", int(misses)))
+	}
+}
@@ -0,0 +1,312 @@
+// Package devcollector runs a local stand-in for the AgentBill backend, so
+// developers can inspect what would be billed without an AgentBill account.
+//
+// Point the SDK at it with Config.BaseURL. It accepts spans, signals and usage
+// records, serves the recent-events query used by `agentbill tail`, and renders
+// a small web UI at its root.
+package devcollector
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+// Options configures a Collector
+type Options struct {
+	// Store defaults to an in-memory store
+	Store Store
+	// Pricing overrides or extends the SDK's price table when estimating span cost
+	Pricing map[string]agentbill.ModelPrice
+}
+
+// Collector is an http.Handler implementing the AgentBill ingestion endpoints
+type Collector struct {
+	store   Store
+	pricing *agentbill.Client
+	mux     *http.ServeMux
+	nextID  uint64
+}
+
+// New creates a collector
+func New(opts Options) *Collector {
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore(0)
+	}
+	c := &Collector{
+		store: opts.Store,
+		// A disabled client is only used for its price table
+		pricing: agentbill.Init(agentbill.Config{Disabled: true, Pricing: opts.Pricing}),
+		mux:     http.NewServeMux(),
+	}
+	c.mux.HandleFunc("/functions/v1/otel-collector", c.handleSpans)
+	c.mux.HandleFunc("/functions/v1/record-signals", c.handleSignal)
+	c.mux.HandleFunc("/functions/v1/record-usage", c.handleUsage)
+	c.mux.HandleFunc("/functions/v1/bulk-ingest", c.handleBulk)
+	c.mux.HandleFunc("/functions/v1/recent-events", c.handleRecent)
+	c.mux.HandleFunc("/", c.handleUI)
+	return c
+}
+
+// ServeHTTP implements http.Handler
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mux.ServeHTTP(w, r)
+}
+
+// Server is a collector listening on a local address
+type Server struct {
+	*Collector
+	URL string
+
+	server *http.Server
+}
+
+// Start serves a collector on addr (e.g. "localhost:4318"; ":0" picks a free port)
+func Start(addr string, opts Options) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	collector := New(opts)
+	s := &Server{
+		Collector: collector,
+		URL:       "http://" + listener.Addr().String(),
+		server:    &http.Server{Handler: collector, ReadHeaderTimeout: 10 * time.Second},
+	}
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+func (c *Collector) handleSpans(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") == "application/x-ndjson" {
+		// NDJSON lines carry their own types, so they are handled like a bulk request
+		c.handleBulk(w, r)
+		return
+	}
+	body, ok := readPost(w, r)
+	if !ok {
+		return
+	}
+	spans, err := agentbill.DecodeOTLP(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events := make([]agentbill.Event, 0, len(spans))
+	for _, span := range spans {
+		events = append(events, c.spanEvent(span))
+	}
+	c.store.Append(events...)
+	writeJSON(w, map[string]interface{}{"accepted": len(events)})
+}
+
+func (c *Collector) handleSignal(w http.ResponseWriter, r *http.Request) {
+	body, ok := readPost(w, r)
+	if !ok {
+		return
+	}
+	var signal agentbill.Signal
+	if err := json.Unmarshal(body, &signal); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.store.Append(c.signalEvent(signal))
+	writeJSON(w, map[string]interface{}{"accepted": 1})
+}
+
+func (c *Collector) handleUsage(w http.ResponseWriter, r *http.Request) {
+	body, ok := readPost(w, r)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Records []agentbill.UsageRecord `json:"records"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events := make([]agentbill.Event, 0, len(payload.Records))
+	for _, record := range payload.Records {
+		events = append(events, c.usageEvent(record))
+	}
+	c.store.Append(events...)
+	writeJSON(w, map[string]interface{}{"accepted": len(events)})
+}
+
+func (c *Collector) handleBulk(w http.ResponseWriter, r *http.Request) {
+	body, ok := readPost(w, r)
+	if !ok {
+		return
+	}
+	decode := agentbill.DecodeBulk
+	if r.Header.Get("Content-Type") == "application/x-ndjson" {
+		decode = agentbill.DecodeNDJSON
+	}
+	spans, signals, usage, err := decode(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events := make([]agentbill.Event, 0, len(spans)+len(signals)+len(usage))
+	for _, span := range spans {
+		events = append(events, c.spanEvent(span))
+	}
+	for _, signal := range signals {
+		events = append(events, c.signalEvent(signal))
+	}
+	for _, record := range usage {
+		events = append(events, c.usageEvent(record))
+	}
+	c.store.Append(events...)
+	writeJSON(w, map[string]interface{}{"accepted": len(events)})
+}
+
+func (c *Collector) handleRecent(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var since time.Time
+	if s := query.Get("since"); s != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			http.Error(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	events, err := c.store.Recent(query.Get("customer_id"), since, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []agentbill.Event{}
+	}
+	writeJSON(w, map[string]interface{}{"events": events})
+}
+
+// spanEvent converts a span, estimating its cost from the recorded token counts
+func (c *Collector) spanEvent(span agentbill.SpanRecord) agentbill.Event {
+	event := agentbill.Event{
+		ID:         span.SpanID,
+		Type:       "span",
+		Name:       span.Name,
+		TraceID:    span.TraceID,
+		Time:       span.StartTime,
+		Attributes: span.Attributes,
+	}
+	event.CustomerID, _ = span.Attributes["customer.id"].(string)
+	event.Model, _ = span.Attributes["model"].(string)
+	if event.Model != "" {
+		prompt, _ := span.Attributes["response.prompt_tokens"].(int64)
+		completion, _ := span.Attributes["response.completion_tokens"].(int64)
+		event.CostUSD = c.pricing.EstimateCost(event.Model, int(prompt), int(completion))
+	}
+	return event
+}
+
+func (c *Collector) signalEvent(signal agentbill.Signal) agentbill.Event {
+	attributes := make(map[string]interface{}, len(signal.Data)+5)
+	for k, v := range signal.Data {
+		attributes[k] = v
+	}
+	if signal.Experiment != "" {
+		attributes["experiment.name"] = signal.Experiment
+		attributes["experiment.variant"] = signal.Variant
+	}
+	for key, value := range map[string]string{
+		"organization.id": signal.OrganizationID,
+		"project.id":      signal.ProjectID,
+		"user.id":         signal.UserID,
+	} {
+		if value != "" {
+			attributes[key] = value
+		}
+	}
+	return agentbill.Event{
+		ID:         c.newID("sig"),
+		Type:       "signal",
+		Name:       signal.EventName,
+		CustomerID: signal.CustomerID,
+		TraceID:    signal.TraceID,
+		Time:       time.Unix(signal.Timestamp, 0),
+		Revenue:    signal.Revenue,
+		Attributes: attributes,
+	}
+}
+
+func (c *Collector) usageEvent(record agentbill.UsageRecord) agentbill.Event {
+	return agentbill.Event{
+		ID:         c.newID("usage"),
+		Type:       "usage",
+		Name:       "usage",
+		CustomerID: record.CustomerID,
+		Time:       time.Unix(record.PeriodStart, 0),
+		Model:      record.Model,
+		CostUSD:    record.CostUSD,
+		Attributes: map[string]interface{}{
+			"provider":          record.Provider,
+			"requests":          record.Requests,
+			"prompt_tokens":     record.PromptTokens,
+			"completion_tokens": record.CompletionTokens,
+		},
+	}
+}
+
+func (c *Collector) newID(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, atomic.AddUint64(&c.nextID, 1))
+}
+
+func readPost(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return nil, false
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		reader = gr
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if r.Header.Get("Content-Type") == "application/msgpack" {
+		if body, err = agentbill.MessagePackToJSON(body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	return body, true
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,125 @@
+package devcollector
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+// Store persists collected events. Implementations must be safe for concurrent use.
+type Store interface {
+	Append(events ...agentbill.Event) error
+	// Recent returns events after since, oldest first; an empty customerID matches all
+	Recent(customerID string, since time.Time, limit int) ([]agentbill.Event, error)
+}
+
+const defaultMaxEvents = 10000
+
+// MemoryStore keeps the most recent events in memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	maxEvents int
+	events    []agentbill.Event
+}
+
+// NewMemoryStore creates a store holding at most maxEvents events (default 10000)
+func NewMemoryStore(maxEvents int) *MemoryStore {
+	if maxEvents <= 0 {
+		maxEvents = defaultMaxEvents
+	}
+	return &MemoryStore{maxEvents: maxEvents}
+}
+
+// Append adds events, evicting the oldest beyond the limit
+func (s *MemoryStore) Append(events ...agentbill.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	if overflow := len(s.events) - s.maxEvents; overflow > 0 {
+		s.events = append(s.events[:0:0], s.events[overflow:]...)
+	}
+	return nil
+}
+
+// Recent returns matching events, keeping the newest when more than limit match
+func (s *MemoryStore) Recent(customerID string, since time.Time, limit int) ([]agentbill.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []agentbill.Event
+	for _, event := range s.events {
+		if customerID != "" && event.CustomerID != customerID {
+			continue
+		}
+		if !event.Time.After(since) {
+			continue
+		}
+		matched = append(matched, event)
+	}
+	if limit > 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+	return matched, nil
+}
+
+// FileStore is a MemoryStore that also appends every event to an NDJSON file,
+// reloading it on open so sessions survive restarts
+type FileStore struct {
+	*MemoryStore
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenFileStore opens or creates the NDJSON file at path
+func OpenFileStore(path string, maxEvents int) (*FileStore, error) {
+	memory := NewMemoryStore(maxEvents)
+
+	if existing, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(existing)
+		scanner.Buffer(make([]byte, 64*1024), 16<<20)
+		for scanner.Scan() {
+			var event agentbill.Event
+			if json.Unmarshal(scanner.Bytes(), &event) == nil {
+				memory.Append(event)
+			}
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{MemoryStore: memory, file: file}, nil
+}
+
+// Append writes events to the file and keeps them in memory
+func (s *FileStore) Append(events ...agentbill.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := bufio.NewWriter(s.file)
+	encoder := json.NewEncoder(w)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return s.MemoryStore.Append(events...)
+}
+
+// Close closes the underlying file
+func (s *FileStore) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,102 @@
+package devcollector
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+const uiEventLimit = 200
+
+type customerTotals struct {
+	CustomerID string
+	Spans      int
+	Signals    int
+	CostUSD    float64
+	Revenue    float64
+}
+
+var uiTemplate = template.Must(template.New("ui").Funcs(template.FuncMap{
+	"clock": func(t time.Time) string { return t.Local().Format("15:04:05.000") },
+}).Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="5">
+<title>AgentBill dev collector</title>
+<style>
+body { font: 13px system-ui, sans-serif; margin: 24px; color: #222; }
+table { border-collapse: collapse; margin-bottom: 32px; }
+th, td { padding: 4px 10px; border-bottom: 1px solid #eee; text-align: left; }
+th { background: #f6f6f6; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+code { font-size: 12px; color: #666; }
+</style>
+</head>
+<body>
+<h1>AgentBill dev collector</h1>
+<h2>Totals by customer</h2>
+<table>
+<tr><th>Customer</th><th>Spans</th><th>Signals</th><th>Estimated cost</th><th>Revenue</th></tr>
+{{range .Totals}}<tr><td>{{or .CustomerID "(none)"}}</td><td class="num">{{.Spans}}</td><td class="num">{{.Signals}}</td><td class="num">${{printf "%.6f" .CostUSD}}</td><td class="num">${{printf "%.2f" .Revenue}}</td></tr>
+{{else}}<tr><td colspan="5">Nothing collected yet. Point Config.BaseURL at this server.</td></tr>
+{{end}}</table>
+<h2>Latest {{len .Events}} events</h2>
+<table>
+<tr><th>Time</th><th>Type</th><th>Name</th><th>Customer</th><th>Model</th><th>Cost</th><th>Revenue</th><th>Trace</th></tr>
+{{range .Events}}<tr><td>{{clock .Time}}</td><td>{{.Type}}</td><td>{{.Name}}</td><td>{{.CustomerID}}</td><td>{{.Model}}</td><td class="num">{{if .CostUSD}}${{printf "%.6f" .CostUSD}}{{end}}</td><td class="num">{{if .Revenue}}${{printf "%.2f" .Revenue}}{{end}}</td><td><code>{{.TraceID}}</code></td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func (c *Collector) handleUI(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	all, err := c.store.Recent("", time.Time{}, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	totals := make(map[string]*customerTotals)
+	for _, event := range all {
+		t, ok := totals[event.CustomerID]
+		if !ok {
+			t = &customerTotals{CustomerID: event.CustomerID}
+			totals[event.CustomerID] = t
+		}
+		switch event.Type {
+		case "span":
+			t.Spans++
+		case "signal":
+			t.Signals++
+		}
+		t.CostUSD += event.CostUSD
+		t.Revenue += event.Revenue
+	}
+	sorted := make([]customerTotals, 0, len(totals))
+	for _, t := range totals {
+		sorted = append(sorted, *t)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].CostUSD > sorted[j].CostUSD })
+
+	// Newest first
+	latest := all
+	if len(latest) > uiEventLimit {
+		latest = latest[len(latest)-uiEventLimit:]
+	}
+	events := make([]agentbill.Event, len(latest))
+	for i, event := range latest {
+		events[len(latest)-1-i] = event
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	uiTemplate.Execute(w, map[string]interface{}{"Totals": sorted, "Events": events})
+}
@@ -0,0 +1,117 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Skew beyond this makes span timestamps and usage periods land in the wrong buckets
+const maxClockSkew = 10 * time.Second
+
+// CheckResult is the outcome of one Diagnose check
+type CheckResult struct {
+	Name    string
+	OK      bool
+	Detail  string
+	Latency time.Duration
+}
+
+// Diagnose validates the API key, checks that the collector and signal endpoints
+// are reachable and measures clock skew against the server. It records no data.
+func (c *Client) Diagnose(ctx context.Context) []CheckResult {
+	results := make([]CheckResult, 0, 4)
+
+	if c.config.APIKey == "" {
+		results = append(results, CheckResult{Name: "api_key", Detail: "no API key configured"})
+	} else {
+		results = append(results, CheckResult{Name: "api_key", OK: true, Detail: "configured"})
+	}
+
+	// An empty export authenticates the key without creating any spans
+	empty, _ := json.Marshal(buildOTLPPayload(nil))
+	collector, serverDate := c.probe(ctx, "collector", http.MethodPost, "otel-collector", empty)
+	results = append(results, collector)
+
+	// OPTIONS reaches the signal endpoint without recording a signal
+	signals, _ := c.probe(ctx, "signals", http.MethodOptions, "record-signals", nil)
+	results = append(results, signals)
+
+	skew := CheckResult{Name: "clock_skew"}
+	if serverDate.IsZero() {
+		skew.Detail = "server did not report its time"
+	} else {
+		offset := c.config.clock().Now().Sub(serverDate).Round(time.Second)
+		skew.OK = offset <= maxClockSkew && offset >= -maxClockSkew
+		skew.Detail = fmt.Sprintf("local clock is %v from server time", offset)
+		if correction := c.ClockOffset(); correction != 0 {
+			skew.Detail += fmt.Sprintf(" after a %v correction", correction)
+		}
+	}
+	results = append(results, skew)
+
+	return results
+}
+
+func (c *Client) probe(ctx context.Context, name, method, endpoint string, body []byte) (CheckResult, time.Time) {
+	result := CheckResult{Name: name}
+	url := fmt.Sprintf("%s/functions/v1/%s", c.config.BaseURL, endpoint)
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		result.Detail = err.Error()
+		return result, time.Time{}
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
+	req.Header.Set("Content-Type", "application/json")
+
+	clock := c.config.stopwatch()
+	started := clock.Now()
+	resp, err := c.apiHTTP.Do(req)
+	result.Latency = clock.Now().Sub(started)
+	if err != nil {
+		result.Detail = err.Error()
+		return result, time.Time{}
+	}
+	closeBody(resp.Body)
+
+	serverDate, _ := http.ParseTime(resp.Header.Get("Date"))
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		result.Detail = fmt.Sprintf("API key rejected (status %d)", resp.StatusCode)
+	case resp.StatusCode >= 500:
+		result.Detail = fmt.Sprintf("server error (status %d)", resp.StatusCode)
+	case method == http.MethodPost && resp.StatusCode >= 300:
+		result.Detail = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	default:
+		result.OK = true
+		result.Detail = fmt.Sprintf("reachable (status %d)", resp.StatusCode)
+	}
+	return result, serverDate
+}
+
+// EffectiveConfig returns the client's configuration with defaults filled in
+func (c *Client) EffectiveConfig() Config {
+	config := c.config
+	config.Transport = config.Transport.resolve()
+	if socket := config.Transport.socket; socket != "" {
+		config.BaseURL = "unix://" + socket
+	}
+	if config.Transport.Preset == "" {
+		config.Transport.Preset = PresetDefault
+	}
+	config.ExportProtocol = config.exportProtocol()
+	config.ExportEndpoint = c.tracer.exportEndpoint(config.ExportProtocol)
+	config.MaxExportBytes = c.tracer.maxExportBytes()
+	config.MaxQueueSize = c.tracer.maxQueueSize()
+	if config.BlockTimeout <= 0 {
+		config.BlockTimeout = defaultBlockTimeout
+	}
+	if config.SampleRate <= 0 || config.SampleRate > 1 {
+		config.SampleRate = 1
+	}
+	return config
+}
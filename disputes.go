@@ -0,0 +1,81 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DisputeStatus is the stage of a payment dispute
+type DisputeStatus string
+
+// Dispute stages. Opening a dispute withdraws the disputed amount; winning it
+// reinstates the amount, and losing it (a chargeback) leaves it withdrawn.
+const (
+	DisputeOpened DisputeStatus = "opened"
+	DisputeWon    DisputeStatus = "won"
+	DisputeLost   DisputeStatus = "lost"
+)
+
+// Dispute is a customer's dispute of an earlier payment with their bank
+type Dispute struct {
+	// ID identifies the dispute, e.g. Stripe's dp_...
+	ID string
+	// OriginalKey is the idempotency key of the disputed revenue signal
+	OriginalKey string
+	Status      DisputeStatus
+	// Amount is the positive amount disputed
+	Amount float64
+	// Fee is the processor's dispute fee, recorded in the signal's data
+	Fee    float64
+	Reason string
+	// CustomerID defaults to the client's customer
+	CustomerID string
+}
+
+// TrackDispute records a stage of a dispute as a dispute_<status> signal
+// referencing the original signal in its original_idempotency_key data.
+// dispute_opened carries the disputed amount as negative revenue and
+// dispute_won reinstates it, so net revenue stays accurate; dispute_lost
+// carries none, the amount having been withdrawn when the dispute opened.
+func (c *Client) TrackDispute(ctx context.Context, dispute Dispute) error {
+	if dispute.ID == "" {
+		return errors.New("dispute ID is required")
+	}
+	if dispute.OriginalKey == "" {
+		return errors.New("dispute: original idempotency key is required")
+	}
+	if dispute.Amount < 0 || dispute.Fee < 0 {
+		return errors.New("dispute: amounts must be positive")
+	}
+
+	var revenue float64
+	switch dispute.Status {
+	case DisputeOpened:
+		revenue = -dispute.Amount
+	case DisputeWon:
+		revenue = dispute.Amount
+	case DisputeLost:
+	default:
+		return fmt.Errorf("invalid dispute status: %q", dispute.Status)
+	}
+
+	data := map[string]interface{}{
+		"original_idempotency_key": dispute.OriginalKey,
+		"dispute_id":               dispute.ID,
+		"amount":                   dispute.Amount,
+	}
+	if dispute.Fee > 0 {
+		data["fee"] = dispute.Fee
+	}
+	if dispute.Reason != "" {
+		data["reason"] = dispute.Reason
+	}
+	return c.TrackSignal(ctx, Signal{
+		EventName:      "dispute_" + string(dispute.Status),
+		Revenue:        revenue,
+		CustomerID:     dispute.CustomerID,
+		IdempotencyKey: "dispute:" + dispute.ID + ":" + string(dispute.Status),
+		Data:           data,
+	})
+}
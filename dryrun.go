@@ -0,0 +1,53 @@
+package agentbill
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// dryRunTransport answers AgentBill API requests locally after logging what would have been sent
+type dryRunTransport struct {
+	debug bool
+}
+
+func (d dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := fmt.Sprintf("%d bytes", len(body))
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/otel-collector"):
+		if spans, err := DecodeOTLP(body); err == nil {
+			summary = fmt.Sprintf("%d spans, %s", len(spans), summary)
+		}
+	case strings.HasSuffix(req.URL.Path, "/bulk-ingest"):
+		if spans, signals, usage, err := DecodeBulk(body); err == nil {
+			summary = fmt.Sprintf("%d spans, %d signals, %d usage records, %s", len(spans), len(signals), len(usage), summary)
+		}
+	}
+	fmt.Printf("[AgentBill] Dry run: would %s %s (%s)\n", req.Method, req.URL, summary)
+	if d.debug {
+		fmt.Printf("[AgentBill] Dry run payload: %s\n", body)
+	}
+
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	if req.Header.Get("Content-Type") == "application/grpc" {
+		header = http.Header{"Content-Type": []string{"application/grpc"}, "Grpc-Status": []string{"0"}}
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Status:        "200 OK",
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte("{}"))),
+		ContentLength: 2,
+		Request:       req,
+	}, nil
+}
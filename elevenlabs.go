@@ -0,0 +1,100 @@
+package agentbill
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+const defaultElevenLabsModel = "eleven_multilingual_v2"
+
+var elevenLabsProvider = &chatProvider{
+	name:       "elevenlabs",
+	service:    "ElevenLabs",
+	baseURL:    "https://api.elevenlabs.io/v1",
+	keyEnv:     "ELEVENLABS_API_KEY",
+	authHeader: "xi-api-key",
+}
+
+// SpeechRequest is a text-to-speech request
+type SpeechRequest struct {
+	VoiceID string
+	Text    string
+	// Model defaults to eleven_multilingual_v2
+	Model string
+	// OutputFormat is e.g. "mp3_44100_128"; the API default when empty
+	OutputFormat string
+}
+
+// ElevenLabsWrapper wraps ElevenLabs text-to-speech calls
+type ElevenLabsWrapper struct {
+	client *Client
+}
+
+// WrapElevenLabs wraps the ElevenLabs API for tracking. The API key is read from ELEVENLABS_API_KEY.
+func (c *Client) WrapElevenLabs() *ElevenLabsWrapper {
+	return &ElevenLabsWrapper{client: c}
+}
+
+// TextToSpeech tracks a text-to-speech call and returns the audio. ElevenLabs
+// bills characters, so the span is metered in characters synthesized
+// (usage.unit "characters") and records the voice and voice model.
+func (w *ElevenLabsWrapper) TextToSpeech(ctx context.Context, r SpeechRequest) ([]byte, error) {
+	if r.Model == "" {
+		r.Model = defaultElevenLabsModel
+	}
+	c := w.client
+	clock := c.config.stopwatch()
+	startTime := clock.Now()
+
+	span := c.tracer.startInternal(ctx, "elevenlabs.text_to_speech",
+		attribute.String("model", r.Model),
+		attribute.String("provider", elevenLabsProvider.name),
+		attribute.String("voice.id", r.VoiceID),
+	)
+	ctx = c.withRequestTiming(ctx, span)
+
+	defer func() {
+		latency := clock.Now().Sub(startTime).Milliseconds()
+		span.SetAttributes(attribute.Int64("latency_ms", latency))
+		span.End()
+	}()
+
+	path := "/text-to-speech/" + url.PathEscape(r.VoiceID)
+	if r.OutputFormat != "" {
+		path += "?output_format=" + url.QueryEscape(r.OutputFormat)
+	}
+	resp, err := c.providerSend(ctx, c.providerHTTP, elevenLabsProvider, path, map[string]interface{}{
+		"text":     r.Text,
+		"model_id": r.Model,
+	})
+	if err != nil {
+		recordCallError(span, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		recordCallError(span, err)
+		return nil, err
+	}
+
+	// ElevenLabs reports the characters it billed; count them when it doesn't
+	characters, err := strconv.Atoi(resp.Header.Get("x-character-count"))
+	if err != nil {
+		characters = utf8.RuneCountInString(r.Text)
+	}
+	span.SetAttributes(
+		attribute.Int("tts.characters", characters),
+		attribute.Int("audio.bytes", len(audio)),
+	)
+	c.recordMeteredUsage(span, r.Model, elevenLabsProvider.name, UnitCharacters, float64(characters), c.EstimateUnitCost(r.Model, float64(characters)))
+
+	span.SetStatus(0, "")
+	return audio, nil
+}
@@ -0,0 +1,43 @@
+package agentbill
+
+import "context"
+
+var voyageProvider = &chatProvider{
+	name:    "voyage",
+	service: "Voyage AI",
+	baseURL: "https://api.voyageai.com/v1",
+	keyEnv:  "VOYAGE_API_KEY",
+}
+
+var jinaProvider = &chatProvider{
+	name:    "jina",
+	service: "Jina AI",
+	baseURL: "https://api.jina.ai/v1",
+	keyEnv:  "JINA_API_KEY",
+}
+
+// EmbeddingsWrapper wraps an embeddings API
+type EmbeddingsWrapper struct {
+	client   *Client
+	provider *chatProvider
+}
+
+// WrapVoyage wraps the Voyage AI embeddings API for tracking. The API key is read from VOYAGE_API_KEY.
+func (c *Client) WrapVoyage() *EmbeddingsWrapper {
+	return &EmbeddingsWrapper{client: c, provider: voyageProvider}
+}
+
+// WrapJina wraps the Jina AI embeddings API for tracking. The API key is read from JINA_API_KEY.
+func (c *Client) WrapJina() *EmbeddingsWrapper {
+	return &EmbeddingsWrapper{client: c, provider: jinaProvider}
+}
+
+// Embeddings tracks an embeddings call, priced by the input tokens the provider reports
+func (w *EmbeddingsWrapper) Embeddings(ctx context.Context, model string, input []string) (map[string]interface{}, error) {
+	return w.client.embeddings(ctx, w.provider, model, input)
+}
+
+// Embeddings tracks an OpenAI embeddings call
+func (w *OpenAIWrapper) Embeddings(ctx context.Context, model string, input []string) (map[string]interface{}, error) {
+	return w.client.embeddings(ctx, openAIProvider, model, input)
+}
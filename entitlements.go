@@ -0,0 +1,108 @@
+package agentbill
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const defaultEntitlementTTL = 30 * time.Second
+
+// Entitlement is whether a customer's plan includes a feature, and how much of it
+type Entitlement struct {
+	CustomerID string `json:"customer_id"`
+	Feature    string `json:"feature"`
+	Allowed    bool   `json:"allowed"`
+	Plan       string `json:"plan,omitempty"`
+	// Reason explains a denial, e.g. "not in plan" or "limit reached"
+	Reason string `json:"reason,omitempty"`
+	// Limit and Used are the feature's allowance and use this period; both are
+	// zero for features without a limit
+	Limit     float64   `json:"limit,omitempty"`
+	Used      float64   `json:"used,omitempty"`
+	Unlimited bool      `json:"unlimited,omitempty"`
+	ResetsAt  time.Time `json:"resets_at,omitempty"`
+	AsOf      time.Time `json:"as_of"`
+}
+
+// entitlementCache keeps entitlements for a short TTL so checks can run on every request
+type entitlementCache struct {
+	mu      sync.Mutex
+	entries map[entitlementKey]cachedEntitlement
+}
+
+type entitlementKey struct {
+	customerID string
+	feature    string
+}
+
+type cachedEntitlement struct {
+	entitlement Entitlement
+	fetched     time.Time
+}
+
+func newEntitlementCache() *entitlementCache {
+	return &entitlementCache{entries: make(map[entitlementKey]cachedEntitlement)}
+}
+
+// CheckEntitlement reports whether a customer may use a feature, with its limits.
+// customerID defaults to the client's customer. Answers are cached for
+// Config.EntitlementTTL; if a refresh fails, the last known answer stands, and
+// with none known the error is returned.
+func (c *Client) CheckEntitlement(ctx context.Context, customerID, feature string) (*Entitlement, error) {
+	customerID = c.customerID(customerID)
+	ttl := c.config.EntitlementTTL
+	if ttl == 0 {
+		ttl = defaultEntitlementTTL
+	}
+	key := entitlementKey{customerID, feature}
+	now := c.config.clock().Now()
+
+	c.entitlements.mu.Lock()
+	cached, ok := c.entitlements.entries[key]
+	c.entitlements.mu.Unlock()
+	if ok && now.Sub(cached.fetched) < ttl {
+		entitlement := cached.entitlement
+		return &entitlement, nil
+	}
+
+	params := url.Values{"feature": {feature}}
+	c.setCustomer(params, customerID)
+	var entitlement Entitlement
+	if err := c.getJSON(ctx, "check-entitlement", params, &entitlement); err != nil {
+		if ok {
+			stale := cached.entitlement
+			return &stale, nil
+		}
+		return nil, err
+	}
+	if entitlement.CustomerID == "" {
+		entitlement.CustomerID = customerID
+	}
+	if entitlement.Feature == "" {
+		entitlement.Feature = feature
+	}
+
+	if ttl > 0 {
+		c.entitlements.mu.Lock()
+		c.entitlements.entries[key] = cachedEntitlement{entitlement: entitlement, fetched: now}
+		c.entitlements.mu.Unlock()
+	}
+	return &entitlement, nil
+}
+
+// InvalidateEntitlements drops cached entitlements for a customer, e.g. after a
+// plan change, or for every customer when customerID is empty
+func (c *Client) InvalidateEntitlements(customerID string) {
+	if customerID != "" {
+		customerID = c.customerID(customerID)
+	}
+	c.entitlements.mu.Lock()
+	defer c.entitlements.mu.Unlock()
+	for key := range c.entitlements.entries {
+		if customerID == "" || key.customerID == customerID {
+			delete(c.entitlements.entries, key)
+		}
+	}
+}
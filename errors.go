@@ -0,0 +1,124 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// APIError is returned when an upstream API responds with a non-success status
+type APIError struct {
+	Service    string
+	StatusCode int
+	// RetryAfter is how long the API asked callers to wait before retrying,
+	// from Retry-After or retry-after-ms; zero when it gave no delay
+	RetryAfter time.Duration
+	// RateLimit holds the rate limit headers of the response, when it sent any
+	RateLimit *RateLimit
+}
+
+func (e *APIError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s API returned status: %d (retry after %s)", e.Service, e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("%s API returned status: %d", e.Service, e.StatusCode)
+}
+
+// RateLimit is the request and token budget reported in a provider's
+// x-ratelimit-* response headers; fields the provider omits are zero
+type RateLimit struct {
+	LimitRequests     int
+	RemainingRequests int
+	// ResetRequests is how long until the request budget is restored
+	ResetRequests   time.Duration
+	LimitTokens     int
+	RemainingTokens int
+	ResetTokens     time.Duration
+}
+
+// newAPIError builds the APIError for a failed response, reading its retry and rate
+// limit headers; now, from the configured clock, dates an HTTP-date Retry-After
+func newAPIError(service string, resp *http.Response, now time.Time) *APIError {
+	return statusAPIError(service, resp.StatusCode, resp.Header, now)
+}
+
+// statusAPIError is newAPIError for a status and headers read from a response already closed
+func statusAPIError(service string, status int, header http.Header, now time.Time) *APIError {
+	return &APIError{
+		Service:    service,
+		StatusCode: status,
+		RetryAfter: retryAfter(header, now),
+		RateLimit:  readRateLimit(header),
+	}
+}
+
+// retryAfter reads retry-after-ms, else Retry-After in seconds or as an HTTP date
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// readRateLimit reads OpenAI-style x-ratelimit-* headers, returning nil when there are none
+func readRateLimit(header http.Header) *RateLimit {
+	var found bool
+	number := func(key string) int {
+		n, err := strconv.Atoi(header.Get(key))
+		found = found || err == nil
+		return n
+	}
+	duration := func(key string) time.Duration {
+		d, err := time.ParseDuration(header.Get(key))
+		found = found || err == nil
+		return d
+	}
+	limit := &RateLimit{
+		LimitRequests:     number("X-Ratelimit-Limit-Requests"),
+		RemainingRequests: number("X-Ratelimit-Remaining-Requests"),
+		ResetRequests:     duration("X-Ratelimit-Reset-Requests"),
+		LimitTokens:       number("X-Ratelimit-Limit-Tokens"),
+		RemainingTokens:   number("X-Ratelimit-Remaining-Tokens"),
+		ResetTokens:       duration("X-Ratelimit-Reset-Tokens"),
+	}
+	if !found {
+		return nil
+	}
+	return limit
+}
+
+// recordCallError marks a provider call's span as failed, noting the HTTP status
+// or a timeout so error rates can be broken down by cause
+func recordCallError(span *Span, err error) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		span.SetAttributes(attribute.Int("http.status_code", apiErr.StatusCode))
+		if apiErr.RetryAfter > 0 {
+			span.SetAttributes(attribute.Int64("http.retry_after_ms", apiErr.RetryAfter.Milliseconds()))
+		}
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		span.SetAttributes(attribute.String("error.type", "timeout"))
+	}
+	span.SetStatus(1, err.Error())
+}
@@ -0,0 +1,29 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIErrorRetryAfterUsesConfiguredClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(1700000000, 0).UTC())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", clock.Now().Add(30*time.Second).Format(http.TimeFormat))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := Init(Config{APIKey: "test", BaseURL: server.URL, CustomerID: "customer-123", Clock: clock})
+	err := client.TrackSignal(context.Background(), Signal{EventName: "signup"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("TrackSignal() error = %v, want an APIError", err)
+	}
+	if apiErr.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s measured on the configured clock", apiErr.RetryAfter)
+	}
+}
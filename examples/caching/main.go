@@ -0,0 +1,40 @@
+// Caching serves repeated prompts from memory and records the hits as zero-cost spans.
+// Requires AGENTBILL_API_KEY and OPENAI_API_KEY.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+func main() {
+	if os.Getenv("AGENTBILL_API_KEY") == "" || os.Getenv("OPENAI_API_KEY") == "" {
+		fmt.Println("Set AGENTBILL_API_KEY and OPENAI_API_KEY to run this example")
+		return
+	}
+
+	client := agentbill.Init(agentbill.Config{
+		APIKey:     os.Getenv("AGENTBILL_API_KEY"),
+		BaseURL:    os.Getenv("AGENTBILL_BASE_URL"),
+		CustomerID: "customer-123",
+		Cache:      agentbill.NewMemoryCache(1000),
+		CacheTTL:   time.Hour,
+	})
+	defer client.Flush(context.Background())
+
+	openai := client.WrapOpenAI()
+	messages := []map[string]string{{"role": "user", "content": "What is the capital of France?"}}
+
+	for i := 1; i <= 2; i++ {
+		started := time.Now()
+		if _, err := openai.ChatCompletion(context.Background(), "gpt-4o-mini", messages); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("call %d took %v\n", i, time.Since(started))
+	}
+}
@@ -0,0 +1,44 @@
+// Guardrails block flagged prompts before they reach the model and record the outcome.
+// Requires AGENTBILL_API_KEY and OPENAI_API_KEY.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+func main() {
+	if os.Getenv("AGENTBILL_API_KEY") == "" || os.Getenv("OPENAI_API_KEY") == "" {
+		fmt.Println("Set AGENTBILL_API_KEY and OPENAI_API_KEY to run this example")
+		return
+	}
+
+	client := agentbill.Init(agentbill.Config{
+		APIKey:     os.Getenv("AGENTBILL_API_KEY"),
+		BaseURL:    os.Getenv("AGENTBILL_BASE_URL"),
+		CustomerID: "customer-123",
+	})
+	defer client.Flush(context.Background())
+
+	openai := client.WrapOpenAI()
+	openai = openai.WithGuardrail(openai.ModerationGuardrail("openai-moderation"))
+
+	_, err := openai.ChatCompletion(context.Background(), "gpt-4o-mini", []map[string]string{
+		{"role": "user", "content": "Tell me a joke about databases."},
+	})
+
+	var blocked *agentbill.GuardrailError
+	switch {
+	case errors.As(err, &blocked):
+		fmt.Printf("blocked: %v (categories %v)\n", blocked, blocked.Event.Categories)
+	case err != nil:
+		log.Fatal(err)
+	default:
+		fmt.Println("prompt passed moderation")
+	}
+}
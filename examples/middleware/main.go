@@ -0,0 +1,57 @@
+// Middleware joins an orchestrator and a worker service into one trace over HTTP.
+// Requires AGENTBILL_API_KEY.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+func main() {
+	if os.Getenv("AGENTBILL_API_KEY") == "" {
+		fmt.Println("Set AGENTBILL_API_KEY to run this example")
+		return
+	}
+
+	client := agentbill.Init(agentbill.Config{
+		APIKey:     os.Getenv("AGENTBILL_API_KEY"),
+		BaseURL:    os.Getenv("AGENTBILL_BASE_URL"),
+		CustomerID: "customer-123",
+	})
+	defer client.Flush(context.Background())
+
+	// Worker: spans started from the request context join the caller's trace
+	worker := httptest.NewServer(agentbill.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := client.Tracer().StartSpanContext(r.Context(), "worker.summarize", nil)
+		defer span.End()
+		fmt.Fprintf(w, "worker span %s in trace %s", span.SpanID, span.TraceID)
+	})))
+	defer worker.Close()
+
+	// Orchestrator: outgoing calls carry the current trace and session
+	ctx := agentbill.WithSession(context.Background(), "session-42")
+	root := client.Tracer().StartSpanContext(ctx, "orchestrator.plan", nil)
+	ctx = agentbill.ContextWithSpan(ctx, root)
+
+	httpClient := &http.Client{Transport: &agentbill.PropagatingTransport{}}
+	req, err := http.NewRequestWithContext(ctx, "GET", worker.URL, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	root.End()
+
+	fmt.Printf("orchestrator span %s in trace %s\n%s\n", root.SpanID, root.TraceID, body)
+}
@@ -0,0 +1,36 @@
+// Offline runs the instrumented code path against a mock client, printing what
+// would have been exported. Requires no credentials.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+func main() {
+	client := agentbill.InitMock()
+	ctx := context.Background()
+
+	_, err := client.WrapOpenAI().ChatCompletion(ctx, "gpt-4o-mini", []map[string]string{
+		{"role": "user", "content": "Hello!"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := client.TrackSignal(ctx, agentbill.Signal{EventName: "greeting_sent", Revenue: 0.01}); err != nil {
+		log.Fatal(err)
+	}
+	if err := client.Flush(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, span := range client.Mock().Spans() {
+		fmt.Printf("span %s: %v\n", span.Name, span.Attributes)
+	}
+	for _, signal := range client.Mock().Signals() {
+		fmt.Printf("signal %s: revenue %.2f\n", signal.EventName, signal.Revenue)
+	}
+}
@@ -0,0 +1,45 @@
+// Routing falls back to cheaper models when the primary is rate limited or down.
+// Requires AGENTBILL_API_KEY and OPENAI_API_KEY.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+func main() {
+	if os.Getenv("AGENTBILL_API_KEY") == "" || os.Getenv("OPENAI_API_KEY") == "" {
+		fmt.Println("Set AGENTBILL_API_KEY and OPENAI_API_KEY to run this example")
+		return
+	}
+
+	client := agentbill.Init(agentbill.Config{
+		APIKey:     os.Getenv("AGENTBILL_API_KEY"),
+		BaseURL:    os.Getenv("AGENTBILL_BASE_URL"),
+		CustomerID: "customer-123",
+		Debug:      true,
+	})
+	defer client.Flush(context.Background())
+
+	router := client.NewRouter(agentbill.RouterConfig{
+		Models:    []string{"gpt-4o", "gpt-4-turbo", "gpt-4o-mini"},
+		CostAware: true,
+	})
+
+	response, route, err := router.ChatCompletion(context.Background(), []map[string]string{
+		{"role": "user", "content": "Summarize the plot of Hamlet in one sentence."},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("served by %s after %d attempt(s)\n", route.Model, route.Attempts)
+	if route.FallbackReason != "" {
+		fmt.Printf("fell back because: %s\n", route.FallbackReason)
+	}
+	fmt.Println(response["choices"])
+}
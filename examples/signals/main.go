@@ -0,0 +1,59 @@
+// Signals attribute revenue, feedback and human approvals to the traces that produced them.
+// Requires AGENTBILL_API_KEY.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/agentbill/agentbill-go"
+)
+
+func main() {
+	if os.Getenv("AGENTBILL_API_KEY") == "" {
+		fmt.Println("Set AGENTBILL_API_KEY to run this example")
+		return
+	}
+
+	client := agentbill.Init(agentbill.Config{
+		APIKey:            os.Getenv("AGENTBILL_API_KEY"),
+		BaseURL:           os.Getenv("AGENTBILL_BASE_URL"),
+		CustomerID:        "customer-123",
+		SignalDedupWindow: time.Minute,
+	})
+	defer client.Flush(context.Background())
+
+	ctx := agentbill.WithExperiment(context.Background(), "prompt-v2", "treatment")
+	span := client.Tracer().StartSpanContext(ctx, "agent.resolve_ticket", nil)
+	span.End()
+
+	// Retried deliveries with the same idempotency key are only counted once
+	for attempt := 0; attempt < 2; attempt++ {
+		err := client.TrackSignal(ctx, agentbill.Signal{
+			EventName:      "ticket_resolved",
+			Revenue:        4.99,
+			TraceID:        span.TraceID,
+			IdempotencyKey: "ticket-8812",
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := client.TrackFeedback(ctx, agentbill.Feedback{TraceID: span.TraceID, Thumbs: agentbill.ThumbsUp}); err != nil {
+		log.Fatal(err)
+	}
+
+	pending, err := client.RequestApproval(ctx, agentbill.Approval{ID: "refund-8812", TraceID: span.TraceID})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := pending.Approve(ctx, agentbill.ApprovalDecision{Reviewer: "ops@example.com"}); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("signals recorded for trace", span.TraceID)
+}
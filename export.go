@@ -0,0 +1,172 @@
+package agentbill
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxExportBytes = 4 << 20
+	maxOpenSpanAge        = 5 * time.Minute
+	// exportTimeout bounds exports that run independently of a caller's context
+	exportTimeout = 10 * time.Second
+)
+
+// detachedContext returns a context for sending telemetry on a caller's behalf:
+// it keeps ctx's values but not its cancellation, so the export outlives a
+// request that finishes or is canceled first, and has its own timeout
+func detachedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), exportTimeout)
+}
+
+var (
+	envelopeOnce   sync.Once
+	envelopePrefix []byte
+	envelopeSuffix []byte
+)
+
+// encodeSpan returns the span's OTLP encoding, reusing the cached form from an
+// earlier failed flush when the span hasn't changed since. Writes to the
+// deprecated Attributes map aren't versioned, so spans with one are re-encoded.
+func (t *Tracer) encodeSpan(span *Span) (json.RawMessage, error) {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	if len(span.encoded) > 0 && span.encodedVersion == span.version && span.Attributes == nil {
+		return span.encoded, nil
+	}
+	span.syncAttributeMap()
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(t.spanToOTLP(span)); err != nil {
+		return nil, err
+	}
+	span.encoded = append(span.encoded[:0], bytes.TrimSuffix(buf.Bytes(), []byte("\n"))...)
+	span.encodedVersion = span.version
+	return span.encoded, nil
+}
+
+// otlpEnvelope returns the encoded OTLP payload split around its span array,
+// so pre-encoded spans can be streamed between the two halves
+func otlpEnvelope() ([]byte, []byte) {
+	envelopeOnce.Do(func() {
+		data, _ := json.Marshal(buildOTLPPayload(nil))
+		marker := []byte(`"spans":[`)
+		i := bytes.LastIndex(data, marker) + len(marker)
+		envelopePrefix = data[:i]
+		envelopeSuffix = data[i:]
+	})
+	return envelopePrefix, envelopeSuffix
+}
+
+// otlpEnvelopeSize returns the encoded size of an OTLP payload with no spans
+func otlpEnvelopeSize() int {
+	prefix, suffix := otlpEnvelope()
+	return len(prefix) + len(suffix)
+}
+
+// writeOTLPPayload streams an OTLP payload containing pre-encoded spans to w
+func writeOTLPPayload(w io.Writer, spans []json.RawMessage) error {
+	prefix, suffix := otlpEnvelope()
+	if _, err := w.Write(prefix); err != nil {
+		return err
+	}
+	for i, span := range spans {
+		if i > 0 {
+			if _, err := w.Write([]byte{','}); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(span); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(suffix)
+	return err
+}
+
+// payloadStream produces request bodies that stream an OTLP payload through a
+// pipe rather than materializing it in memory. The transport may call GetBody
+// for retries and may close a body after Do returns, so every pipe is tracked
+// and drained in close before the span bytes can be reused.
+type payloadStream struct {
+	spans    []json.RawMessage
+	size     int64 // uncompressed
+	compress bool
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	readers []*io.PipeReader
+}
+
+func newPayloadStream(spans []json.RawMessage, compress bool) *payloadStream {
+	size := otlpEnvelopeSize()
+	for i, span := range spans {
+		if i > 0 {
+			size++
+		}
+		size += len(span)
+	}
+	return &payloadStream{spans: spans, size: int64(size), compress: compress}
+}
+
+func (p *payloadStream) reader() io.ReadCloser {
+	pr, pw := io.Pipe()
+	p.mu.Lock()
+	p.readers = append(p.readers, pr)
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if !p.compress {
+			pw.CloseWithError(writeOTLPPayload(pw, p.spans))
+			return
+		}
+		gw := gzip.NewWriter(pw)
+		err := writeOTLPPayload(gw, p.spans)
+		if err == nil {
+			err = gw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// close aborts any writer the transport abandoned and waits for all of them to exit
+func (p *payloadStream) close() {
+	p.mu.Lock()
+	for _, pr := range p.readers {
+		pr.Close()
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// chunkEncodedSpans groups encoded spans into [start, end) ranges whose combined
+// size, including separators, stays within limit
+func chunkEncodedSpans(spans []json.RawMessage, limit int) [][2]int {
+	var chunks [][2]int
+	start, size := 0, 0
+	for i, span := range spans {
+		add := len(span)
+		if i > start {
+			add++ // comma separator
+		}
+		if i > start && size+add > limit {
+			chunks = append(chunks, [2]int{start, i})
+			start, size, add = i, 0, len(span)
+		}
+		size += add
+	}
+	if start < len(spans) {
+		chunks = append(chunks, [2]int{start, len(spans)})
+	}
+	return chunks
+}
@@ -0,0 +1,172 @@
+package agentbill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// exportCollector is a test collector that records the span names of each
+// export request and answers with status(names)
+type exportCollector struct {
+	mu       sync.Mutex
+	requests [][]string
+	sizes    []int
+	status   func(names []string) int
+}
+
+func (c *exportCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var payload struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var names []string
+	for _, rs := range payload.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				names = append(names, span.Name)
+			}
+		}
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, names)
+	c.sizes = append(c.sizes, len(body))
+	c.mu.Unlock()
+	w.WriteHeader(c.status(names))
+}
+
+func newExportTest(t *testing.T, config Config, status func(names []string) int) (*Tracer, *exportCollector) {
+	t.Helper()
+	collector := &exportCollector{status: status}
+	server := httptest.NewServer(collector)
+	t.Cleanup(server.Close)
+	config.APIKey = "test"
+	config.BaseURL = server.URL
+	tracer := NewTracer(config)
+	t.Cleanup(tracer.Shutdown)
+	return tracer, collector
+}
+
+func endSpans(tracer *Tracer, names ...string) {
+	for _, name := range names {
+		tracer.startInternal(context.Background(), name, attribute.String("payload", strings.Repeat("x", 200))).End()
+	}
+}
+
+func TestFlushChunksByMaxExportBytes(t *testing.T) {
+	const limit = 4 << 10
+	tracer, collector := newExportTest(t, Config{MaxExportBytes: limit}, func([]string) int { return http.StatusOK })
+	names := []string{"s0", "s1", "s2", "s3", "s4", "s5", "s6", "s7", "s8", "s9"}
+	endSpans(tracer, names...)
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(collector.requests) < 2 {
+		t.Fatalf("got %d requests, want the spans split across several", len(collector.requests))
+	}
+	var got []string
+	for i, request := range collector.requests {
+		if collector.sizes[i] > limit {
+			t.Errorf("request %d is %d bytes, over MaxExportBytes %d", i, collector.sizes[i], limit)
+		}
+		got = append(got, request...)
+	}
+	if strings.Join(got, ",") != strings.Join(names, ",") {
+		t.Errorf("exported %v, want %v in order", got, names)
+	}
+	if stats := tracer.Stats(); stats.ExportedSpans != uint64(len(names)) || stats.BufferedSpans != 0 {
+		t.Errorf("ExportedSpans = %d, BufferedSpans = %d, want %d and 0", stats.ExportedSpans, stats.BufferedSpans, len(names))
+	}
+}
+
+func TestFlushSplitsTooLargeChunks(t *testing.T) {
+	tracer, collector := newExportTest(t, Config{}, func(names []string) int {
+		if len(names) > 2 || containsString(names, "huge") {
+			return http.StatusRequestEntityTooLarge
+		}
+		return http.StatusOK
+	})
+	endSpans(tracer, "a", "b", "c", "huge", "d", "e", "f")
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var delivered []string
+	for _, request := range collector.requests {
+		if len(request) <= 2 && !containsString(request, "huge") {
+			delivered = append(delivered, request...)
+		}
+	}
+	if got := strings.Join(delivered, ","); got != "a,b,c,d,e,f" {
+		t.Errorf("delivered %s, want a,b,c,d,e,f", got)
+	}
+	stats := tracer.Stats()
+	if stats.ExportedSpans != 6 || stats.DroppedSpans != 1 || stats.BufferedSpans != 0 {
+		t.Errorf("ExportedSpans = %d, DroppedSpans = %d, BufferedSpans = %d, want 6, 1 and 0",
+			stats.ExportedSpans, stats.DroppedSpans, stats.BufferedSpans)
+	}
+}
+
+func TestFlushDiscardsRefusedChunks(t *testing.T) {
+	cases := []struct {
+		status       int
+		wantBuffered int
+		wantDropped  uint64
+	}{
+		{http.StatusBadRequest, 0, 3},
+		{http.StatusUnprocessableEntity, 0, 3},
+		{http.StatusUnauthorized, 3, 0},
+		{http.StatusTooManyRequests, 3, 0},
+		{http.StatusServiceUnavailable, 3, 0},
+	}
+	for _, tc := range cases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			tracer, collector := newExportTest(t, Config{}, func([]string) int { return tc.status })
+			endSpans(tracer, "a", "b", "c")
+
+			err := tracer.Flush(context.Background())
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tc.status {
+				t.Fatalf("Flush() error = %v, want an APIError with status %d", err, tc.status)
+			}
+			stats := tracer.Stats()
+			if stats.BufferedSpans != tc.wantBuffered || stats.DroppedSpans != tc.wantDropped {
+				t.Errorf("BufferedSpans = %d, DroppedSpans = %d, want %d and %d",
+					stats.BufferedSpans, stats.DroppedSpans, tc.wantBuffered, tc.wantDropped)
+			}
+
+			tracer.Flush(context.Background())
+			if want := 1 + min(tc.wantBuffered, 1); len(collector.requests) != want {
+				t.Errorf("collector received %d requests after a second flush, want %d", len(collector.requests), want)
+			}
+		})
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,356 @@
+package agentbill
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ExportProtocol selects how spans are sent to the collector. The OTLP values
+// match those of OTEL_EXPORTER_OTLP_PROTOCOL.
+type ExportProtocol string
+
+// Export protocols
+const (
+	// ExportHTTPJSON posts OTLP/JSON over HTTP
+	ExportHTTPJSON ExportProtocol = "http/json"
+	// ExportHTTPProtobuf posts OTLP/protobuf over HTTP
+	ExportHTTPProtobuf ExportProtocol = "http/protobuf"
+	// ExportHTTPMsgPack posts the OTLP/JSON payload encoded as MessagePack, falling
+	// back to JSON if the collector rejects the content type with 415
+	ExportHTTPMsgPack ExportProtocol = "http/msgpack"
+	// ExportHTTPNDJSON posts one JSON object per line, each tagged with its type,
+	// for line-oriented ingestion such as data lakes; not an OTLP protocol
+	ExportHTTPNDJSON ExportProtocol = "http/ndjson"
+	// ExportGRPC calls the OTLP TraceService over gRPC; it needs an https:// endpoint
+	// since HTTP/2 is negotiated over TLS
+	ExportGRPC ExportProtocol = "grpc"
+)
+
+// Compression selects how export bodies are compressed
+type Compression string
+
+// Compression algorithms
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+)
+
+const otlpTraceExportMethod = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+
+// spanExporter delivers one batch of encoded spans. It reports the HTTP status, or
+// its equivalent for gRPC, so that batch splitting, re-queueing of failed batches
+// and metrics behave the same whichever protocol is selected.
+type spanExporter interface {
+	export(ctx context.Context, spans []json.RawMessage) (exportResponse, error)
+}
+
+// exportResponse is the part of a collector's answer the export pipeline acts on
+type exportResponse struct {
+	status int
+	header http.Header
+}
+
+// apiError describes a failed export, with the collector's Retry-After and rate limits
+func (r exportResponse) apiError(now time.Time) *APIError {
+	return statusAPIError("AgentBill", r.status, r.header, now)
+}
+
+// exportProtocol resolves the configured protocol. Disabled clients always use
+// OTLP/JSON so their exports can be decoded into the mock's records.
+func (c Config) exportProtocol() ExportProtocol {
+	if c.Disabled {
+		return ExportHTTPJSON
+	}
+	protocol := c.ExportProtocol
+	if protocol == "" {
+		protocol = ExportProtocol(os.Getenv("AGENTBILL_EXPORT_PROTOCOL"))
+	}
+	switch protocol {
+	case ExportHTTPProtobuf, ExportHTTPNDJSON, ExportHTTPMsgPack, ExportGRPC:
+		return protocol
+	}
+	return ExportHTTPJSON
+}
+
+func (t *Tracer) exporter() spanExporter {
+	if t.redirected() {
+		return redirectExporter{tracer: t}
+	}
+	if t.bulk != nil {
+		return bulkExporter{tracer: t}
+	}
+	protocol := t.exportProtocol()
+	if protocol == ExportGRPC {
+		return grpcExporter{tracer: t}
+	}
+	return httpExporter{tracer: t, protocol: protocol}
+}
+
+// exportProtocol is the configured protocol unless the collector has declined it
+func (t *Tracer) exportProtocol() ExportProtocol {
+	protocol := t.config.exportProtocol()
+	if protocol == ExportHTTPMsgPack && atomic.LoadInt32(&t.msgpackRejected) == 1 {
+		return ExportHTTPJSON
+	}
+	return protocol
+}
+
+// exportEndpoint returns the collector address, ignoring overrides on disabled
+// clients whose mock only answers AgentBill URLs
+func (t *Tracer) exportEndpoint(protocol ExportProtocol) string {
+	if t.config.ExportEndpoint != "" && !t.config.Disabled {
+		return t.config.ExportEndpoint
+	}
+	if protocol == ExportGRPC {
+		return t.config.BaseURL
+	}
+	return fmt.Sprintf("%s/functions/v1/otel-collector", t.config.BaseURL)
+}
+
+type httpExporter struct {
+	tracer   *Tracer
+	protocol ExportProtocol
+}
+
+func (e httpExporter) export(ctx context.Context, spans []json.RawMessage) (exportResponse, error) {
+	t := e.tracer
+	compress := t.config.ExportCompression == CompressionGzip
+	url := t.exportEndpoint(ExportHTTPJSON)
+
+	var req *http.Request
+	switch e.protocol {
+	case ExportHTTPProtobuf, ExportHTTPNDJSON, ExportHTTPMsgPack:
+		payload, contentType, err := encodeSpans(e.protocol, spans)
+		if err != nil {
+			return exportResponse{}, err
+		}
+		if compress {
+			if payload, err = gzipBytes(payload); err != nil {
+				return exportResponse{}, err
+			}
+		}
+		req, err = http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+		if err != nil {
+			return exportResponse{}, err
+		}
+		req.Header.Set("Content-Type", contentType)
+	default:
+		body := newPayloadStream(spans, compress)
+		defer body.close()
+
+		var err error
+		req, err = http.NewRequestWithContext(ctx, "POST", url, body.reader())
+		if err != nil {
+			return exportResponse{}, err
+		}
+		if !compress {
+			req.ContentLength = body.size
+		}
+		req.GetBody = func() (io.ReadCloser, error) { return body.reader(), nil }
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	if err := t.setExportAuth(req); err != nil {
+		return exportResponse{}, err
+	}
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return exportResponse{}, err
+	}
+	var response []byte
+	if resp.StatusCode == http.StatusOK {
+		response, _ = io.ReadAll(io.LimitReader(resp.Body, maxExportResponseBytes))
+	}
+	closeBody(resp.Body)
+
+	if resp.StatusCode == http.StatusUnsupportedMediaType && e.protocol == ExportHTTPMsgPack {
+		t.rejectMsgPack()
+		return httpExporter{tracer: t, protocol: ExportHTTPJSON}.export(ctx, spans)
+	}
+	if resp.StatusCode == http.StatusOK {
+		t.recordPartialSuccess(readPartialSuccess(response, resp.Header.Get("Content-Type")))
+	}
+	return exportResponse{status: resp.StatusCode, header: resp.Header}, nil
+}
+
+// encodeSpans builds a complete request body for protocols that can't be streamed
+func encodeSpans(protocol ExportProtocol, spans []json.RawMessage) ([]byte, string, error) {
+	switch protocol {
+	case ExportHTTPProtobuf:
+		payload, err := encodeOTLPProto(spans)
+		return payload, "application/x-protobuf", err
+	case ExportHTTPMsgPack:
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if err := writeOTLPPayload(buf, spans); err != nil {
+			return nil, "", err
+		}
+		payload, err := jsonToMsgPack(buf.Bytes())
+		return payload, "application/msgpack", err
+	}
+	var buf bytes.Buffer
+	err := writeNDJSON(&buf, spans, nil, nil)
+	return buf.Bytes(), "application/x-ndjson", err
+}
+
+// rejectMsgPack switches exports to JSON after the collector answers 415 to MessagePack
+func (t *Tracer) rejectMsgPack() {
+	if atomic.CompareAndSwapInt32(&t.msgpackRejected, 0, 1) && t.config.Debug {
+		fmt.Println("[AgentBill] Collector does not accept MessagePack, exporting JSON")
+	}
+}
+
+type grpcExporter struct {
+	tracer *Tracer
+}
+
+func (e grpcExporter) export(ctx context.Context, spans []json.RawMessage) (exportResponse, error) {
+	t := e.tracer
+	message, err := encodeOTLPProto(spans)
+	if err != nil {
+		return exportResponse{}, err
+	}
+	compressed := byte(0)
+	if t.config.ExportCompression == CompressionGzip {
+		if message, err = gzipBytes(message); err != nil {
+			return exportResponse{}, err
+		}
+		compressed = 1
+	}
+
+	// gRPC frames each message with a compression flag and a big-endian length
+	frame := make([]byte, 5, 5+len(message))
+	frame[0] = compressed
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	frame = append(frame, message...)
+
+	url := strings.TrimSuffix(t.exportEndpoint(ExportGRPC), "/") + otlpTraceExportMethod
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(frame))
+	if err != nil {
+		return exportResponse{}, err
+	}
+	if req.URL.Scheme != "https" && !t.config.DryRun {
+		return exportResponse{}, fmt.Errorf("gRPC export requires an https:// endpoint, got %s", req.URL.Scheme)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("Grpc-Accept-Encoding", "gzip")
+	if compressed == 1 {
+		req.Header.Set("Grpc-Encoding", "gzip")
+	}
+	if err := t.setExportAuth(req); err != nil {
+		return exportResponse{}, err
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return exportResponse{}, err
+	}
+	defer resp.Body.Close()
+	// Trailers are only populated once the body has been read
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, maxExportResponseBytes))
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return exportResponse{status: resp.StatusCode, header: resp.Header}, nil
+	}
+	code := grpcTrailer(resp, "Grpc-Status")
+	if code == "" {
+		return exportResponse{}, errors.New("gRPC export response has no grpc-status")
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return exportResponse{}, fmt.Errorf("invalid grpc-status %q", code)
+	}
+	status := grpcStatusToHTTP(n, grpcTrailer(resp, "Grpc-Message"))
+	if status == http.StatusOK {
+		t.recordPartialSuccess(readGRPCPartialSuccess(response))
+	}
+	return exportResponse{status: status, header: resp.Header}, nil
+}
+
+// grpcTrailer reads a gRPC trailer, falling back to the headers where
+// trailers-only responses carry it
+func grpcTrailer(resp *http.Response, key string) string {
+	if value := resp.Trailer.Get(key); value != "" {
+		return value
+	}
+	return resp.Header.Get(key)
+}
+
+// grpcStatusToHTTP maps gRPC status codes onto the HTTP statuses the export
+// pipeline acts on
+func grpcStatusToHTTP(code int, message string) int {
+	switch code {
+	case 0: // OK
+		return http.StatusOK
+	case 3: // INVALID_ARGUMENT
+		return http.StatusBadRequest
+	case 4: // DEADLINE_EXCEEDED
+		return http.StatusGatewayTimeout
+	case 7: // PERMISSION_DENIED
+		return http.StatusForbidden
+	case 8: // RESOURCE_EXHAUSTED, also used for messages over the server's size limit
+		if strings.Contains(message, "larger than max") {
+			return http.StatusRequestEntityTooLarge
+		}
+		return http.StatusTooManyRequests
+	case 12: // UNIMPLEMENTED
+		return http.StatusNotFound
+	case 14: // UNAVAILABLE
+		return http.StatusServiceUnavailable
+	case 16: // UNAUTHENTICATED
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	gw := gzip.NewWriter(buf)
+	if _, err := gw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+// readRequestBody reads and closes req's body, undoing gzip content encoding and
+// converting MessagePack to JSON
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	var reader io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		reader = gr
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil || req.Header.Get("Content-Type") != "application/msgpack" {
+		return body, err
+	}
+	return MessagePackToJSON(body)
+}
@@ -0,0 +1,50 @@
+package agentbill
+
+import (
+	"context"
+	"fmt"
+)
+
+// Thumbs values accepted by Feedback
+const (
+	ThumbsUp   = "up"
+	ThumbsDown = "down"
+)
+
+// Feedback represents a quality signal for a previously traced call
+type Feedback struct {
+	TraceID string
+	SpanID  string
+	Score   float64
+	Thumbs  string
+	Comment string
+}
+
+// TrackFeedback records an evaluation or user feedback signal tied to a trace
+func (c *Client) TrackFeedback(ctx context.Context, feedback Feedback) error {
+	if feedback.TraceID == "" {
+		return fmt.Errorf("feedback trace ID is required")
+	}
+	if feedback.Thumbs != "" && feedback.Thumbs != ThumbsUp && feedback.Thumbs != ThumbsDown {
+		return fmt.Errorf("invalid thumbs value: %q", feedback.Thumbs)
+	}
+
+	data := map[string]interface{}{
+		"score": feedback.Score,
+	}
+	if feedback.SpanID != "" {
+		data["span_id"] = feedback.SpanID
+	}
+	if feedback.Thumbs != "" {
+		data["thumbs"] = feedback.Thumbs
+	}
+	if feedback.Comment != "" {
+		data["comment"] = feedback.Comment
+	}
+
+	return c.TrackSignal(ctx, Signal{
+		EventName: "feedback",
+		TraceID:   feedback.TraceID,
+		Data:      data,
+	})
+}
@@ -0,0 +1,85 @@
+package agentbill
+
+import (
+	"context"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+var fireworksProvider = &chatProvider{
+	name:    "fireworks",
+	service: "Fireworks",
+	baseURL: "https://api.fireworks.ai/inference/v1",
+	keyEnv:  "FIREWORKS_API_KEY",
+	extra:   map[string]interface{}{"perf_metrics_in_response": true},
+	record:  recordFireworks,
+}
+
+// FireworksWrapper wraps Fireworks AI chat and embeddings calls
+type FireworksWrapper struct {
+	client    *Client
+	guardrail GuardrailHook
+}
+
+// WrapFireworks wraps the Fireworks API for tracking. The API key is read from FIREWORKS_API_KEY.
+func (c *Client) WrapFireworks() *FireworksWrapper {
+	return &FireworksWrapper{client: c}
+}
+
+// WithGuardrail returns a copy of the wrapper that runs hook before every chat call
+func (w *FireworksWrapper) WithGuardrail(hook GuardrailHook) *FireworksWrapper {
+	wrapped := *w
+	wrapped.guardrail = hook
+	return &wrapped
+}
+
+// ChatCompletion tracks a Fireworks chat completion call. Fireworks is asked for
+// its performance metrics, and the span records how speculative decoding did.
+func (w *FireworksWrapper) ChatCompletion(ctx context.Context, model string, messages []map[string]string) (map[string]interface{}, error) {
+	return w.client.chatCompletion(ctx, fireworksProvider, w.guardrail, model, messages)
+}
+
+// ChatCompletionStream streams a Fireworks chat completion, see OpenAIWrapper.ChatCompletionStream
+func (w *FireworksWrapper) ChatCompletionStream(ctx context.Context, model string, messages []map[string]string, onDelta StreamCallback) (map[string]interface{}, error) {
+	return w.client.chatCompletionStream(ctx, fireworksProvider, w.guardrail, model, messages, onDelta)
+}
+
+// Embeddings tracks a Fireworks embeddings call
+func (w *FireworksWrapper) Embeddings(ctx context.Context, model string, input []string) (map[string]interface{}, error) {
+	return w.client.embeddings(ctx, fireworksProvider, model, input)
+}
+
+// recordFireworks records the speculative decoding and server timing metrics
+// Fireworks returns in perf_metrics
+func recordFireworks(span *Span, response map[string]interface{}) {
+	metrics, ok := response["perf_metrics"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if v, ok := metrics["speculation-prompt-tokens"].(float64); ok {
+		span.SetAttributes(attribute.Int("speculation.prompt_tokens", int(v)))
+	}
+	if v, ok := metrics["speculation-generated-tokens"].(float64); ok {
+		span.SetAttributes(attribute.Int("speculation.generated_tokens", int(v)))
+	}
+	// Acceptance is reported per speculated position; the span gets their mean
+	switch acceptance := metrics["speculation-acceptance"].(type) {
+	case float64:
+		span.SetAttributes(attribute.Float64("speculation.acceptance_rate", acceptance))
+	case []interface{}:
+		var sum float64
+		var n int
+		for _, a := range acceptance {
+			if v, ok := a.(float64); ok {
+				sum += v
+				n++
+			}
+		}
+		if n > 0 {
+			span.SetAttributes(attribute.Float64("speculation.acceptance_rate", sum/float64(n)))
+		}
+	}
+	if v, ok := metrics["server-time-to-first-token"].(float64); ok {
+		span.SetAttributes(attribute.Int64("server.ttft_ms", int64(v*1000)))
+	}
+}
@@ -0,0 +1,91 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+func FuzzValueToOTLP(f *testing.F) {
+	f.Add("key", "value", int64(0), 0.0, false)
+	f.Add("", "bad\xffutf8", int64(math.MinInt64), math.NaN(), true)
+	f.Add("ключ", "\x00\n\t", int64(math.MaxInt64), math.Inf(-1), false)
+	f.Add("k", "<&>", int64(-1), math.MaxFloat64, true)
+
+	tracer := NewTracer(Config{})
+	f.Fuzz(func(t *testing.T, key, s string, i int64, fl float64, b bool) {
+		values := []attribute.KeyValue{
+			attribute.String(key, s),
+			attribute.Int64(key, i),
+			attribute.Float64(key, fl),
+			attribute.Bool(key, b),
+			attribute.Any(key, s),
+			attribute.Any(key, []interface{}{s, i, fl}),
+		}
+		for _, kv := range values {
+			if _, err := json.Marshal(tracer.valueToOTLP(kv.Value)); err != nil {
+				t.Fatalf("%v (%v) does not encode: %v", kv.Value.AsInterface(), kv.Value.Type(), err)
+			}
+		}
+
+		span := tracer.Start(context.Background(), key, values...)
+		span.AddEvent(s, values...)
+		span.End()
+		encoded, err := tracer.encodeSpan(span)
+		if err != nil {
+			t.Fatalf("span does not encode: %v", err)
+		}
+
+		var payload bytes.Buffer
+		if err := writeOTLPPayload(&payload, []json.RawMessage{encoded}); err != nil {
+			t.Fatal(err)
+		}
+		records, err := DecodeOTLP(payload.Bytes())
+		if err != nil || len(records) != 1 {
+			t.Fatalf("payload does not decode: %v (%d spans)", err, len(records))
+		}
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func FuzzChatCompletionResponse(f *testing.F) {
+	f.Add([]byte(`{"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`))
+	f.Add([]byte(`{"usage":null}`))
+	f.Add([]byte(`{"usage":"lots"}`))
+	f.Add([]byte(`{"usage":{"prompt_tokens":"10","completion_tokens":[1],"total_tokens":{}}}`))
+	f.Add([]byte(`{"usage":{"prompt_tokens":-3,"completion_tokens":1e300,"total_tokens":1.5}}`))
+	f.Add([]byte(`[1,2,3]`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`{"usage":{"prompt_tokens":`))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		client := Init(Config{Disabled: true, Cache: NewMemoryCache(1), AggregateUsage: true})
+		client.providerHTTP.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(bytes.NewReader(body)),
+				Request:    req,
+			}, nil
+		})
+
+		messages := []map[string]string{{"role": "user", "content": "hi"}}
+		// Errors are fine; panics are not. The second call exercises the cache path.
+		for i := 0; i < 2; i++ {
+			client.WrapOpenAI().ChatCompletion(context.Background(), "gpt-4o", messages)
+		}
+		if err := client.Flush(context.Background()); err != nil {
+			t.Fatalf("flush failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,233 @@
+package agentbill
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	googleTokenURL      = "https://oauth2.googleapis.com/token"
+	googleCloudScope    = "https://www.googleapis.com/auth/cloud-platform"
+	googleMetadataToken = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	// Tokens are refreshed this long before they expire
+	googleTokenSlack = time.Minute
+)
+
+// googleCredentials is an Application Default Credentials file: a service
+// account key or the authorized_user file written by gcloud auth application-default login
+type googleCredentials struct {
+	Type           string `json:"type"`
+	ProjectID      string `json:"project_id"`
+	QuotaProjectID string `json:"quota_project_id"`
+	ClientEmail    string `json:"client_email"`
+	PrivateKey     string `json:"private_key"`
+	TokenURI       string `json:"token_uri"`
+	ClientID       string `json:"client_id"`
+	ClientSecret   string `json:"client_secret"`
+	RefreshToken   string `json:"refresh_token"`
+}
+
+// findGoogleCredentials reads the ADC file named by GOOGLE_APPLICATION_CREDENTIALS,
+// else gcloud's well-known file. It returns nil when neither exists, meaning the
+// metadata server is the remaining source.
+func findGoogleCredentials() (*googleCredentials, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		path = gcloudCredentialsPath()
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("google credentials: %w", err)
+	}
+	var creds googleCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("google credentials %s: %w", path, err)
+	}
+	return &creds, nil
+}
+
+func gcloudCredentialsPath() string {
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else {
+			home, _ := os.UserHomeDir()
+			dir = filepath.Join(home, ".config", "gcloud")
+		}
+	}
+	return filepath.Join(dir, "application_default_credentials.json")
+}
+
+// googleTokenSource returns cached OAuth access tokens from Application Default
+// Credentials: a service account key, gcloud user credentials or, on Google
+// Cloud, the metadata server.
+type googleTokenSource struct {
+	httpClient *http.Client
+	clock      Clock
+
+	mu      sync.Mutex
+	loaded  bool
+	creds   *googleCredentials
+	token   string
+	expires time.Time
+}
+
+func (s *googleTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	if s.token != "" && now.Add(googleTokenSlack).Before(s.expires) {
+		return s.token, nil
+	}
+	if !s.loaded {
+		creds, err := findGoogleCredentials()
+		if err != nil {
+			return "", err
+		}
+		s.creds, s.loaded = creds, true
+	}
+
+	var req *http.Request
+	var err error
+	switch {
+	case s.creds == nil:
+		req, err = http.NewRequestWithContext(ctx, "GET", googleMetadataToken, nil)
+		if err == nil {
+			req.Header.Set("Metadata-Flavor", "Google")
+		}
+	case s.creds.Type == "service_account":
+		var assertion string
+		if assertion, err = s.creds.assertion(now); err == nil {
+			req, err = googleTokenRequest(ctx, s.creds.tokenURL(), url.Values{
+				"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+				"assertion":  {assertion},
+			})
+		}
+	case s.creds.Type == "authorized_user":
+		req, err = googleTokenRequest(ctx, s.creds.tokenURL(), url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {s.creds.ClientID},
+			"client_secret": {s.creds.ClientSecret},
+			"refresh_token": {s.creds.RefreshToken},
+		})
+	default:
+		return "", fmt.Errorf("google credentials: unsupported type %q; pass a token source instead", s.creds.Type)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("google credentials: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError("Google OAuth", resp, s.clock.Now())
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("google credentials: token response has no access_token")
+	}
+	s.token = token.AccessToken
+	s.expires = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// project is the project named by the credentials file, if any
+func (s *googleTokenSource) project() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		creds, err := findGoogleCredentials()
+		if err != nil {
+			return ""
+		}
+		s.creds, s.loaded = creds, true
+	}
+	if s.creds == nil {
+		return ""
+	}
+	if s.creds.ProjectID != "" {
+		return s.creds.ProjectID
+	}
+	return s.creds.QuotaProjectID
+}
+
+func googleTokenRequest(ctx context.Context, tokenURL string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+func (g *googleCredentials) tokenURL() string {
+	if g.TokenURI != "" {
+		return g.TokenURI
+	}
+	return googleTokenURL
+}
+
+// assertion is the RS256-signed JWT a service account exchanges for an access token
+func (g *googleCredentials) assertion(now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(g.PrivateKey))
+	if block == nil {
+		return "", errors.New("google credentials: private_key is not PEM")
+	}
+	var key *rsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return "", errors.New("google credentials: private_key is not an RSA key")
+		}
+		key = rsaKey
+	} else if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+		return "", fmt.Errorf("google credentials: %w", err)
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   g.ClientEmail,
+		"scope": googleCloudScope,
+		"aud":   g.tokenURL(),
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,158 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// StreamMeterOptions describes how a model server reports usage on a gRPC stream
+type StreamMeterOptions struct {
+	// Model and Provider label the span and price the usage
+	Model    string
+	Provider string
+	// PromptTokensKey and CompletionTokensKey name the metadata keys carrying token
+	// counts (default "x-usage-prompt-tokens" and "x-usage-completion-tokens")
+	PromptTokensKey     string
+	CompletionTokensKey string
+	// Cumulative means each report carries running totals rather than increments,
+	// as is usual for trailers
+	Cumulative bool
+}
+
+// StreamMeter accumulates usage reported over a bidirectional gRPC stream into a
+// single span. Call it from a stream interceptor: pass header, trailer and
+// periodic usage metadata (metadata.MD works as is) to ObserveMetadata.
+type StreamMeter struct {
+	client *Client
+	opts   StreamMeterOptions
+	span   *Span
+
+	mu               sync.Mutex
+	ended            bool
+	sent             int
+	received         int
+	promptTokens     int
+	completionTokens int
+}
+
+// MeterStream starts metering a stream for the RPC method (e.g. "/inference.Model/Generate")
+func (c *Client) MeterStream(ctx context.Context, method string, opts StreamMeterOptions) *StreamMeter {
+	if opts.PromptTokensKey == "" {
+		opts.PromptTokensKey = "x-usage-prompt-tokens"
+	}
+	if opts.CompletionTokensKey == "" {
+		opts.CompletionTokensKey = "x-usage-completion-tokens"
+	}
+	if opts.Provider == "" {
+		opts.Provider = "grpc"
+	}
+
+	span := c.tracer.startInternal(ctx, "grpc.stream",
+		attribute.String("rpc.method", method),
+		attribute.String("model", opts.Model),
+		attribute.String("provider", opts.Provider),
+	)
+	return &StreamMeter{client: c, opts: opts, span: span}
+}
+
+// Span returns the stream's span so children can join its trace
+func (m *StreamMeter) Span() *Span {
+	return m.span
+}
+
+// ObserveSend counts a message sent on the stream
+func (m *StreamMeter) ObserveSend() {
+	m.mu.Lock()
+	m.sent++
+	m.mu.Unlock()
+}
+
+// ObserveRecv counts a message received on the stream
+func (m *StreamMeter) ObserveRecv() {
+	m.mu.Lock()
+	m.received++
+	m.mu.Unlock()
+}
+
+// ObserveMetadata reads token counts from headers, trailers or in-band metadata
+func (m *StreamMeter) ObserveMetadata(md map[string][]string) {
+	prompt, hasPrompt := metadataInt(md, m.opts.PromptTokensKey)
+	completion, hasCompletion := metadataInt(md, m.opts.CompletionTokensKey)
+	if !hasPrompt && !hasCompletion {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.opts.Cumulative {
+		if hasPrompt {
+			m.promptTokens = prompt
+		}
+		if hasCompletion {
+			m.completionTokens = completion
+		}
+		return
+	}
+	m.promptTokens += prompt
+	m.completionTokens += completion
+}
+
+// AddUsage adds token counts parsed from stream messages themselves
+func (m *StreamMeter) AddUsage(promptTokens, completionTokens int) {
+	m.mu.Lock()
+	m.promptTokens += promptTokens
+	m.completionTokens += completionTokens
+	m.mu.Unlock()
+}
+
+// End records the stream's span; err is the stream's final status (nil or io.EOF for success).
+// Calls after the first have no effect.
+func (m *StreamMeter) End(err error) {
+	m.mu.Lock()
+	if m.ended {
+		m.mu.Unlock()
+		return
+	}
+	m.ended = true
+	prompt, completion := m.promptTokens, m.completionTokens
+	sent, received := m.sent, m.received
+	m.mu.Unlock()
+
+	m.span.SetAttributes(
+		attribute.Int("stream.messages_sent", sent),
+		attribute.Int("stream.messages_received", received),
+		attribute.Int("response.prompt_tokens", prompt),
+		attribute.Int("response.completion_tokens", completion),
+		attribute.Int("response.total_tokens", prompt+completion),
+		attribute.Int64("stream.duration_ms", (m.span.now()-m.span.StartTime)/1e6),
+	)
+	m.client.recordUsage(m.span, m.opts.Model, m.opts.Provider, prompt, completion)
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		recordCallError(m.span, err)
+	} else {
+		m.span.SetStatus(0, "")
+	}
+	m.span.End()
+}
+
+func metadataInt(md map[string][]string, key string) (int, bool) {
+	values := md[strings.ToLower(key)]
+	if len(values) == 0 {
+		values = md[key]
+	}
+	if len(values) == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(values[len(values)-1]))
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
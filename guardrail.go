@@ -0,0 +1,173 @@
+package agentbill
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// Guardrail actions recorded on spans and signals
+const (
+	GuardrailBlockedPrompt  = "blocked_prompt"
+	GuardrailFilteredOutput = "filtered_output"
+	GuardrailFlagged        = "flagged"
+)
+
+// GuardrailEvent describes the outcome of a guardrail or moderation policy
+type GuardrailEvent struct {
+	Policy     string
+	Action     string
+	Reason     string
+	Categories []string
+	TraceID    string
+}
+
+// GuardrailHook inspects a prompt before it is sent. Returning an event with
+// Action GuardrailBlockedPrompt blocks the call; other events are only recorded.
+type GuardrailHook func(ctx context.Context, model string, messages []map[string]string) (*GuardrailEvent, error)
+
+// GuardrailError is returned when a guardrail hook blocks a call
+type GuardrailError struct {
+	Event GuardrailEvent
+}
+
+func (e *GuardrailError) Error() string {
+	if e.Event.Reason != "" {
+		return fmt.Sprintf("blocked by guardrail %s: %s", e.Event.Policy, e.Event.Reason)
+	}
+	return fmt.Sprintf("blocked by guardrail %s", e.Event.Policy)
+}
+
+// TrackGuardrail records a guardrail outcome as a signal
+func (c *Client) TrackGuardrail(ctx context.Context, event GuardrailEvent) error {
+	if event.Policy == "" || event.Action == "" {
+		return fmt.Errorf("guardrail policy and action are required")
+	}
+	return c.TrackSignal(ctx, Signal{
+		EventName: "guardrail_" + event.Action,
+		TraceID:   event.TraceID,
+		Data:      event.attributes(),
+	})
+}
+
+// RecordGuardrail attaches a guardrail outcome to the span as an event
+func (s *Span) RecordGuardrail(event GuardrailEvent) {
+	s.SetAttributes(
+		attribute.String("guardrail.action", event.Action),
+		attribute.String("guardrail.policy", event.Policy),
+	)
+
+	attributes := event.attributes()
+	attrs := make([]attribute.KeyValue, 0, len(attributes))
+	for k, v := range attributes {
+		attrs = append(attrs, attribute.Any(k, v))
+	}
+	s.AddEvent("guardrail", attrs...)
+}
+
+func (e GuardrailEvent) attributes() map[string]interface{} {
+	attributes := map[string]interface{}{
+		"guardrail.policy": e.Policy,
+		"guardrail.action": e.Action,
+	}
+	if e.Reason != "" {
+		attributes["guardrail.reason"] = e.Reason
+	}
+	if len(e.Categories) > 0 {
+		attributes["guardrail.categories"] = strings.Join(e.Categories, ",")
+	}
+	return attributes
+}
+
+// WithGuardrail returns a copy of the wrapper that runs hook before every call
+func (w *OpenAIWrapper) WithGuardrail(hook GuardrailHook) *OpenAIWrapper {
+	wrapped := *w
+	wrapped.guardrail = hook
+	return &wrapped
+}
+
+func checkGuardrail(ctx context.Context, hook GuardrailHook, model string, messages []map[string]string, span *Span) error {
+	// Requests the hook makes, such as a moderation call, are not the provider call
+	// whose timings the span records
+	event, err := hook(withoutRequestTiming(ctx), model, messages)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return nil
+	}
+
+	event.TraceID = span.TraceID
+	span.RecordGuardrail(*event)
+	if event.Action == GuardrailBlockedPrompt {
+		return &GuardrailError{Event: *event}
+	}
+	return nil
+}
+
+// ModerationGuardrail returns a hook that blocks prompts flagged by the OpenAI moderation endpoint
+func (w *OpenAIWrapper) ModerationGuardrail(policy string) GuardrailHook {
+	return func(ctx context.Context, model string, messages []map[string]string) (*GuardrailEvent, error) {
+		inputs := make([]string, 0, len(messages))
+		for _, message := range messages {
+			if message["role"] == "user" {
+				inputs = append(inputs, message["content"])
+			}
+		}
+		if len(inputs) == 0 {
+			return nil, nil
+		}
+
+		result, err := w.post(ctx, "/moderations", map[string]interface{}{"input": inputs})
+		if err != nil {
+			return nil, err
+		}
+		event := GuardrailFromModeration(policy, result)
+		if event != nil {
+			event.Action = GuardrailBlockedPrompt
+		}
+		return event, nil
+	}
+}
+
+// GuardrailFromModeration converts a moderation API response into a guardrail event,
+// returning nil when nothing was flagged
+func GuardrailFromModeration(policy string, moderation map[string]interface{}) *GuardrailEvent {
+	results, _ := moderation["results"].([]interface{})
+	flagged := false
+	seen := make(map[string]bool)
+	for _, r := range results {
+		result, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if f, _ := result["flagged"].(bool); f {
+			flagged = true
+		}
+		categories, _ := result["categories"].(map[string]interface{})
+		for name, value := range categories {
+			if v, _ := value.(bool); v {
+				seen[name] = true
+			}
+		}
+	}
+	if !flagged {
+		return nil
+	}
+
+	categories := make([]string, 0, len(seen))
+	for name := range seen {
+		categories = append(categories, name)
+	}
+	sort.Strings(categories)
+
+	return &GuardrailEvent{
+		Policy:     policy,
+		Action:     GuardrailFlagged,
+		Reason:     "moderation flagged",
+		Categories: categories,
+	}
+}
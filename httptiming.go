@@ -0,0 +1,116 @@
+package agentbill
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+type requestTimingKey struct{}
+
+// requestTiming collects the phases of a provider span's first HTTP request as
+// http.dns_ms, http.connect_ms, http.tls_ms and http.ttfb_ms (request start to
+// first response byte), plus http.conn_reused. A reused connection skips the
+// DNS, connect and TLS phases. Later requests for the span, such as polls and
+// retries, are not traced, nor are requests made by guardrail hooks.
+type requestTiming struct {
+	span   *Span
+	clock  Clock
+	traced int32
+
+	mu     sync.Mutex
+	starts map[string]time.Time
+	attrs  []attribute.KeyValue
+}
+
+// withRequestTiming returns a context whose first provider request records its timings on span
+func (c *Client) withRequestTiming(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, requestTimingKey{}, &requestTiming{
+		span:   span,
+		clock:  c.config.stopwatch(),
+		starts: make(map[string]time.Time),
+	})
+}
+
+// withoutRequestTiming returns a context whose requests are not traced for the span
+// of an enclosing withRequestTiming
+func withoutRequestTiming(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(requestTimingKey{}).(*requestTiming); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, requestTimingKey{}, (*requestTiming)(nil))
+}
+
+// traceRequest attaches an httptrace.ClientTrace to the first request made with a
+// context from withRequestTiming. The returned function records the timings on the
+// span once the response headers have arrived.
+func traceRequest(ctx context.Context) (context.Context, func()) {
+	timing, ok := ctx.Value(requestTimingKey{}).(*requestTiming)
+	if !ok || timing == nil || !atomic.CompareAndSwapInt32(&timing.traced, 0, 1) {
+		return ctx, func() {}
+	}
+	timing.begin("ttfb")
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { timing.begin("dns") },
+		DNSDone:  func(httptrace.DNSDoneInfo) { timing.end("dns") },
+		// Dialing several addresses calls these once per address; the first dial is timed
+		ConnectStart: func(string, string) { timing.begin("connect") },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				timing.end("connect")
+			}
+		},
+		TLSHandshakeStart: func() { timing.begin("tls") },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				timing.end("tls")
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			timing.add(attribute.Bool("http.conn_reused", info.Reused))
+		},
+		GotFirstResponseByte: func() { timing.end("ttfb") },
+	}
+	return httptrace.WithClientTrace(ctx, trace), timing.record
+}
+
+func (r *requestTiming) begin(phase string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.starts[phase]; !ok {
+		r.starts[phase] = r.clock.Now()
+	}
+}
+
+func (r *requestTiming) end(phase string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	start, ok := r.starts[phase]
+	if !ok || start.IsZero() {
+		return
+	}
+	r.attrs = append(r.attrs, attribute.Int64("http."+phase+"_ms", r.clock.Now().Sub(start).Milliseconds()))
+	// A zero start marks the phase as recorded
+	r.starts[phase] = time.Time{}
+}
+
+func (r *requestTiming) add(kv attribute.KeyValue) {
+	r.mu.Lock()
+	r.attrs = append(r.attrs, kv)
+	r.mu.Unlock()
+}
+
+func (r *requestTiming) record() {
+	r.mu.Lock()
+	attrs := r.attrs
+	r.attrs = nil
+	r.mu.Unlock()
+	if len(attrs) > 0 {
+		r.span.SetAttributes(attrs...)
+	}
+}
@@ -0,0 +1,52 @@
+package agentbill
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestTimingSkipsGuardrailRequests(t *testing.T) {
+	clock := NewManualClock(time.Unix(1700000000, 0))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/moderations":
+			clock.Advance(500 * time.Millisecond)
+			io.WriteString(w, `{"results":[{"flagged":false}]}`)
+		case "/chat/completions":
+			clock.Advance(7 * time.Millisecond)
+			io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("TEST_PROVIDER_API_KEY", "key")
+	provider := &chatProvider{name: "test", service: "Test", baseURL: server.URL, keyEnv: "TEST_PROVIDER_API_KEY"}
+	client := Init(Config{APIKey: "test", BaseURL: server.URL, Clock: clock})
+	guardrail := func(ctx context.Context, model string, messages []map[string]string) (*GuardrailEvent, error) {
+		_, err := client.providerPost(ctx, provider, "/moderations", map[string]interface{}{"input": "hello"})
+		return nil, err
+	}
+
+	messages := []map[string]string{{"role": "user", "content": "hello"}}
+	if _, err := client.chatCompletion(context.Background(), provider, guardrail, "test-model", messages); err != nil {
+		t.Fatal(err)
+	}
+
+	client.tracer.mu.Lock()
+	spans := append([]*Span(nil), client.tracer.spans...)
+	client.tracer.mu.Unlock()
+	if len(spans) != 1 {
+		t.Fatalf("got %d buffered spans, want 1", len(spans))
+	}
+	if ttfb := spans[0].AttributeMap()["http.ttfb_ms"]; ttfb != int64(7) {
+		t.Errorf("http.ttfb_ms = %v, want 7 from the chat completion request", ttfb)
+	}
+}
@@ -0,0 +1,94 @@
+package agentbill
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator creates trace and span IDs. Implementations must be safe for concurrent use.
+type IDGenerator interface {
+	NewTraceID() string
+	NewSpanID() string
+}
+
+// Clock supplies timestamps for spans, signals and usage records, and measures
+// latencies as differences between readings. Times from time.Now carry a
+// monotonic reading, so the default clock's latencies ignore wall-clock steps.
+type Clock interface {
+	Now() time.Time
+}
+
+type randomIDGenerator struct{}
+
+func (randomIDGenerator) NewTraceID() string { return uuid.New().String() }
+func (randomIDGenerator) NewSpanID() string  { return uuid.New().String()[:16] }
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SequentialIDGenerator hands out predictable IDs in call order, for golden-file tests
+type SequentialIDGenerator struct {
+	traces uint64
+	spans  uint64
+}
+
+// NewTraceID returns the next trace ID in the same shape as generated ones
+func (g *SequentialIDGenerator) NewTraceID() string {
+	return fmt.Sprintf("00000000-0000-0000-0000-%012x", atomic.AddUint64(&g.traces, 1))
+}
+
+// NewSpanID returns the next span ID
+func (g *SequentialIDGenerator) NewSpanID() string {
+	return fmt.Sprintf("%016x", atomic.AddUint64(&g.spans, 1))
+}
+
+// ManualClock is a Clock that only moves when told to
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock creates a clock stopped at start
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now returns the clock's current time
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// Set moves the clock to t
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
+
+func (c Config) idGenerator() IDGenerator {
+	if c.IDGenerator != nil {
+		return c.IDGenerator
+	}
+	return randomIDGenerator{}
+}
+
+func (c Config) clock() Clock {
+	if c.Clock != nil {
+		return c.Clock
+	}
+	return systemClock{}
+}
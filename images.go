@@ -0,0 +1,106 @@
+package agentbill
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// ImageRequest is an image generation request, shared by the image wrappers so
+// their spans compare directly
+type ImageRequest struct {
+	Model  string
+	Prompt string
+	// Width and Height default to 1024
+	Width  int
+	Height int
+	// Count is the number of images to generate, default 1
+	Count int
+	// Quality is OpenAI's "standard" (default) or "hd"
+	Quality string
+	// Steps is the number of diffusion steps for Stability models, default 30
+	Steps int
+}
+
+func (r *ImageRequest) setDefaults() {
+	if r.Width == 0 {
+		r.Width = 1024
+	}
+	if r.Height == 0 {
+		r.Height = 1024
+	}
+	if r.Count == 0 {
+		r.Count = 1
+	}
+	if r.Quality == "" {
+		r.Quality = "standard"
+	}
+}
+
+func (r ImageRequest) size() string {
+	return fmt.Sprintf("%dx%d", r.Width, r.Height)
+}
+
+// generateImages tracks an image generation call. Every provider's span is named
+// "<provider>.image.generation" with the same image.* attributes, and is metered
+// in images priced by model, quality and size (e.g. "dall-e-3-hd-1024x1792").
+// count reads the number of images returned from the response.
+func (c *Client) generateImages(ctx context.Context, p *chatProvider, r ImageRequest, path string, requestBody interface{}, count func(map[string]interface{}) int) (map[string]interface{}, error) {
+	clock := c.config.stopwatch()
+	startTime := clock.Now()
+
+	span := c.tracer.startInternal(ctx, p.name+".image.generation",
+		attribute.String("model", r.Model),
+		attribute.String("provider", p.name),
+		attribute.Int("image.width", r.Width),
+		attribute.Int("image.height", r.Height),
+		attribute.String("image.resolution", r.size()),
+		attribute.String("image.quality", r.Quality),
+		attribute.Int("image.requested", r.Count),
+	)
+	ctx = c.withRequestTiming(ctx, span)
+	if r.Steps > 0 {
+		span.SetAttributes(attribute.Int("image.steps", r.Steps))
+	}
+
+	defer func() {
+		latency := clock.Now().Sub(startTime).Milliseconds()
+		span.SetAttributes(attribute.Int64("latency_ms", latency))
+		span.End()
+	}()
+
+	response, err := c.providerPost(ctx, p, path, requestBody)
+	if err != nil {
+		recordCallError(span, err)
+		return nil, err
+	}
+
+	images := count(response)
+	if images == 0 {
+		// Responses without an image list are billed as requested
+		images = r.Count
+	}
+	span.SetAttributes(attribute.Int("image.count", images))
+	priceKey := r.Model + "-" + r.Quality + "-" + r.size()
+	c.recordMeteredUsage(span, r.Model, p.name, UnitImages, float64(images), c.EstimateUnitCost(priceKey, float64(images)))
+
+	span.SetStatus(0, "")
+	return response, nil
+}
+
+// GenerateImage tracks an OpenAI image generation (DALL-E) call
+func (w *OpenAIWrapper) GenerateImage(ctx context.Context, r ImageRequest) (map[string]interface{}, error) {
+	r.setDefaults()
+	body := map[string]interface{}{
+		"model":   r.Model,
+		"prompt":  r.Prompt,
+		"n":       r.Count,
+		"size":    r.size(),
+		"quality": r.Quality,
+	}
+	return w.client.generateImages(ctx, openAIProvider, r, "/images/generations", body, func(response map[string]interface{}) int {
+		data, _ := response["data"].([]interface{})
+		return len(data)
+	})
+}
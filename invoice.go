@@ -0,0 +1,89 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// InvoiceLineItem is a line on a customer's draft invoice
+type InvoiceLineItem struct {
+	ID string `json:"id,omitempty"`
+	// CustomerID defaults to the client's customer
+	CustomerID  string `json:"customer_id"`
+	Description string `json:"description"`
+	// Quantity is in Unit, e.g. 1250.5 with Unit "1k tokens"; Unit is optional
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit,omitempty"`
+	UnitPriceUSD float64 `json:"unit_price_usd"`
+	// PeriodStart and PeriodEnd are the service period the line bills for; optional
+	PeriodStart time.Time `json:"period_start,omitempty"`
+	PeriodEnd   time.Time `json:"period_end,omitempty"`
+	// IdempotencyKey makes retried creates add the line once
+	IdempotencyKey string            `json:"idempotency_key,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	// InvoiceID is the draft invoice the line was added to, set by AgentBill
+	InvoiceID string `json:"invoice_id,omitempty"`
+}
+
+// AmountUSD is the line's total, its quantity times its unit price
+func (l InvoiceLineItem) AmountUSD() float64 {
+	return l.Quantity * l.UnitPriceUSD
+}
+
+// CreateInvoiceLineItems adds lines to their customers' draft invoices for the
+// current billing period, opening a draft when a customer has none. It returns
+// the lines with their IDs and invoice IDs.
+func (c *Client) CreateInvoiceLineItems(ctx context.Context, items ...InvoiceLineItem) ([]InvoiceLineItem, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	lines := make([]InvoiceLineItem, len(items))
+	for i, item := range items {
+		if item.Description == "" {
+			return nil, fmt.Errorf("invoice line %d: description is required", i)
+		}
+		if item.Quantity < 0 || item.UnitPriceUSD < 0 {
+			return nil, fmt.Errorf("invoice line %d: quantity and unit price must not be negative", i)
+		}
+		if item.CustomerID = c.customerID(item.CustomerID); item.CustomerID == "" {
+			return nil, fmt.Errorf("invoice line %d: customer ID is required", i)
+		}
+		lines[i] = item
+	}
+
+	var response struct {
+		Lines []InvoiceLineItem `json:"lines"`
+	}
+	body := map[string]interface{}{"lines": lines}
+	if err := c.callJSON(ctx, "POST", "invoice-line-items", nil, body, &response); err != nil {
+		return nil, err
+	}
+	return response.Lines, nil
+}
+
+// ListInvoiceLineItems returns the lines on a customer's draft invoice.
+// customerID defaults to the client's customer.
+func (c *Client) ListInvoiceLineItems(ctx context.Context, customerID string) ([]InvoiceLineItem, error) {
+	params := url.Values{}
+	c.setCustomer(params, customerID)
+
+	var response struct {
+		Lines []InvoiceLineItem `json:"lines"`
+	}
+	if err := c.getJSON(ctx, "invoice-line-items", params, &response); err != nil {
+		return nil, err
+	}
+	return response.Lines, nil
+}
+
+// DeleteInvoiceLineItem removes a line from a draft invoice by ID. Lines on
+// finalized invoices cannot be removed.
+func (c *Client) DeleteInvoiceLineItem(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("invoice line ID is required")
+	}
+	return c.callJSON(ctx, "DELETE", "invoice-line-items", url.Values{"id": {id}}, nil, nil)
+}
@@ -0,0 +1,85 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+)
+
+// KafkaRecord is one record to produce to a Kafka topic
+type KafkaRecord struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// KafkaProducer writes records to Kafka and returns once the brokers have
+// acknowledged all of them. Implement it with the Kafka client you already run
+// (kafka-go, sarama, franz-go and so on), which owns the broker list, TLS, SASL
+// and acks settings, so the SDK does not pull in a Kafka dependency.
+type KafkaProducer interface {
+	Produce(ctx context.Context, records []KafkaRecord) error
+}
+
+// KafkaOptions configures a Kafka publisher
+type KafkaOptions struct {
+	Producer KafkaProducer
+	// Topic receives every record unless a per-type topic is set
+	Topic       string
+	SpanTopic   string
+	SignalTopic string
+	UsageTopic  string
+}
+
+type kafkaPublisher struct {
+	opts KafkaOptions
+}
+
+// NewKafkaPublisher returns a Publisher that produces each span, signal and usage
+// record to Kafka keyed by customer ID, so a customer's records land on one
+// partition in order. Records carry "type" and "content-type" headers.
+func NewKafkaPublisher(opts KafkaOptions) (Publisher, error) {
+	if opts.Producer == nil {
+		return nil, errors.New("kafka: Producer is required")
+	}
+	for _, topic := range []string{opts.SpanTopic, opts.SignalTopic, opts.UsageTopic} {
+		if topic == "" && opts.Topic == "" {
+			return nil, errors.New("kafka: Topic is required unless every per-type topic is set")
+		}
+	}
+	return kafkaPublisher{opts: opts}, nil
+}
+
+func (p kafkaPublisher) Publish(ctx context.Context, messages []Message) error {
+	records := make([]KafkaRecord, len(messages))
+	for i, message := range messages {
+		records[i] = KafkaRecord{
+			Topic: p.topic(message.Type),
+			Value: message.Value,
+			Headers: map[string]string{
+				"type":         message.Type,
+				"content-type": "application/json",
+			},
+		}
+		if message.Key != "" {
+			records[i].Key = []byte(message.Key)
+		}
+	}
+	return p.opts.Producer.Produce(ctx, records)
+}
+
+func (p kafkaPublisher) topic(kind string) string {
+	var topic string
+	switch kind {
+	case "span":
+		topic = p.opts.SpanTopic
+	case "signal":
+		topic = p.opts.SignalTopic
+	case "usage":
+		topic = p.opts.UsageTopic
+	}
+	if topic == "" {
+		return p.opts.Topic
+	}
+	return topic
+}
@@ -0,0 +1,123 @@
+package agentbill
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the SDK's own telemetry pipeline
+type Stats struct {
+	BufferedSpans       int
+	BufferedBytes       int64
+	DroppedSpans        uint64
+	DroppedSignals      uint64
+	ExportedSpans       uint64
+	ExportErrors        uint64
+	ExportRequests      uint64
+	ExportLatencyTotal  time.Duration
+	LastExportLatency   time.Duration
+	PendingUsageRecords int
+	// RejectedSpans counts spans the collector refused in exports it otherwise
+	// accepted (OTLP partial success); LastRejection is its most recent reason
+	RejectedSpans uint64
+	LastRejection string
+}
+
+type exportMetrics struct {
+	exportedSpans uint64
+	errors        uint64
+	requests      uint64
+	latencyTotal  int64
+	latencyLast   int64
+	rejectedSpans uint64
+	lastRejection atomic.Value // string
+}
+
+func (m *exportMetrics) observeExport(latency time.Duration) {
+	atomic.AddUint64(&m.requests, 1)
+	atomic.AddInt64(&m.latencyTotal, int64(latency))
+	atomic.StoreInt64(&m.latencyLast, int64(latency))
+}
+
+func (m *exportMetrics) exportError() {
+	atomic.AddUint64(&m.errors, 1)
+}
+
+func (m *exportMetrics) exported(n int) {
+	atomic.AddUint64(&m.exportedSpans, uint64(n))
+}
+
+func (m *exportMetrics) rejected(n int64, reason string) {
+	atomic.AddUint64(&m.rejectedSpans, uint64(n))
+	m.lastRejection.Store(reason)
+}
+
+// Stats returns a snapshot of the tracer's buffer and export counters
+func (t *Tracer) Stats() Stats {
+	t.mu.Lock()
+	buffered, bytes := len(t.spans), t.bufferedBytes
+	t.mu.Unlock()
+
+	lastRejection, _ := t.metrics.lastRejection.Load().(string)
+	return Stats{
+		BufferedSpans:      buffered,
+		BufferedBytes:      bytes,
+		DroppedSpans:       atomic.LoadUint64(&t.dropped),
+		DroppedSignals:     atomic.LoadUint64(&t.droppedSignals),
+		ExportedSpans:      atomic.LoadUint64(&t.metrics.exportedSpans),
+		ExportErrors:       atomic.LoadUint64(&t.metrics.errors),
+		ExportRequests:     atomic.LoadUint64(&t.metrics.requests),
+		ExportLatencyTotal: time.Duration(atomic.LoadInt64(&t.metrics.latencyTotal)),
+		LastExportLatency:  time.Duration(atomic.LoadInt64(&t.metrics.latencyLast)),
+		RejectedSpans:      atomic.LoadUint64(&t.metrics.rejectedSpans),
+		LastRejection:      lastRejection,
+	}
+}
+
+// Stats returns a snapshot of the SDK's buffered telemetry and export counters
+func (c *Client) Stats() Stats {
+	stats := c.tracer.Stats()
+	c.usage.mu.Lock()
+	stats.PendingUsageRecords = len(c.usage.records)
+	c.usage.mu.Unlock()
+	return stats
+}
+
+// WritePrometheus writes the client's self-metrics in the Prometheus text exposition format
+func (c *Client) WritePrometheus(w io.Writer) error {
+	stats := c.Stats()
+	metrics := []struct {
+		name, kind, help string
+		value            float64
+	}{
+		{"agentbill_buffered_spans", "gauge", "Spans waiting to be exported.", float64(stats.BufferedSpans)},
+		{"agentbill_buffered_bytes", "gauge", "Estimated memory held by buffered spans.", float64(stats.BufferedBytes)},
+		{"agentbill_pending_usage_records", "gauge", "Aggregated usage records waiting to be exported.", float64(stats.PendingUsageRecords)},
+		{"agentbill_dropped_spans_total", "counter", "Spans discarded because the buffer was full or the collector refused them.", float64(stats.DroppedSpans)},
+		{"agentbill_dropped_signals_total", "counter", "Signals discarded because the bulk export buffer was full.", float64(stats.DroppedSignals)},
+		{"agentbill_exported_spans_total", "counter", "Spans delivered to the collector.", float64(stats.ExportedSpans)},
+		{"agentbill_rejected_spans_total", "counter", "Spans refused by the collector in partially successful exports.", float64(stats.RejectedSpans)},
+		{"agentbill_export_errors_total", "counter", "Failed export requests.", float64(stats.ExportErrors)},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", m.name, m.help, m.name, m.kind, m.name, m.value); err != nil {
+			return err
+		}
+	}
+	// Export latency is one summary family whose _sum and _count samples give the average
+	const latency = "agentbill_export_latency_seconds"
+	_, err := fmt.Fprintf(w, "# HELP %s Time spent in export requests.\n# TYPE %s summary\n%s_sum %g\n%s_count %d\n",
+		latency, latency, latency, stats.ExportLatencyTotal.Seconds(), latency, stats.ExportRequests)
+	return err
+}
+
+// MetricsHandler serves the client's self-metrics for Prometheus scraping
+func (c *Client) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		c.WritePrometheus(w)
+	})
+}
@@ -0,0 +1,46 @@
+package agentbill
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	client := Init(Config{APIKey: "mock", Disabled: true})
+	client.tracer.metrics.observeExport(1500 * time.Millisecond)
+	client.tracer.metrics.observeExport(500 * time.Millisecond)
+
+	var out strings.Builder
+	if err := client.WritePrometheus(&out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+
+	want := "# HELP agentbill_export_latency_seconds Time spent in export requests.\n" +
+		"# TYPE agentbill_export_latency_seconds summary\n" +
+		"agentbill_export_latency_seconds_sum 2\n" +
+		"agentbill_export_latency_seconds_count 2\n"
+	if !strings.Contains(text, want) {
+		t.Errorf("export latency is not one summary family; got:\n%s", text)
+	}
+
+	families := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if strings.HasPrefix(line, "# TYPE ") {
+			name := strings.Fields(line)[2]
+			if families[name] {
+				t.Errorf("family %s declared twice", name)
+			}
+			families[name] = true
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name := strings.Fields(line)[0]
+		if !families[name] && !families[strings.TrimSuffix(strings.TrimSuffix(name, "_sum"), "_count")] {
+			t.Errorf("sample %s has no TYPE line", name)
+		}
+	}
+}
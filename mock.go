@@ -0,0 +1,345 @@
+package agentbill
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Records beyond this many per kind evict the oldest, bounding memory for long-lived disabled clients
+const maxMockRecords = 10000
+
+// SpanRecord is a span as received by a collector
+type SpanRecord struct {
+	Name          string
+	TraceID       string
+	SpanID        string
+	ParentSpanID  string
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]interface{}
+	Events        []string
+	StatusCode    int
+	StatusMessage string
+}
+
+// ProviderCall is a provider request intercepted by a mock client
+type ProviderCall struct {
+	Method string
+	URL    string
+	Body   map[string]interface{}
+}
+
+// ProviderResponder simulates a provider response; body is encoded as JSON
+type ProviderResponder func(call ProviderCall) (status int, body interface{})
+
+// Mock records everything a disabled client would have sent. Spans are recorded
+// when the client flushes, after passing through the real encoding path.
+type Mock struct {
+	apiHost string
+
+	mu        sync.Mutex
+	spans     []SpanRecord
+	signals   []Signal
+	usage     []UsageRecord
+	calls     []ProviderCall
+	responder ProviderResponder
+}
+
+// InitMock creates a client that performs no network I/O, recording exports and
+// answering provider calls with simulated responses
+func InitMock() *Client {
+	return Init(Config{APIKey: "mock", CustomerID: "mock-customer", Disabled: true})
+}
+
+// Mock returns the recorder of a disabled client, or nil for a live client
+func (c *Client) Mock() *Mock {
+	return c.mock
+}
+
+func newMock(baseURL string) *Mock {
+	host := ""
+	if u, err := url.Parse(baseURL); err == nil {
+		host = u.Host
+	}
+	return &Mock{apiHost: host}
+}
+
+// Spans returns the spans flushed so far
+func (m *Mock) Spans() []SpanRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]SpanRecord(nil), m.spans...)
+}
+
+// Signals returns the signals tracked so far
+func (m *Mock) Signals() []Signal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Signal(nil), m.signals...)
+}
+
+// UsageRecords returns the aggregated usage records flushed so far
+func (m *Mock) UsageRecords() []UsageRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]UsageRecord(nil), m.usage...)
+}
+
+// ProviderCalls returns the provider requests intercepted so far
+func (m *Mock) ProviderCalls() []ProviderCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ProviderCall(nil), m.calls...)
+}
+
+// RespondWith sets how provider calls are answered; by default a fixed chat completion is returned
+func (m *Mock) RespondWith(responder ProviderResponder) {
+	m.mu.Lock()
+	m.responder = responder
+	m.mu.Unlock()
+}
+
+// Reset clears all recorded calls
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	m.spans, m.signals, m.usage, m.calls = nil, nil, nil, nil
+	m.mu.Unlock()
+}
+
+// RoundTrip implements http.RoundTripper without touching the network
+func (m *Mock) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL.Host == m.apiHost && strings.HasPrefix(req.URL.Path, "/functions/v1/") {
+		return m.recordAPI(req, body)
+	}
+	return m.respondProvider(req, body)
+}
+
+func (m *Mock) recordAPI(req *http.Request, body []byte) (*http.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch strings.TrimPrefix(req.URL.Path, "/functions/v1/") {
+	case "otel-collector":
+		spans, err := DecodeOTLP(body)
+		if err != nil {
+			return mockResponse(req, http.StatusBadRequest, map[string]string{"error": err.Error()}), nil
+		}
+		m.spans = appendBounded(m.spans, spans...)
+	case "record-signals":
+		var signal Signal
+		if err := json.Unmarshal(body, &signal); err != nil {
+			return mockResponse(req, http.StatusBadRequest, map[string]string{"error": err.Error()}), nil
+		}
+		m.signals = appendBounded(m.signals, signal)
+	case "record-usage":
+		var payload struct {
+			Records []UsageRecord `json:"records"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return mockResponse(req, http.StatusBadRequest, map[string]string{"error": err.Error()}), nil
+		}
+		m.usage = appendBounded(m.usage, payload.Records...)
+	case "bulk-ingest":
+		spans, signals, usage, err := DecodeBulk(body)
+		if err != nil {
+			return mockResponse(req, http.StatusBadRequest, map[string]string{"error": err.Error()}), nil
+		}
+		m.spans = appendBounded(m.spans, spans...)
+		m.signals = appendBounded(m.signals, signals...)
+		m.usage = appendBounded(m.usage, usage...)
+	}
+	return mockResponse(req, http.StatusOK, map[string]interface{}{}), nil
+}
+
+func (m *Mock) respondProvider(req *http.Request, body []byte) (*http.Response, error) {
+	call := ProviderCall{Method: req.Method, URL: req.URL.String()}
+	if len(body) > 0 {
+		json.Unmarshal(body, &call.Body)
+	}
+
+	m.mu.Lock()
+	m.calls = appendBounded(m.calls, call)
+	responder := m.responder
+	m.mu.Unlock()
+
+	if responder == nil {
+		responder = defaultMockResponder
+	}
+	status, response := responder(call)
+	return mockResponse(req, status, response), nil
+}
+
+func defaultMockResponder(call ProviderCall) (int, interface{}) {
+	model, _ := call.Body["model"].(string)
+	return http.StatusOK, map[string]interface{}{
+		"id":     "chatcmpl-mock",
+		"object": "chat.completion",
+		"model":  model,
+		"choices": []map[string]interface{}{
+			{
+				"index":         0,
+				"message":       map[string]interface{}{"role": "assistant", "content": "This is a mock response."},
+				"finish_reason": "stop",
+			},
+		},
+		"usage": map[string]interface{}{
+			"prompt_tokens":     10,
+			"completion_tokens": 5,
+			"total_tokens":      15,
+		},
+	}
+}
+
+func mockResponse(req *http.Request, status int, body interface{}) *http.Response {
+	data, err := json.Marshal(body)
+	if err != nil {
+		data = []byte(fmt.Sprintf(`{"error":%q}`, err.Error()))
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}
+}
+
+func appendBounded[T any](records []T, items ...T) []T {
+	records = append(records, items...)
+	if overflow := len(records) - maxMockRecords; overflow > 0 {
+		records = append(records[:0:0], records[overflow:]...)
+	}
+	return records
+}
+
+// DecodeOTLP parses an OTLP/JSON trace export into span records
+func DecodeOTLP(data []byte) ([]SpanRecord, error) {
+	var payload struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []otlpJSONSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	var records []SpanRecord
+	for _, rs := range payload.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				record := SpanRecord{
+					Name:          span.Name,
+					TraceID:       span.TraceID,
+					SpanID:        span.SpanID,
+					ParentSpanID:  span.ParentSpanID,
+					StartTime:     unixNano(span.StartTime),
+					EndTime:       unixNano(span.EndTime),
+					Attributes:    make(map[string]interface{}, len(span.Attributes)),
+					StatusCode:    span.Status.Code,
+					StatusMessage: span.Status.Message,
+				}
+				for _, kv := range span.Attributes {
+					record.Attributes[kv.Key] = kv.Value.value()
+				}
+				for _, event := range span.Events {
+					record.Events = append(record.Events, event.Name)
+				}
+				records = append(records, record)
+			}
+		}
+	}
+	return records, nil
+}
+
+func unixNano(s string) time.Time {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// otlpJSONSpan is one span of an OTLP/JSON trace export
+type otlpJSONSpan struct {
+	TraceID      string         `json:"traceId"`
+	SpanID       string         `json:"spanId"`
+	ParentSpanID string         `json:"parentSpanId"`
+	Name         string         `json:"name"`
+	Kind         int            `json:"kind"`
+	StartTime    string         `json:"startTimeUnixNano"`
+	EndTime      string         `json:"endTimeUnixNano"`
+	Attributes   []otlpKeyValue `json:"attributes"`
+	Events       []struct {
+		Time       string         `json:"timeUnixNano"`
+		Name       string         `json:"name"`
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"events"`
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string         `json:"stringValue"`
+	IntValue    *json.Number    `json:"intValue"`
+	DoubleValue json.RawMessage `json:"doubleValue"`
+	BoolValue   *bool           `json:"boolValue"`
+}
+
+func (v otlpValue) value() interface{} {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.IntValue != nil:
+		n, _ := v.IntValue.Int64()
+		return n
+	case v.DoubleValue != nil:
+		var special string
+		if json.Unmarshal(v.DoubleValue, &special) == nil {
+			switch special {
+			case "Infinity":
+				return math.Inf(1)
+			case "-Infinity":
+				return math.Inf(-1)
+			}
+			if f, err := strconv.ParseFloat(special, 64); err == nil {
+				return f
+			}
+			return math.NaN()
+		}
+		f, _ := strconv.ParseFloat(string(v.DoubleValue), 64)
+		return f
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package agentbill
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// The SDK only needs MessagePack as a denser spelling of its JSON payloads, so
+// payloads are converted from their JSON form rather than encoded from structs.
+// Object keys are written in sorted order.
+
+// jsonToMsgPack re-encodes a JSON document as MessagePack
+func jsonToMsgPack(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return appendMsgPack(nil, value)
+}
+
+func appendMsgPack(b []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return appendMsgPackInt(b, n), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, 0xcb)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(f)), nil
+	case string:
+		return appendMsgPackString(b, v), nil
+	case []interface{}:
+		b = appendMsgPackHeader(b, len(v), 0x90, 0xdc, 0xdd)
+		for _, item := range v {
+			var err error
+			if b, err = appendMsgPack(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b = appendMsgPackHeader(b, len(v), 0x80, 0xde, 0xdf)
+		for _, key := range keys {
+			b = appendMsgPackString(b, key)
+			var err error
+			if b, err = appendMsgPack(b, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type %T", value)
+}
+
+func appendMsgPackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n <= 0x7f:
+		return append(b, byte(n))
+	case n < 0 && n >= -32:
+		return append(b, byte(n))
+	case n >= 0 && n <= math.MaxUint8:
+		return append(b, 0xcc, byte(n))
+	case n >= 0 && n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(n))
+	case n >= 0 && n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(n))
+	case n >= 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(n))
+	case n >= math.MinInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+}
+
+func appendMsgPackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendMsgPackHeader writes an array or map length using the fix, 16-bit or 32-bit form
+func appendMsgPackHeader(b []byte, n int, fix, code16, code32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, code32), uint32(n))
+}
+
+var errMsgPackTruncated = errors.New("msgpack: unexpected end of data")
+
+// MessagePackToJSON converts a MessagePack export back to JSON, so collectors can
+// decode it with DecodeOTLP, DecodeBulk or json.Unmarshal
+func MessagePackToJSON(data []byte) ([]byte, error) {
+	value, rest, err := readMsgPack(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("msgpack: trailing data")
+	}
+	return json.Marshal(value)
+}
+
+func readMsgPack(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errMsgPackTruncated
+	}
+	code, b := b[0], b[1:]
+	switch {
+	case code <= 0x7f:
+		return int64(code), b, nil
+	case code >= 0xe0:
+		return int64(int8(code)), b, nil
+	case code&0xe0 == 0xa0:
+		return readMsgPackString(b, int(code&0x1f))
+	case code&0xf0 == 0x90:
+		return readMsgPackArray(b, int(code&0x0f))
+	case code&0xf0 == 0x80:
+		return readMsgPackMap(b, int(code&0x0f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2:
+		return false, b, nil
+	case 0xc3:
+		return true, b, nil
+	case 0xca:
+		raw, rest, err := take(b, 4)
+		if err != nil {
+			return nil, nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), rest, nil
+	case 0xcb:
+		raw, rest, err := take(b, 8)
+		if err != nil {
+			return nil, nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), rest, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		raw, rest, err := take(b, 1<<(code-0xcc))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readUint(raw), rest, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		raw, rest, err := take(b, 1<<(code-0xd0))
+		if err != nil {
+			return nil, nil, err
+		}
+		return signExtend(readUint(raw), len(raw)), rest, nil
+	case 0xd9, 0xda, 0xdb:
+		raw, rest, err := take(b, 1<<(code-0xd9))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgPackString(rest, int(readUint(raw)))
+	case 0xdc, 0xdd:
+		raw, rest, err := take(b, 2<<(code-0xdc))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgPackArray(rest, int(readUint(raw)))
+	case 0xde, 0xdf:
+		raw, rest, err := take(b, 2<<(code-0xde))
+		if err != nil {
+			return nil, nil, err
+		}
+		return readMsgPackMap(rest, int(readUint(raw)))
+	}
+	return nil, nil, fmt.Errorf("msgpack: unsupported type byte 0x%02x", code)
+}
+
+func readMsgPackString(b []byte, n int) (interface{}, []byte, error) {
+	raw, rest, err := take(b, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return string(raw), rest, nil
+}
+
+func readMsgPackArray(b []byte, n int) (interface{}, []byte, error) {
+	if n > len(b) {
+		return nil, nil, errMsgPackTruncated
+	}
+	items := make([]interface{}, n)
+	for i := range items {
+		var err error
+		if items[i], b, err = readMsgPack(b); err != nil {
+			return nil, nil, err
+		}
+	}
+	return items, b, nil
+}
+
+func readMsgPackMap(b []byte, n int) (interface{}, []byte, error) {
+	if 2*n > len(b) {
+		return nil, nil, errMsgPackTruncated
+	}
+	fields := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, rest, err := readMsgPack(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("msgpack: map key of type %T", key)
+		}
+		if fields[name], b, err = readMsgPack(rest); err != nil {
+			return nil, nil, err
+		}
+	}
+	return fields, b, nil
+}
+
+func take(b []byte, n int) ([]byte, []byte, error) {
+	if n < 0 || n > len(b) {
+		return nil, nil, errMsgPackTruncated
+	}
+	return b[:n], b[n:], nil
+}
+
+func readUint(raw []byte) uint64 {
+	var n uint64
+	for _, c := range raw {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+func signExtend(n uint64, size int) int64 {
+	shift := 64 - 8*size
+	return int64(n<<shift) >> shift
+}
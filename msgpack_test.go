@@ -0,0 +1,114 @@
+package agentbill
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestJSONToMsgPackScalars(t *testing.T) {
+	cases := []struct {
+		json string
+		want []byte
+	}{
+		{`null`, []byte{0xc0}},
+		{`true`, []byte{0xc3}},
+		{`false`, []byte{0xc2}},
+		{`0`, []byte{0x00}},
+		{`127`, []byte{0x7f}},
+		{`128`, []byte{0xcc, 0x80}},
+		{`255`, []byte{0xcc, 0xff}},
+		{`256`, []byte{0xcd, 0x01, 0x00}},
+		{`65535`, []byte{0xcd, 0xff, 0xff}},
+		{`65536`, []byte{0xce, 0x00, 0x01, 0x00, 0x00}},
+		{`4294967295`, []byte{0xce, 0xff, 0xff, 0xff, 0xff}},
+		{`4294967296`, []byte{0xcf, 0, 0, 0, 0x01, 0, 0, 0, 0}},
+		{`-1`, []byte{0xff}},
+		{`-32`, []byte{0xe0}},
+		{`-33`, []byte{0xd0, 0xdf}},
+		{`-128`, []byte{0xd0, 0x80}},
+		{`-129`, []byte{0xd1, 0xff, 0x7f}},
+		{`-32768`, []byte{0xd1, 0x80, 0x00}},
+		{`-32769`, []byte{0xd2, 0xff, 0xff, 0x7f, 0xff}},
+		{`-2147483648`, []byte{0xd2, 0x80, 0x00, 0x00, 0x00}},
+		{`-2147483649`, []byte{0xd3, 0xff, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff}},
+		{`1.5`, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{`1e300`, []byte{0xcb, 0x7e, 0x37, 0xe4, 0x3c, 0x88, 0x00, 0x75, 0x9c}},
+		{`""`, []byte{0xa0}},
+		{`"abc"`, []byte{0xa3, 'a', 'b', 'c'}},
+		{`[]`, []byte{0x90}},
+		{`{}`, []byte{0x80}},
+		{`{"b":1,"a":[true]}`, []byte{0x82, 0xa1, 'a', 0x91, 0xc3, 0xa1, 'b', 0x01}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.json, func(t *testing.T) {
+			got, err := jsonToMsgPack([]byte(tc.json))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tc.want) {
+				t.Errorf("got % x, want % x", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJSONToMsgPackLengths(t *testing.T) {
+	str := func(n int) string { return `"` + strings.Repeat("x", n) + `"` }
+	array := func(n int) string { return "[" + strings.TrimSuffix(strings.Repeat("0,", n), ",") + "]" }
+	object := func(n int) string {
+		fields := make([]string, n)
+		for i := range fields {
+			fields[i] = fmt.Sprintf(`"k%06d":0`, i)
+		}
+		return "{" + strings.Join(fields, ",") + "}"
+	}
+
+	cases := []struct {
+		name   string
+		json   string
+		header []byte
+	}{
+		{"fixstr 31", str(31), []byte{0xbf}},
+		{"str8 32", str(32), []byte{0xd9, 32}},
+		{"str8 255", str(255), []byte{0xd9, 0xff}},
+		{"str16 256", str(256), []byte{0xda, 0x01, 0x00}},
+		{"str16 65535", str(65535), []byte{0xda, 0xff, 0xff}},
+		{"str32 65536", str(65536), []byte{0xdb, 0x00, 0x01, 0x00, 0x00}},
+		{"fixarray 15", array(15), []byte{0x9f}},
+		{"array16 16", array(16), []byte{0xdc, 0x00, 0x10}},
+		{"array16 65535", array(65535), []byte{0xdc, 0xff, 0xff}},
+		{"array32 65536", array(65536), []byte{0xdd, 0x00, 0x01, 0x00, 0x00}},
+		{"fixmap 15", object(15), []byte{0x8f}},
+		{"map16 16", object(16), []byte{0xde, 0x00, 0x10}},
+		{"map32 65536", object(65536), []byte{0xdf, 0x00, 0x01, 0x00, 0x00}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := jsonToMsgPack([]byte(tc.json))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(got, tc.header) {
+				t.Fatalf("header = % x, want % x", got[:len(tc.header)], tc.header)
+			}
+
+			back, err := MessagePackToJSON(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want, roundTrip interface{}
+			if err := json.Unmarshal([]byte(tc.json), &want); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(back, &roundTrip); err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(roundTrip) != fmt.Sprint(want) {
+				t.Error("MessagePackToJSON did not round-trip the value")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+)
+
+// JetStreamPublisher publishes one message to a JetStream subject and returns once
+// the stream has acknowledged it. Implement it with nats.go's JetStreamContext, e.g.
+//
+//	msg := nats.NewMsg(subject)
+//	msg.Data = data
+//	for k, v := range headers { msg.Header.Set(k, v) }
+//	_, err := js.PublishMsg(msg, nats.Context(ctx))
+//
+// The connection, credentials and stream configuration stay with your NATS client.
+type JetStreamPublisher interface {
+	PublishMsg(ctx context.Context, subject string, data []byte, headers map[string]string) error
+}
+
+// NATSOptions configures a NATS JetStream publisher
+type NATSOptions struct {
+	JetStream JetStreamPublisher
+	// Subject prefixes the subjects records are published on: spans go to
+	// "<Subject>.span", signals to "<Subject>.signal" and usage records to
+	// "<Subject>.usage" (default "agentbill")
+	Subject string
+}
+
+type natsPublisher struct {
+	opts NATSOptions
+}
+
+// NewNATSPublisher returns a Publisher that writes each record to a JetStream
+// stream, waiting for the stream's acknowledgment so records are stored before a
+// flush reports success. Each message carries its Message.ID as Nats-Msg-Id,
+// so a batch republished after a partial failure is deduplicated.
+func NewNATSPublisher(opts NATSOptions) (Publisher, error) {
+	if opts.JetStream == nil {
+		return nil, errors.New("nats: JetStream is required")
+	}
+	if opts.Subject == "" {
+		opts.Subject = "agentbill"
+	}
+	return natsPublisher{opts: opts}, nil
+}
+
+func (p natsPublisher) Publish(ctx context.Context, messages []Message) error {
+	for _, message := range messages {
+		headers := map[string]string{
+			"Nats-Msg-Id":  message.ID,
+			"Content-Type": "application/json",
+		}
+		if message.Key != "" {
+			headers["Customer-Id"] = message.Key
+		}
+		if err := p.opts.JetStream.PublishMsg(ctx, p.opts.Subject+"."+message.Type, message.Value, headers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package agentbill
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// writeNDJSON writes spans, signals and usage records one per line. Every line is
+// an object whose "type" is "span", "signal" or "usage"; span lines carry the
+// OTLP/JSON span fields, signal and usage lines those of Signal and UsageRecord.
+func writeNDJSON(w io.Writer, spans []json.RawMessage, signals []Signal, usage []UsageRecord) error {
+	for _, span := range spans {
+		if err := writeNDJSONLine(w, "span", span); err != nil {
+			return err
+		}
+	}
+	for _, signal := range signals {
+		data, err := json.Marshal(signal)
+		if err != nil {
+			return err
+		}
+		if err := writeNDJSONLine(w, "signal", data); err != nil {
+			return err
+		}
+	}
+	for _, record := range usage {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if err := writeNDJSONLine(w, "usage", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNDJSONLine writes object with a leading type field, splicing it in
+// rather than re-encoding the object
+func writeNDJSONLine(w io.Writer, kind string, object []byte) error {
+	line := make([]byte, 0, len(object)+len(kind)+12)
+	line = append(line, `{"type":"`...)
+	line = append(line, kind...)
+	line = append(line, '"')
+	if rest := bytes.TrimSpace(object)[1:]; len(bytes.TrimSpace(rest)) > 1 {
+		line = append(line, ',')
+		line = append(line, rest...)
+	} else {
+		line = append(line, '}')
+	}
+	line = append(line, '\n')
+	_, err := w.Write(line)
+	return err
+}
+
+// DecodeNDJSON parses NDJSON exports into spans, signals and usage records.
+// Lines of unknown type are skipped.
+func DecodeNDJSON(data []byte) ([]SpanRecord, []Signal, []UsageRecord, error) {
+	var spans []SpanRecord
+	var signals []Signal
+	var usage []UsageRecord
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, defaultMaxExportBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var kind struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(line, &kind); err != nil {
+			return nil, nil, nil, err
+		}
+		switch kind.Type {
+		case "span":
+			records, err := DecodeOTLP(wrapOTLPSpan(line))
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			spans = append(spans, records...)
+		case "signal":
+			var signal Signal
+			if err := json.Unmarshal(line, &signal); err != nil {
+				return nil, nil, nil, err
+			}
+			signals = append(signals, signal)
+		case "usage":
+			var record UsageRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				return nil, nil, nil, err
+			}
+			usage = append(usage, record)
+		}
+	}
+	return spans, signals, usage, scanner.Err()
+}
+
+// wrapOTLPSpan places a single span in an OTLP envelope so DecodeOTLP can read it
+func wrapOTLPSpan(span []byte) []byte {
+	var buf bytes.Buffer
+	writeOTLPPayload(&buf, []json.RawMessage{span})
+	return buf.Bytes()
+}
+
+// writeLines writes one batch to the export writer without interleaving it with
+// batches written concurrently
+func (t *Tracer) writeLines(spans []json.RawMessage, signals []Signal, usage []UsageRecord) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := writeNDJSON(buf, spans, signals, usage); err != nil {
+		return err
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.config.ExportWriter.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("export writer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package agentbill
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+const nvidiaAPIURL = "https://integrate.api.nvidia.com/v1"
+
+// NIMDeployment describes an NVIDIA NIM or Triton endpoint serving the OpenAI API
+type NIMDeployment struct {
+	// Name labels the deployment on spans (nim.deployment), e.g. "llama3-70b-prod"
+	Name string
+	// BaseURL is the endpoint including /v1, e.g. http://nim-llama3:8000/v1;
+	// NVIDIA's hosted API when empty
+	BaseURL string
+	// APIKey defaults to NVIDIA_API_KEY; self-hosted endpoints usually need none
+	APIKey string
+	// Price, when set, prices this deployment's calls instead of the model's price,
+	// e.g. the amortized cost of the fleet serving it
+	Price *ModelPrice
+	// GPUType and GPUCount describe the GPUs a call occupies, e.g. "H100" and 2
+	GPUType  string
+	GPUCount int
+	// GPUHourUSD is the cost of one GPU hour, for the gpu.cost_usd attribute
+	GPUHourUSD float64
+}
+
+// NIMWrapper wraps calls to a NIM deployment
+type NIMWrapper struct {
+	client    *Client
+	guardrail GuardrailHook
+	provider  *chatProvider
+}
+
+// WrapNIM wraps a NIM deployment for tracking. Spans carry the deployment name
+// and GPU-time attributes: gpu.type, gpu.count and gpu.seconds, the call's
+// duration times its GPU count, plus gpu.cost_usd when GPUHourUSD is set.
+func (c *Client) WrapNIM(d NIMDeployment) *NIMWrapper {
+	baseURL := d.BaseURL
+	if baseURL == "" {
+		baseURL = nvidiaAPIURL
+	}
+	apiKey := d.APIKey
+	token := func(ctx context.Context) (string, error) {
+		if apiKey != "" {
+			return apiKey, nil
+		}
+		return os.Getenv("NVIDIA_API_KEY"), nil
+	}
+
+	p := &chatProvider{
+		name:    "nim",
+		service: "NVIDIA NIM",
+		baseURL: baseURL,
+		token:   token,
+		price:   d.Price,
+		finish: func(span *Span, elapsed time.Duration) {
+			if d.Name != "" {
+				span.SetAttributes(attribute.String("nim.deployment", d.Name))
+			}
+			if d.GPUType != "" {
+				span.SetAttributes(attribute.String("gpu.type", d.GPUType))
+			}
+			if d.GPUCount == 0 {
+				return
+			}
+			gpuSeconds := elapsed.Seconds() * float64(d.GPUCount)
+			span.SetAttributes(
+				attribute.Int("gpu.count", d.GPUCount),
+				attribute.Float64("gpu.seconds", gpuSeconds),
+			)
+			if d.GPUHourUSD > 0 {
+				span.SetAttributes(attribute.Float64("gpu.cost_usd", gpuSeconds*d.GPUHourUSD/3600))
+			}
+		},
+	}
+	return &NIMWrapper{client: c, provider: p}
+}
+
+// WithGuardrail returns a copy of the wrapper that runs hook before every chat call
+func (w *NIMWrapper) WithGuardrail(hook GuardrailHook) *NIMWrapper {
+	wrapped := *w
+	wrapped.guardrail = hook
+	return &wrapped
+}
+
+// ChatCompletion tracks a chat completion call to the deployment
+func (w *NIMWrapper) ChatCompletion(ctx context.Context, model string, messages []map[string]string) (map[string]interface{}, error) {
+	return w.client.chatCompletion(ctx, w.provider, w.guardrail, model, messages)
+}
+
+// ChatCompletionStream streams a chat completion from the deployment, see OpenAIWrapper.ChatCompletionStream
+func (w *NIMWrapper) ChatCompletionStream(ctx context.Context, model string, messages []map[string]string, onDelta StreamCallback) (map[string]interface{}, error) {
+	return w.client.chatCompletionStream(ctx, w.provider, w.guardrail, model, messages, onDelta)
+}
+
+// Embeddings tracks an embeddings call to the deployment
+func (w *NIMWrapper) Embeddings(ctx context.Context, model string, input []string) (map[string]interface{}, error) {
+	return w.client.embeddings(ctx, w.provider, model, input)
+}
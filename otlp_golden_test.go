@@ -0,0 +1,191 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata/otlp")
+
+// goldenTracer returns a tracer whose IDs and timestamps are reproducible
+func goldenTracer() (*Tracer, *ManualClock) {
+	clock := NewManualClock(time.Unix(1700000000, 0).UTC())
+	tracer := NewTracer(Config{
+		CustomerID:  "customer-123",
+		IDGenerator: &SequentialIDGenerator{},
+		Clock:       clock,
+	})
+	return tracer, clock
+}
+
+func TestOTLPGolden(t *testing.T) {
+	cases := []struct {
+		name  string
+		spans func(tracer *Tracer, clock *ManualClock) []*Span
+	}{
+		{"empty", func(*Tracer, *ManualClock) []*Span { return nil }},
+		{"attribute_types", func(tracer *Tracer, clock *ManualClock) []*Span {
+			span := tracer.Start(context.Background(), "attributes",
+				attribute.String("string", "value"),
+				attribute.Int("int", 42),
+				attribute.Int64("int64", -7),
+				attribute.Float64("float", 0.1),
+				attribute.Float64("float.whole", 3),
+				attribute.Bool("bool.true", true),
+				attribute.Bool("bool.false", false),
+				attribute.String("string.empty", ""),
+				attribute.Any("slice", []string{"a", "b"}),
+				attribute.Any("nil", nil),
+			)
+			clock.Advance(time.Millisecond)
+			span.End()
+			return []*Span{span}
+		}},
+		{"nil_attributes", func(tracer *Tracer, clock *ManualClock) []*Span {
+			span := tracer.StartSpan("nil.attributes", nil)
+			span.AddEvent("no.attributes")
+			clock.Advance(time.Millisecond)
+			span.End()
+
+			empty := tracer.StartSpan("empty.map", map[string]interface{}{})
+			empty.End()
+			return []*Span{span, empty}
+		}},
+		{"unicode", func(tracer *Tracer, clock *ManualClock) []*Span {
+			span := tracer.Start(context.Background(), "unicode.名前",
+				attribute.String("accents", "héllo wörld"),
+				attribute.String("cjk", "日本語のテキスト"),
+				attribute.String("emoji", "🚀👍🏽"),
+				attribute.String("control", "tab\tnewline\nnul\x00"),
+				attribute.String("html", "<script>&</script>"),
+				attribute.String("invalid.utf8", "bad\xffbyte"),
+				attribute.String("ключ", "значение"),
+			)
+			span.End()
+			return []*Span{span}
+		}},
+		{"huge_numbers", func(tracer *Tracer, clock *ManualClock) []*Span {
+			span := tracer.Start(context.Background(), "numbers",
+				attribute.Int64("int64.max", math.MaxInt64),
+				attribute.Int64("int64.min", math.MinInt64),
+				attribute.Float64("float.max", math.MaxFloat64),
+				attribute.Float64("float.smallest", math.SmallestNonzeroFloat64),
+				attribute.Float64("float.negative_zero", math.Copysign(0, -1)),
+				attribute.Float64("float.large_exact", 1<<53),
+			)
+			span.End()
+			return []*Span{span}
+		}},
+		{"hierarchy_events_status", func(tracer *Tracer, clock *ManualClock) []*Span {
+			ctx := WithSession(context.Background(), "session-1")
+			ctx = WithExperiment(ctx, "prompt-v2", "treatment")
+			parent := tracer.Start(ctx, "agent.run")
+			child := tracer.Start(ContextWithSpan(ctx, parent), "agent.step")
+			clock.Advance(10 * time.Millisecond)
+			child.AddEvent("retry", attribute.Int("attempt", 2), attribute.String("reason", "rate_limited"))
+			child.SetStatus(1, "upstream failed")
+			clock.Advance(5 * time.Millisecond)
+			child.End()
+			parent.End()
+			return []*Span{parent, child}
+		}},
+		{"open_span", func(tracer *Tracer, clock *ManualClock) []*Span {
+			span := tracer.Start(context.Background(), "still.running")
+			clock.Advance(time.Second)
+			return []*Span{span}
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tracer, clock := goldenTracer()
+			spans := tc.spans(tracer, clock)
+
+			encoded := make([]json.RawMessage, 0, len(spans))
+			for _, span := range spans {
+				data, err := tracer.encodeSpan(span)
+				if err != nil {
+					t.Fatal(err)
+				}
+				encoded = append(encoded, append(json.RawMessage(nil), data...))
+			}
+
+			var streamed bytes.Buffer
+			if err := writeOTLPPayload(&streamed, encoded); err != nil {
+				t.Fatal(err)
+			}
+			built, err := json.Marshal(buildOTLPPayload(encoded))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(streamed.Bytes(), built) {
+				t.Fatalf("streamed payload differs from buildOTLPPayload:\n%s\n%s", streamed.Bytes(), built)
+			}
+
+			var got bytes.Buffer
+			if err := json.Indent(&got, built, "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			got.WriteByte('\n')
+			checkGolden(t, filepath.Join("testdata", "otlp", tc.name+".json"), got.Bytes())
+		})
+	}
+}
+
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -run TestOTLPGolden -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("OTLP output changed; if intended, run go test -run TestOTLPGolden -update\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSpanAttributeMapCompatibility(t *testing.T) {
+	tracer, _ := goldenTracer()
+	span := tracer.Start(context.Background(), "compat", attribute.Int("top_k", 8))
+	if span.Attributes["top_k"] != int64(8) {
+		t.Fatalf("Attributes[top_k] = %#v, want the typed attribute mirrored", span.Attributes["top_k"])
+	}
+
+	span.SetAttribute("model", "gpt-4o")
+	span.Attributes["top_k"] = 16
+	span.Attributes["legacy"] = true
+	delete(span.Attributes, "service.name")
+	span.End()
+
+	got := span.AttributeMap()
+	if got["model"] != "gpt-4o" || got["top_k"] != int64(16) || got["legacy"] != true {
+		t.Errorf("AttributeMap() = %v, want map writes taken in", got)
+	}
+	if _, ok := got["service.name"]; ok {
+		t.Error("AttributeMap() kept a key deleted from Attributes")
+	}
+
+	encoded, err := tracer.encodeSpan(span)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`{"key":"top_k","value":{"intValue":16}}`, `{"key":"legacy","value":{"boolValue":true}}`} {
+		if !bytes.Contains(encoded, []byte(want)) {
+			t.Errorf("encoded span is missing %s:\n%s", want, encoded)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package agentbill
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// protoBuffer appends protobuf wire-format fields. The OTLP messages the SDK
+// sends are small and fixed, so they are encoded by hand rather than generated.
+type protoBuffer []byte
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func (b *protoBuffer) key(field, wireType int) {
+	*b = binary.AppendUvarint(*b, uint64(field)<<3|uint64(wireType))
+}
+
+func (b *protoBuffer) uint(field int, v uint64) {
+	b.key(field, wireVarint)
+	*b = binary.AppendUvarint(*b, v)
+}
+
+func (b *protoBuffer) fixed64(field int, v uint64) {
+	b.key(field, wireFixed64)
+	*b = binary.LittleEndian.AppendUint64(*b, v)
+}
+
+func (b *protoBuffer) bytes(field int, v []byte) {
+	b.key(field, wireBytes)
+	*b = binary.AppendUvarint(*b, uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *protoBuffer) string(field int, v string) {
+	b.key(field, wireBytes)
+	*b = binary.AppendUvarint(*b, uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *protoBuffer) message(field int, encode func(*protoBuffer)) {
+	var nested protoBuffer
+	encode(&nested)
+	b.bytes(field, nested)
+}
+
+// encodeOTLPProto converts OTLP/JSON-encoded spans into a protobuf
+// ExportTraceServiceRequest carrying the same resource and scope as the JSON payload
+func encodeOTLPProto(spans []json.RawMessage) ([]byte, error) {
+	decoded := make([]otlpJSONSpan, len(spans))
+	for i, raw := range spans {
+		if err := json.Unmarshal(raw, &decoded[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var request protoBuffer
+	request.message(1, func(rs *protoBuffer) { // ResourceSpans
+		rs.message(1, func(resource *protoBuffer) {
+			resource.message(1, stringKeyValue("service.name", sdkServiceName))
+			resource.message(1, stringKeyValue("service.version", sdkVersion))
+		})
+		rs.message(2, func(ss *protoBuffer) { // ScopeSpans
+			ss.message(1, func(scope *protoBuffer) {
+				scope.string(1, "agentbill")
+				scope.string(2, sdkVersion)
+			})
+			for _, span := range decoded {
+				ss.message(2, span.appendProto)
+			}
+		})
+	})
+	return request, nil
+}
+
+func (s otlpJSONSpan) appendProto(b *protoBuffer) {
+	b.bytes(1, otlpID(s.TraceID, 16))
+	b.bytes(2, otlpID(s.SpanID, 8))
+	if s.ParentSpanID != "" {
+		b.bytes(4, otlpID(s.ParentSpanID, 8))
+	}
+	b.string(5, s.Name)
+	b.uint(6, uint64(s.Kind))
+	b.fixed64(7, parseUnixNano(s.StartTime))
+	b.fixed64(8, parseUnixNano(s.EndTime))
+	for _, kv := range s.Attributes {
+		b.message(9, kv.appendProto)
+	}
+	for _, event := range s.Events {
+		event := event
+		b.message(11, func(e *protoBuffer) {
+			e.fixed64(1, parseUnixNano(event.Time))
+			e.string(2, event.Name)
+			for _, kv := range event.Attributes {
+				e.message(3, kv.appendProto)
+			}
+		})
+	}
+	b.message(15, func(status *protoBuffer) {
+		if s.Status.Message != "" {
+			status.string(2, s.Status.Message)
+		}
+		status.uint(3, uint64(s.Status.Code))
+	})
+}
+
+func (kv otlpKeyValue) appendProto(b *protoBuffer) {
+	b.string(1, kv.Key)
+	b.message(2, kv.Value.appendProto)
+}
+
+func (v otlpValue) appendProto(b *protoBuffer) {
+	switch {
+	case v.StringValue != nil:
+		b.string(1, *v.StringValue)
+	case v.BoolValue != nil:
+		value := uint64(0)
+		if *v.BoolValue {
+			value = 1
+		}
+		b.uint(2, value)
+	case v.IntValue != nil:
+		n, _ := v.IntValue.Int64()
+		b.uint(3, uint64(n))
+	case v.DoubleValue != nil:
+		f, _ := v.value().(float64)
+		b.fixed64(4, math.Float64bits(f))
+	}
+}
+
+func stringKeyValue(key, value string) func(*protoBuffer) {
+	return otlpKeyValue{Key: key, Value: otlpValue{StringValue: &value}}.appendProto
+}
+
+// otlpID converts an SDK ID to the fixed-size binary form OTLP/protobuf requires.
+// Hex IDs (dashes ignored) are right-aligned; anything else is hashed, so IDs
+// map the same way everywhere and parent links still match.
+func otlpID(id string, size int) []byte {
+	out := make([]byte, size)
+	if raw, err := hex.DecodeString(strings.ReplaceAll(id, "-", "")); err == nil && len(raw) <= size {
+		copy(out[size-len(raw):], raw)
+		return out
+	}
+	sum := sha256.Sum256([]byte(id))
+	copy(out, sum[:])
+	return out
+}
+
+func parseUnixNano(s string) uint64 {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
@@ -0,0 +1,218 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// protoField is one decoded protobuf field; varint and fixed64 values land in
+// num, length-delimited values in data
+type protoField struct {
+	field int
+	wire  int
+	num   uint64
+	data  []byte
+}
+
+func decodeProtoFields(t *testing.T, b []byte) []protoField {
+	t.Helper()
+	var fields []protoField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("bad field key at %x", b)
+		}
+		b = b[n:]
+		f := protoField{field: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case wireVarint:
+			f.num, n = binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatalf("bad varint for field %d", f.field)
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				t.Fatalf("short fixed64 for field %d", f.field)
+			}
+			f.num = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				t.Fatalf("bad length for field %d", f.field)
+			}
+			f.data = b[n : n+int(size)]
+			b = b[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d for field %d", f.wire, f.field)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// protoLookup returns every occurrence of field, checking its wire type
+func protoLookup(t *testing.T, fields []protoField, field, wire int) []protoField {
+	t.Helper()
+	var out []protoField
+	for _, f := range fields {
+		if f.field != field {
+			continue
+		}
+		if f.wire != wire {
+			t.Fatalf("field %d has wire type %d, want %d", field, f.wire, wire)
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func protoOne(t *testing.T, fields []protoField, field, wire int) protoField {
+	t.Helper()
+	found := protoLookup(t, fields, field, wire)
+	if len(found) != 1 {
+		t.Fatalf("field %d occurs %d times, want 1", field, len(found))
+	}
+	return found[0]
+}
+
+// protoAttributes decodes repeated KeyValue messages into key -> AnyValue fields
+func protoAttributes(t *testing.T, fields []protoField, field int) map[string][]protoField {
+	t.Helper()
+	attrs := make(map[string][]protoField)
+	for _, kv := range protoLookup(t, fields, field, wireBytes) {
+		decoded := decodeProtoFields(t, kv.data)
+		key := string(protoOne(t, decoded, 1, wireBytes).data)
+		attrs[key] = decodeProtoFields(t, protoOne(t, decoded, 2, wireBytes).data)
+	}
+	return attrs
+}
+
+func TestEncodeOTLPProto(t *testing.T) {
+	tracer, clock := goldenTracer()
+	start := clock.Now()
+	parent := tracer.Start(context.Background(), "agent.run")
+	child := tracer.Start(ContextWithSpan(context.Background(), parent), "agent.step",
+		attribute.String("string", "value"),
+		attribute.Bool("bool", true),
+		attribute.Int64("negative", -7),
+		attribute.Float64("double", 1.5),
+	)
+	clock.Advance(10 * time.Millisecond)
+	child.AddEvent("retry", attribute.Int("attempt", 2))
+	child.SetStatus(2, "upstream failed")
+	clock.Advance(5 * time.Millisecond)
+	child.End()
+	parent.End()
+
+	encoded, err := tracer.encodeSpan(child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := encodeOTLPProto([]json.RawMessage{encoded})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := decodeProtoFields(t, payload)
+	resourceSpans := decodeProtoFields(t, protoOne(t, request, 1, wireBytes).data)
+	resource := decodeProtoFields(t, protoOne(t, resourceSpans, 1, wireBytes).data)
+	resourceAttrs := protoAttributes(t, resource, 1)
+	if got := string(protoOne(t, resourceAttrs["service.name"], 1, wireBytes).data); got != sdkServiceName {
+		t.Errorf("service.name = %q, want %q", got, sdkServiceName)
+	}
+	scopeSpans := decodeProtoFields(t, protoOne(t, resourceSpans, 2, wireBytes).data)
+	scope := decodeProtoFields(t, protoOne(t, scopeSpans, 1, wireBytes).data)
+	if got := string(protoOne(t, scope, 1, wireBytes).data); got != "agentbill" {
+		t.Errorf("scope name = %q, want agentbill", got)
+	}
+
+	span := decodeProtoFields(t, protoOne(t, scopeSpans, 2, wireBytes).data)
+	if got, want := protoOne(t, span, 1, wireBytes).data, otlpID(child.TraceID, 16); !bytes.Equal(got, want) {
+		t.Errorf("traceId = %x, want %x", got, want)
+	}
+	if got, want := protoOne(t, span, 2, wireBytes).data, otlpID(child.SpanID, 8); !bytes.Equal(got, want) {
+		t.Errorf("spanId = %x, want %x", got, want)
+	}
+	if got, want := protoOne(t, span, 4, wireBytes).data, otlpID(parent.SpanID, 8); !bytes.Equal(got, want) {
+		t.Errorf("parentSpanId = %x, want %x", got, want)
+	}
+	if got := string(protoOne(t, span, 5, wireBytes).data); got != "agent.step" {
+		t.Errorf("name = %q, want agent.step", got)
+	}
+	if got, want := protoOne(t, span, 7, wireFixed64).num, uint64(start.UnixNano()); got != want {
+		t.Errorf("startTimeUnixNano = %d, want %d", got, want)
+	}
+	if got, want := protoOne(t, span, 8, wireFixed64).num, uint64(start.Add(15*time.Millisecond).UnixNano()); got != want {
+		t.Errorf("endTimeUnixNano = %d, want %d", got, want)
+	}
+
+	attrs := protoAttributes(t, span, 9)
+	if got := string(protoOne(t, attrs["string"], 1, wireBytes).data); got != "value" {
+		t.Errorf("string attribute = %q, want value", got)
+	}
+	if got := protoOne(t, attrs["bool"], 2, wireVarint).num; got != 1 {
+		t.Errorf("bool attribute = %d, want 1", got)
+	}
+	if got := int64(protoOne(t, attrs["negative"], 3, wireVarint).num); got != -7 {
+		t.Errorf("negative intValue = %d, want -7", got)
+	}
+	if got := math.Float64frombits(protoOne(t, attrs["double"], 4, wireFixed64).num); got != 1.5 {
+		t.Errorf("doubleValue = %v, want 1.5", got)
+	}
+
+	event := decodeProtoFields(t, protoOne(t, span, 11, wireBytes).data)
+	if got, want := protoOne(t, event, 1, wireFixed64).num, uint64(start.Add(10*time.Millisecond).UnixNano()); got != want {
+		t.Errorf("event timeUnixNano = %d, want %d", got, want)
+	}
+	if got := string(protoOne(t, event, 2, wireBytes).data); got != "retry" {
+		t.Errorf("event name = %q, want retry", got)
+	}
+	if got := protoOne(t, protoAttributes(t, event, 3)["attempt"], 3, wireVarint).num; got != 2 {
+		t.Errorf("event attempt = %d, want 2", got)
+	}
+
+	status := decodeProtoFields(t, protoOne(t, span, 15, wireBytes).data)
+	if got := string(protoOne(t, status, 2, wireBytes).data); got != "upstream failed" {
+		t.Errorf("status message = %q, want upstream failed", got)
+	}
+	if got := protoOne(t, status, 3, wireVarint).num; got != 2 {
+		t.Errorf("status code = %d, want 2", got)
+	}
+}
+
+func TestOTLPID(t *testing.T) {
+	hashed := sha256.Sum256([]byte("not-hex"))
+	tooLong := "0102030405060708ff"
+	tooLongHash := sha256.Sum256([]byte(tooLong))
+	cases := []struct {
+		name string
+		id   string
+		size int
+		want []byte
+	}{
+		{"short hex right-aligned", "abcd", 8, []byte{0, 0, 0, 0, 0, 0, 0xab, 0xcd}},
+		{"uuid dashes stripped", "00000000-0000-0000-0000-000000000001", 16,
+			[]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}},
+		{"exact size", "0102030405060708", 8, []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		{"non-hex hashed", "not-hex", 8, hashed[:8]},
+		{"hex too long hashed", tooLong, 8, tooLongHash[:8]},
+		{"empty", "", 8, make([]byte, 8)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := otlpID(tc.id, tc.size); !bytes.Equal(got, tc.want) {
+				t.Errorf("otlpID(%q, %d) = %x, want %x", tc.id, tc.size, got, tc.want)
+			}
+		})
+	}
+}
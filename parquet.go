@@ -0,0 +1,258 @@
+package agentbill
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// A minimal Parquet writer: required flat columns, PLAIN encoding, no compression,
+// one data page per column chunk and one row group per batch of rows. Metadata is
+// Thrift compact protocol, encoded by hand like the SDK's protobuf payloads.
+
+// Parquet physical types
+const (
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+)
+
+// Parquet converted types
+const (
+	parquetNoConversion    = -1
+	parquetUTF8            = 0
+	parquetTimestampMillis = 9
+)
+
+var parquetMagic = []byte("PAR1")
+
+type parquetColumn struct {
+	name      string
+	typ       int32
+	converted int32
+}
+
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type parquetRowGroup struct {
+	rows   int64
+	size   int64
+	chunks []parquetChunk
+}
+
+// parquetWriter streams row groups to w and writes the footer on close
+type parquetWriter struct {
+	w       io.Writer
+	columns []parquetColumn
+	offset  int64
+	groups  []parquetRowGroup
+}
+
+func newParquetWriter(w io.Writer, columns []parquetColumn) *parquetWriter {
+	return &parquetWriter{w: w, columns: columns}
+}
+
+func (p *parquetWriter) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	return err
+}
+
+// writeRowGroup writes one row group; pages holds each column's PLAIN-encoded values
+func (p *parquetWriter) writeRowGroup(rows int, pages [][]byte) error {
+	if p.offset == 0 {
+		if err := p.write(parquetMagic); err != nil {
+			return err
+		}
+	}
+	group := parquetRowGroup{rows: int64(rows)}
+	for _, page := range pages {
+		var header thriftStruct
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.structField(5, func(data *thriftStruct) {
+			data.i32(1, int32(rows))
+			data.i32(2, 0) // PLAIN
+			data.i32(3, 3) // RLE definition levels, none for required columns
+			data.i32(4, 3) // RLE repetition levels
+		})
+		headerBytes := header.end()
+
+		chunk := parquetChunk{offset: p.offset, size: int64(len(headerBytes) + len(page)), values: int64(rows)}
+		if err := p.write(headerBytes); err != nil {
+			return err
+		}
+		if err := p.write(page); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.size += chunk.size
+	}
+	p.groups = append(p.groups, group)
+	return nil
+}
+
+// close writes the file footer. A file without rows still gets a schema.
+func (p *parquetWriter) close() error {
+	if p.offset == 0 {
+		if err := p.write(parquetMagic); err != nil {
+			return err
+		}
+	}
+	var rows int64
+	for _, group := range p.groups {
+		rows += group.rows
+	}
+
+	var meta thriftStruct
+	meta.i32(1, 1)
+	meta.structList(2, len(p.columns)+1, func(i int, element *thriftStruct) {
+		if i == 0 {
+			element.binary(4, "schema")
+			element.i32(5, int32(len(p.columns)))
+			return
+		}
+		column := p.columns[i-1]
+		element.i32(1, column.typ)
+		element.i32(3, 0) // REQUIRED
+		element.binary(4, column.name)
+		if column.converted != parquetNoConversion {
+			element.i32(6, column.converted)
+		}
+	})
+	meta.i64(3, rows)
+	meta.structList(4, len(p.groups), func(i int, rowGroup *thriftStruct) {
+		group := p.groups[i]
+		rowGroup.structList(1, len(group.chunks), func(j int, chunk *thriftStruct) {
+			c := group.chunks[j]
+			chunk.i64(2, c.offset)
+			chunk.structField(3, func(column *thriftStruct) {
+				column.i32(1, p.columns[j].typ)
+				column.i32List(2, 0) // PLAIN
+				column.binaryList(3, p.columns[j].name)
+				column.i32(4, 0) // UNCOMPRESSED
+				column.i64(5, c.values)
+				column.i64(6, c.size)
+				column.i64(7, c.size)
+				column.i64(9, c.offset)
+			})
+		})
+		rowGroup.i64(2, group.size)
+		rowGroup.i64(3, group.rows)
+	})
+	meta.binary(6, "agentbill-go "+sdkVersion)
+	footer := meta.end()
+
+	if err := p.write(footer); err != nil {
+		return err
+	}
+	if err := p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	return p.write(parquetMagic)
+}
+
+func appendParquetInt64(b []byte, v int64) []byte {
+	return binary.LittleEndian.AppendUint64(b, uint64(v))
+}
+
+func appendParquetDouble(b []byte, v float64) []byte {
+	return binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+}
+
+func appendParquetString(b []byte, v string) []byte {
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(v)))
+	return append(b, v...)
+}
+
+// Thrift compact protocol type codes
+const (
+	thriftI32        = 5
+	thriftI64        = 6
+	thriftBinary     = 8
+	thriftList       = 9
+	thriftStructType = 12
+)
+
+// thriftStruct encodes one Thrift compact protocol struct
+type thriftStruct struct {
+	b    []byte
+	last int
+}
+
+func (s *thriftStruct) header(id, typ int) {
+	if delta := id - s.last; delta > 0 && delta <= 15 {
+		s.b = append(s.b, byte(delta<<4|typ))
+	} else {
+		s.b = append(s.b, byte(typ))
+		s.b = binary.AppendVarint(s.b, int64(id))
+	}
+	s.last = id
+}
+
+func (s *thriftStruct) i32(id int, v int32) {
+	s.header(id, thriftI32)
+	s.b = binary.AppendVarint(s.b, int64(v))
+}
+
+func (s *thriftStruct) i64(id int, v int64) {
+	s.header(id, thriftI64)
+	s.b = binary.AppendVarint(s.b, v)
+}
+
+func (s *thriftStruct) binary(id int, v string) {
+	s.header(id, thriftBinary)
+	s.b = binary.AppendUvarint(s.b, uint64(len(v)))
+	s.b = append(s.b, v...)
+}
+
+func (s *thriftStruct) structField(id int, encode func(*thriftStruct)) {
+	s.header(id, thriftStructType)
+	var nested thriftStruct
+	encode(&nested)
+	s.b = append(s.b, nested.end()...)
+}
+
+func (s *thriftStruct) listHeader(id, n, elemType int) {
+	s.header(id, thriftList)
+	if n < 15 {
+		s.b = append(s.b, byte(n<<4|elemType))
+		return
+	}
+	s.b = append(s.b, byte(0xf0|elemType))
+	s.b = binary.AppendUvarint(s.b, uint64(n))
+}
+
+func (s *thriftStruct) structList(id, n int, encode func(i int, element *thriftStruct)) {
+	s.listHeader(id, n, thriftStructType)
+	for i := 0; i < n; i++ {
+		var element thriftStruct
+		encode(i, &element)
+		s.b = append(s.b, element.end()...)
+	}
+}
+
+func (s *thriftStruct) i32List(id int, values ...int32) {
+	s.listHeader(id, len(values), thriftI32)
+	for _, v := range values {
+		s.b = binary.AppendVarint(s.b, int64(v))
+	}
+}
+
+func (s *thriftStruct) binaryList(id int, values ...string) {
+	s.listHeader(id, len(values), thriftBinary)
+	for _, v := range values {
+		s.b = binary.AppendUvarint(s.b, uint64(len(v)))
+		s.b = append(s.b, v...)
+	}
+}
+
+// end returns the struct terminated by its stop field
+func (s *thriftStruct) end() []byte {
+	return append(s.b, 0)
+}
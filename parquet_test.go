@@ -0,0 +1,229 @@
+package agentbill
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// thriftReader decodes the subset of the Thrift compact protocol the Parquet
+// writer emits. Structs decode to map[int]interface{}, lists to []interface{}.
+type thriftReader struct {
+	t *testing.T
+	b []byte
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.b)
+	if n <= 0 {
+		r.t.Fatal("thrift: bad varint")
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.t.Fatal("thrift: bad uvarint")
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) byte() byte {
+	if len(r.b) == 0 {
+		r.t.Fatal("thrift: unexpected end of data")
+	}
+	c := r.b[0]
+	r.b = r.b[1:]
+	return c
+}
+
+func (r *thriftReader) value(typ int) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		if n > len(r.b) {
+			r.t.Fatal("thrift: binary runs past end of data")
+		}
+		s := string(r.b[:n])
+		r.b = r.b[n:]
+		return s
+	case thriftList:
+		header := r.byte()
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(int(header & 0x0f))
+		}
+		return list
+	case thriftStructType:
+		return r.structValue()
+	}
+	r.t.Fatalf("thrift: unexpected type %d", typ)
+	return nil
+}
+
+func (r *thriftReader) structValue() map[int]interface{} {
+	fields := make(map[int]interface{})
+	last := 0
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		id := last + int(header>>4)
+		if header>>4 == 0 {
+			id = int(r.varint())
+		}
+		fields[id] = r.value(int(header & 0x0f))
+		last = id
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	pages := [][]UsageRecord{
+		{
+			{CustomerID: "customer-a", Model: "gpt-4o", Provider: "openai", PeriodStart: 1700000000, Requests: 3, PromptTokens: 120, CompletionTokens: 40, CostUSD: 0.25},
+			{CustomerID: "customer-b", Model: "claude", Provider: "anthropic", PeriodStart: 1700000060, Requests: 1, PromptTokens: 10, CompletionTokens: 5, CostUSD: 0.01},
+		},
+		{
+			{CustomerID: "customer-a", Model: "gpt-4o", Provider: "openai", PeriodStart: 1700000120, Requests: 7, PromptTokens: -1, CompletionTokens: 0, CostUSD: math.MaxFloat64},
+		},
+	}
+
+	var buf bytes.Buffer
+	w := newParquetWriter(&buf, usageColumns)
+	for _, records := range pages {
+		if err := w.writeRowGroup(len(records), usageParquetPages(records)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+	file := buf.Bytes()
+
+	meta := readParquetFooter(t, file)
+	if meta[1] != int64(1) {
+		t.Errorf("version = %v, want 1", meta[1])
+	}
+	if meta[3] != int64(3) {
+		t.Errorf("num_rows = %v, want 3", meta[3])
+	}
+	checkParquetSchema(t, meta[2].([]interface{}))
+
+	groups := meta[4].([]interface{})
+	if len(groups) != len(pages) {
+		t.Fatalf("got %d row groups, want %d", len(groups), len(pages))
+	}
+	for g, records := range pages {
+		group := groups[g].(map[int]interface{})
+		if group[3] != int64(len(records)) {
+			t.Errorf("row group %d num_rows = %v, want %d", g, group[3], len(records))
+		}
+		want := usageParquetPages(records)
+		chunks := group[1].([]interface{})
+		if len(chunks) != len(usageColumns) {
+			t.Fatalf("row group %d has %d column chunks, want %d", g, len(chunks), len(usageColumns))
+		}
+		var total int64
+		for c, raw := range chunks {
+			column := raw.(map[int]interface{})[3].(map[int]interface{})
+			if got := column[3]; !reflect.DeepEqual(got, []interface{}{usageColumns[c].name}) {
+				t.Errorf("chunk %d path = %v, want %s", c, got, usageColumns[c].name)
+			}
+			if column[1] != int64(usageColumns[c].typ) {
+				t.Errorf("chunk %d type = %v, want %d", c, column[1], usageColumns[c].typ)
+			}
+			if column[5] != int64(len(records)) {
+				t.Errorf("chunk %d num_values = %v, want %d", c, column[5], len(records))
+			}
+
+			offset, size := column[9].(int64), column[6].(int64)
+			total += size
+			page := &thriftReader{t: t, b: file[offset : offset+size]}
+			header := page.structValue()
+			if header[1] != int64(0) || header[2] != int64(len(want[c])) {
+				t.Errorf("chunk %d page header = %v", c, header)
+			}
+			if data := header[5].(map[int]interface{}); data[1] != int64(len(records)) {
+				t.Errorf("chunk %d data page num_values = %v, want %d", c, data[1], len(records))
+			}
+			if !bytes.Equal(page.b, want[c]) {
+				t.Errorf("chunk %d values = % x, want % x", c, page.b, want[c])
+			}
+		}
+		if group[2] != total {
+			t.Errorf("row group %d total_byte_size = %v, want %d", g, group[2], total)
+		}
+	}
+}
+
+func TestParquetWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newParquetWriter(&buf, usageColumns).close(); err != nil {
+		t.Fatal(err)
+	}
+	meta := readParquetFooter(t, buf.Bytes())
+	if meta[3] != int64(0) {
+		t.Errorf("num_rows = %v, want 0", meta[3])
+	}
+	if groups := meta[4].([]interface{}); len(groups) != 0 {
+		t.Errorf("got %d row groups, want none", len(groups))
+	}
+	checkParquetSchema(t, meta[2].([]interface{}))
+}
+
+// readParquetFooter checks the leading and trailing magic and the footer length,
+// and returns the decoded FileMetaData
+func readParquetFooter(t *testing.T, file []byte) map[int]interface{} {
+	t.Helper()
+	if !bytes.HasPrefix(file, parquetMagic) || !bytes.HasSuffix(file, parquetMagic) {
+		t.Fatal("file does not start and end with PAR1")
+	}
+	end := len(file) - len(parquetMagic) - 4
+	footerLen := int(binary.LittleEndian.Uint32(file[end:]))
+	if footerLen <= 0 || footerLen > end-len(parquetMagic) {
+		t.Fatalf("footer length %d out of range", footerLen)
+	}
+	r := &thriftReader{t: t, b: file[end-footerLen : end]}
+	meta := r.structValue()
+	if len(r.b) != 0 {
+		t.Fatalf("footer has %d trailing bytes", len(r.b))
+	}
+	return meta
+}
+
+func checkParquetSchema(t *testing.T, schema []interface{}) {
+	t.Helper()
+	if len(schema) != len(usageColumns)+1 {
+		t.Fatalf("schema has %d elements, want %d", len(schema), len(usageColumns)+1)
+	}
+	root := schema[0].(map[int]interface{})
+	if root[4] != "schema" || root[5] != int64(len(usageColumns)) {
+		t.Errorf("schema root = %v", root)
+	}
+	for i, column := range usageColumns {
+		element := schema[i+1].(map[int]interface{})
+		if element[4] != column.name || element[1] != int64(column.typ) || element[3] != int64(0) {
+			t.Errorf("schema element %d = %v, want required %s of type %d", i+1, element, column.name, column.typ)
+		}
+		converted, ok := element[6]
+		if column.converted == parquetNoConversion {
+			if ok {
+				t.Errorf("column %s has converted type %v, want none", column.name, converted)
+			}
+		} else if converted != int64(column.converted) {
+			t.Errorf("column %s converted type = %v, want %d", column.name, converted, column.converted)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package agentbill
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxExportResponseBytes bounds how much of a collector's response is read
+const maxExportResponseBytes = 64 << 10
+
+// otlpPartialSuccess is the partial_success of an OTLP export response: the
+// collector accepted the request but refused RejectedSpans of its spans, or
+// with none refused, sent a warning in ErrorMessage
+type otlpPartialSuccess struct {
+	RejectedSpans int64
+	ErrorMessage  string
+}
+
+// readPartialSuccess parses an ExportTraceServiceResponse, which collectors send
+// in the encoding of the request; an empty or unreadable response reports nothing
+func readPartialSuccess(body []byte, contentType string) otlpPartialSuccess {
+	if strings.Contains(contentType, "protobuf") || strings.Contains(contentType, "grpc") {
+		return readPartialSuccessProto(body)
+	}
+	var response struct {
+		PartialSuccess struct {
+			// int64 fields are strings in OTLP/JSON, though some collectors send numbers
+			RejectedSpans json.RawMessage `json:"rejectedSpans"`
+			ErrorMessage  string          `json:"errorMessage"`
+		} `json:"partialSuccess"`
+	}
+	if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &response) != nil {
+		return otlpPartialSuccess{}
+	}
+	rejected, _ := strconv.ParseInt(strings.Trim(string(response.PartialSuccess.RejectedSpans), `"`), 10, 64)
+	return otlpPartialSuccess{RejectedSpans: rejected, ErrorMessage: response.PartialSuccess.ErrorMessage}
+}
+
+// readPartialSuccessProto reads field 1 (partial_success) of an
+// ExportTraceServiceResponse, whose fields 1 and 2 are rejected_spans and error_message
+func readPartialSuccessProto(body []byte) otlpPartialSuccess {
+	var result otlpPartialSuccess
+	readProtoFields(body, func(field int, value []byte) error {
+		if field != 1 {
+			return nil
+		}
+		return walkProtoFields(value, func(field int, value uint64) {
+			if field == 1 {
+				result.RejectedSpans = int64(value)
+			}
+		}, func(field int, value []byte) error {
+			if field == 2 {
+				result.ErrorMessage = string(value)
+			}
+			return nil
+		})
+	})
+	return result
+}
+
+// readGRPCPartialSuccess parses the length-prefixed message of a gRPC export response
+func readGRPCPartialSuccess(body []byte) otlpPartialSuccess {
+	if len(body) < 5 {
+		return otlpPartialSuccess{}
+	}
+	size := binary.BigEndian.Uint32(body[1:5])
+	if uint64(size) > uint64(len(body)-5) {
+		return otlpPartialSuccess{}
+	}
+	message := body[5 : 5+size]
+	if body[0] == 1 {
+		gr, err := gzip.NewReader(bytes.NewReader(message))
+		if err != nil {
+			return otlpPartialSuccess{}
+		}
+		if message, err = io.ReadAll(io.LimitReader(gr, maxExportResponseBytes)); err != nil {
+			return otlpPartialSuccess{}
+		}
+	}
+	return readPartialSuccessProto(message)
+}
+
+// recordPartialSuccess counts spans the collector refused in an export it
+// otherwise accepted. OTLP does not say which spans were refused and asks that
+// they not be sent again, so they are counted in Stats.RejectedSpans, with the
+// collector's reason in Stats.LastRejection, rather than requeued.
+func (t *Tracer) recordPartialSuccess(p otlpPartialSuccess) {
+	if p.RejectedSpans > 0 {
+		t.metrics.rejected(p.RejectedSpans, p.ErrorMessage)
+	}
+	if !t.config.Debug {
+		return
+	}
+	switch {
+	case p.RejectedSpans > 0:
+		fmt.Printf("[AgentBill] Collector rejected %d spans: %s\n", p.RejectedSpans, p.ErrorMessage)
+	case p.ErrorMessage != "":
+		fmt.Printf("[AgentBill] Collector warning: %s\n", p.ErrorMessage)
+	}
+}
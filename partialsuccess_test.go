@@ -0,0 +1,151 @@
+package agentbill
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// partialSuccessProto encodes an ExportTraceServiceResponse carrying partial_success
+func partialSuccessProto(rejected int64, message string) []byte {
+	var response protoBuffer
+	response.message(1, func(p *protoBuffer) {
+		p.uint(1, uint64(rejected))
+		if message != "" {
+			p.string(2, message)
+		}
+	})
+	return response
+}
+
+func grpcFrame(t *testing.T, message []byte, compress bool) []byte {
+	t.Helper()
+	flag := byte(0)
+	if compress {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(message); err != nil {
+			t.Fatal(err)
+		}
+		if err := gw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		message, flag = buf.Bytes(), 1
+	}
+	frame := binary.BigEndian.AppendUint32([]byte{flag}, uint32(len(message)))
+	return append(frame, message...)
+}
+
+func TestReadPartialSuccess(t *testing.T) {
+	cases := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        otlpPartialSuccess
+	}{
+		{"json string count", []byte(`{"partialSuccess":{"rejectedSpans":"3","errorMessage":"too old"}}`), "application/json",
+			otlpPartialSuccess{RejectedSpans: 3, ErrorMessage: "too old"}},
+		{"json number count", []byte(`{"partialSuccess":{"rejectedSpans":4}}`), "application/json; charset=utf-8",
+			otlpPartialSuccess{RejectedSpans: 4}},
+		{"json warning only", []byte(`{"partialSuccess":{"errorMessage":"deprecated field"}}`), "application/json",
+			otlpPartialSuccess{ErrorMessage: "deprecated field"}},
+		{"json full success", []byte(`{}`), "application/json", otlpPartialSuccess{}},
+		{"json no content type", []byte(`{"partialSuccess":{"rejectedSpans":"1"}}`), "",
+			otlpPartialSuccess{RejectedSpans: 1}},
+		{"empty body", nil, "application/json", otlpPartialSuccess{}},
+		{"invalid json", []byte(`{"partialSuccess":`), "application/json", otlpPartialSuccess{}},
+		{"protobuf", partialSuccessProto(5, "bad attribute"), "application/x-protobuf",
+			otlpPartialSuccess{RejectedSpans: 5, ErrorMessage: "bad attribute"}},
+		{"protobuf full success", nil, "application/x-protobuf", otlpPartialSuccess{}},
+		{"protobuf truncated", partialSuccessProto(5, "bad attribute")[:4], "application/x-protobuf",
+			otlpPartialSuccess{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := readPartialSuccess(tc.body, tc.contentType); got != tc.want {
+				t.Errorf("readPartialSuccess() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadGRPCPartialSuccess(t *testing.T) {
+	message := partialSuccessProto(2, "quota exceeded")
+	want := otlpPartialSuccess{RejectedSpans: 2, ErrorMessage: "quota exceeded"}
+	cases := []struct {
+		name string
+		body []byte
+		want otlpPartialSuccess
+	}{
+		{"plain", grpcFrame(t, message, false), want},
+		{"gzip", grpcFrame(t, message, true), want},
+		{"empty message", grpcFrame(t, nil, false), otlpPartialSuccess{}},
+		{"short header", []byte{0, 0, 0}, otlpPartialSuccess{}},
+		{"length past end", grpcFrame(t, message, false)[:8], otlpPartialSuccess{}},
+		{"bad gzip", append([]byte{1, 0, 0, 0, 3}, "abc"...), otlpPartialSuccess{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := readGRPCPartialSuccess(tc.body); got != tc.want {
+				t.Errorf("readGRPCPartialSuccess() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPartialSuccessMetrics(t *testing.T) {
+	responses := []struct {
+		contentType string
+		body        []byte
+	}{
+		{"application/json", []byte(`{"partialSuccess":{"rejectedSpans":"2","errorMessage":"span too old"}}`)},
+		{"application/x-protobuf", partialSuccessProto(3, "invalid trace id")},
+		{"application/json", []byte(`{"partialSuccess":{"errorMessage":"warning only"}}`)},
+	}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		response := responses[requests%len(responses)]
+		requests++
+		w.Header().Set("Content-Type", response.contentType)
+		w.Write(response.body)
+	}))
+	defer server.Close()
+
+	client := Init(Config{APIKey: "test", BaseURL: server.URL, CustomerID: "customer-123"})
+	ctx := context.Background()
+	for range responses {
+		client.tracer.startInternal(ctx, "openai.chat.completion").End()
+		if err := client.Flush(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != len(responses) {
+		t.Fatalf("collector received %d requests, want %d", requests, len(responses))
+	}
+
+	stats := client.Stats()
+	if stats.RejectedSpans != 5 {
+		t.Errorf("RejectedSpans = %d, want 5", stats.RejectedSpans)
+	}
+	if stats.LastRejection != "invalid trace id" {
+		t.Errorf("LastRejection = %q, want the last rejection's reason", stats.LastRejection)
+	}
+	if stats.ExportErrors != 0 {
+		t.Errorf("ExportErrors = %d, want partial successes not counted as errors", stats.ExportErrors)
+	}
+
+	var metrics strings.Builder
+	if err := client.WritePrometheus(&metrics); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(metrics.String(), "\nagentbill_rejected_spans_total 5\n") {
+		t.Errorf("Prometheus output is missing agentbill_rejected_spans_total 5:\n%s", metrics.String())
+	}
+}
@@ -0,0 +1,62 @@
+package agentbill
+
+import (
+	"context"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+var perplexityProvider = &chatProvider{
+	name:    "perplexity",
+	service: "Perplexity",
+	baseURL: "https://api.perplexity.ai",
+	keyEnv:  "PERPLEXITY_API_KEY",
+	record:  recordPerplexity,
+}
+
+// PerplexityWrapper wraps Perplexity (Sonar) chat calls
+type PerplexityWrapper struct {
+	client    *Client
+	guardrail GuardrailHook
+}
+
+// WrapPerplexity wraps the Perplexity API for tracking. The API key is read from PERPLEXITY_API_KEY.
+func (c *Client) WrapPerplexity() *PerplexityWrapper {
+	return &PerplexityWrapper{client: c}
+}
+
+// WithGuardrail returns a copy of the wrapper that runs hook before every call
+func (w *PerplexityWrapper) WithGuardrail(hook GuardrailHook) *PerplexityWrapper {
+	wrapped := *w
+	wrapped.guardrail = hook
+	return &wrapped
+}
+
+// ChatCompletion tracks a Perplexity chat completion call. Perplexity bills a
+// request fee on top of tokens, so the cost includes the model's PerRequest
+// price and the span records the citations and search results returned.
+func (w *PerplexityWrapper) ChatCompletion(ctx context.Context, model string, messages []map[string]string) (map[string]interface{}, error) {
+	return w.client.chatCompletion(ctx, perplexityProvider, w.guardrail, model, messages)
+}
+
+// ChatCompletionStream streams a Perplexity chat completion, see OpenAIWrapper.ChatCompletionStream
+func (w *PerplexityWrapper) ChatCompletionStream(ctx context.Context, model string, messages []map[string]string, onDelta StreamCallback) (map[string]interface{}, error) {
+	return w.client.chatCompletionStream(ctx, perplexityProvider, w.guardrail, model, messages, onDelta)
+}
+
+// recordPerplexity records the search side of a Perplexity call
+func recordPerplexity(span *Span, response map[string]interface{}) {
+	if citations, ok := response["citations"].([]interface{}); ok {
+		span.SetAttributes(attribute.Int("response.citations", len(citations)))
+	}
+	if results, ok := response["search_results"].([]interface{}); ok {
+		span.SetAttributes(attribute.Int("response.search_results", len(results)))
+	}
+	usage, _ := response["usage"].(map[string]interface{})
+	if queries, ok := usage["num_search_queries"].(float64); ok {
+		span.SetAttributes(attribute.Int("response.search_queries", int(queries)))
+	}
+	if size, ok := usage["search_context_size"].(string); ok && size != "" {
+		span.SetAttributes(attribute.String("response.search_context_size", size))
+	}
+}
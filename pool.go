@@ -0,0 +1,49 @@
+package agentbill
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// Buffers larger than this are left for the garbage collector so one huge
+// flush doesn't pin its memory in the pool
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+var spanPool = sync.Pool{
+	New: func() interface{} {
+		return &Span{attributes: make([]attribute.KeyValue, 0, 16)}
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+func newSpan() *Span {
+	return spanPool.Get().(*Span)
+}
+
+// release returns an exported SDK-owned span to the pool
+func (s *Span) release() {
+	if !s.recyclable || s.EndTime == 0 {
+		return
+	}
+	attributes, encoded := s.attributes[:0], s.encoded[:0]
+	*s = Span{attributes: attributes, encoded: encoded}
+	spanPool.Put(s)
+}
@@ -0,0 +1,231 @@
+package agentbill
+
+import "strings"
+
+// ModelPrice is the USD price per million tokens for a model, or per unit for
+// models billed in other units
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+	// CachedInputPerMillion prices input tokens served from the provider's prompt
+	// cache; cached tokens cost InputPerMillion when it is 0
+	CachedInputPerMillion float64 `json:"cached_input_per_million,omitempty"`
+	// PerRequest is a flat USD fee per billed call, on top of tokens (e.g. Perplexity's search fee)
+	PerRequest float64 `json:"per_request,omitempty"`
+	// Unit is what a model is billed in when it isn't tokens, and PerUnit is the
+	// USD price of one
+	Unit    BillingUnit `json:"unit,omitempty"`
+	PerUnit float64     `json:"per_unit,omitempty"`
+}
+
+// BillingUnit is a usage unit other than tokens
+type BillingUnit string
+
+// Billing units recorded as usage.unit on metered spans
+const (
+	UnitImages       BillingUnit = "images"
+	UnitCharacters   BillingUnit = "characters"
+	UnitAudioMinutes BillingUnit = "audio_minutes"
+	UnitGPUSeconds   BillingUnit = "gpu_seconds"
+	// UnitSearchUnits is Cohere's rerank unit: one query over up to 100 documents
+	UnitSearchUnits BillingUnit = "search_units"
+)
+
+var defaultPricing = map[string]ModelPrice{
+	"gpt-4o":            {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+	"gpt-4o-mini":       {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	"gpt-4-turbo":       {InputPerMillion: 10.00, OutputPerMillion: 30.00},
+	"gpt-4":             {InputPerMillion: 30.00, OutputPerMillion: 60.00},
+	"gpt-3.5-turbo":     {InputPerMillion: 0.50, OutputPerMillion: 1.50},
+	"o1":                {InputPerMillion: 15.00, OutputPerMillion: 60.00},
+	"o1-mini":           {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+	"o3-mini":           {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+	"claude-3-5-sonnet": {InputPerMillion: 3.00, OutputPerMillion: 15.00, CachedInputPerMillion: 0.30},
+	"claude-3-5-haiku":  {InputPerMillion: 0.80, OutputPerMillion: 4.00, CachedInputPerMillion: 0.08},
+	"claude-3-opus":     {InputPerMillion: 15.00, OutputPerMillion: 75.00, CachedInputPerMillion: 1.50},
+	"claude-3-haiku":    {InputPerMillion: 0.25, OutputPerMillion: 1.25, CachedInputPerMillion: 0.03},
+	"deepseek-chat":     {InputPerMillion: 0.27, OutputPerMillion: 1.10, CachedInputPerMillion: 0.07},
+	"deepseek-reasoner": {InputPerMillion: 0.55, OutputPerMillion: 2.19, CachedInputPerMillion: 0.14},
+	"grok-2":            {InputPerMillion: 2.00, OutputPerMillion: 10.00},
+	"grok-2-vision":     {InputPerMillion: 2.00, OutputPerMillion: 10.00},
+	"grok-beta":         {InputPerMillion: 5.00, OutputPerMillion: 15.00},
+	// Perplexity request fees are for the default (low) search context size
+	"sonar":               {InputPerMillion: 1.00, OutputPerMillion: 1.00, PerRequest: 0.005},
+	"sonar-pro":           {InputPerMillion: 3.00, OutputPerMillion: 15.00, PerRequest: 0.006},
+	"sonar-reasoning":     {InputPerMillion: 1.00, OutputPerMillion: 5.00, PerRequest: 0.005},
+	"sonar-reasoning-pro": {InputPerMillion: 2.00, OutputPerMillion: 8.00, PerRequest: 0.006},
+	// Fireworks serverless models go by their full resource name
+	"accounts/fireworks/models/llama-v3p1-8b-instruct":   {InputPerMillion: 0.20, OutputPerMillion: 0.20},
+	"accounts/fireworks/models/llama-v3p1-70b-instruct":  {InputPerMillion: 0.90, OutputPerMillion: 0.90},
+	"accounts/fireworks/models/llama-v3p1-405b-instruct": {InputPerMillion: 3.00, OutputPerMillion: 3.00},
+	"nomic-ai/nomic-embed-text-v1.5":                     {InputPerMillion: 0.008},
+	// Embeddings
+	"text-embedding-3-small": {InputPerMillion: 0.02},
+	"text-embedding-3-large": {InputPerMillion: 0.13},
+	"text-embedding-ada-002": {InputPerMillion: 0.10},
+	"voyage-3":               {InputPerMillion: 0.06},
+	"voyage-3-lite":          {InputPerMillion: 0.02},
+	"voyage-3-large":         {InputPerMillion: 0.18},
+	"voyage-code-3":          {InputPerMillion: 0.18},
+	"jina-embeddings-v3":     {InputPerMillion: 0.02},
+	// Image models are keyed by model, then quality and size for non-default sizes
+	"dall-e-3":                    {Unit: UnitImages, PerUnit: 0.040},
+	"dall-e-3-standard-1024x1792": {Unit: UnitImages, PerUnit: 0.080},
+	"dall-e-3-standard-1792x1024": {Unit: UnitImages, PerUnit: 0.080},
+	"dall-e-3-hd":                 {Unit: UnitImages, PerUnit: 0.080},
+	"dall-e-3-hd-1024x1792":       {Unit: UnitImages, PerUnit: 0.120},
+	"dall-e-3-hd-1792x1024":       {Unit: UnitImages, PerUnit: 0.120},
+	"dall-e-2":                    {Unit: UnitImages, PerUnit: 0.020},
+	"dall-e-2-standard-512x512":   {Unit: UnitImages, PerUnit: 0.018},
+	"dall-e-2-standard-256x256":   {Unit: UnitImages, PerUnit: 0.016},
+	// Stability prices are credits at $0.01 for the default 30 steps
+	"stable-diffusion-xl-1024-v1-0": {Unit: UnitImages, PerUnit: 0.006},
+	"stable-diffusion-v1-6":         {Unit: UnitImages, PerUnit: 0.002},
+	// ElevenLabs characters at Creator plan overage rates; set your plan's rate in Config.Pricing
+	"eleven_multilingual_v2": {Unit: UnitCharacters, PerUnit: 0.00030},
+	"eleven_turbo_v2_5":      {Unit: UnitCharacters, PerUnit: 0.00015},
+	"eleven_flash_v2_5":      {Unit: UnitCharacters, PerUnit: 0.00015},
+	// Transcription per audio minute of pre-recorded audio, keyed by provider and model tier
+	"deepgram/nova-3":        {Unit: UnitAudioMinutes, PerUnit: 0.0043},
+	"deepgram/nova-2":        {Unit: UnitAudioMinutes, PerUnit: 0.0043},
+	"deepgram/enhanced":      {Unit: UnitAudioMinutes, PerUnit: 0.0145},
+	"deepgram/base":          {Unit: UnitAudioMinutes, PerUnit: 0.0125},
+	"deepgram/whisper-large": {Unit: UnitAudioMinutes, PerUnit: 0.0048},
+	"assemblyai/best":        {Unit: UnitAudioMinutes, PerUnit: 0.37 / 60},
+	"assemblyai/nano":        {Unit: UnitAudioMinutes, PerUnit: 0.12 / 60},
+	// Cohere rerank per search unit
+	"rerank-v3.5":              {Unit: UnitSearchUnits, PerUnit: 0.002},
+	"rerank-english-v3.0":      {Unit: UnitSearchUnits, PerUnit: 0.002},
+	"rerank-multilingual-v3.0": {Unit: UnitSearchUnits, PerUnit: 0.002},
+	// Gemini on Vertex AI; cached input is the context cache rate
+	"gemini-1.5-pro":        {InputPerMillion: 1.25, OutputPerMillion: 5.00, CachedInputPerMillion: 0.3125},
+	"gemini-1.5-flash":      {InputPerMillion: 0.075, OutputPerMillion: 0.30, CachedInputPerMillion: 0.01875},
+	"gemini-2.0-flash":      {InputPerMillion: 0.15, OutputPerMillion: 0.60, CachedInputPerMillion: 0.0375},
+	"gemini-2.0-flash-lite": {InputPerMillion: 0.075, OutputPerMillion: 0.30, CachedInputPerMillion: 0.01875},
+}
+
+// PriceFor returns the price of a model, preferring Config.Pricing over the built-in table.
+// Dated model snapshots (e.g. gpt-4o-2024-08-06) resolve to their longest matching prefix,
+// and Claude model IDs from Bedrock and Vertex AI price as their Anthropic equivalent.
+func (c *Client) PriceFor(model string) (ModelPrice, bool) {
+	if price, ok := c.config.Pricing[model]; ok {
+		return price, true
+	}
+	model = CanonicalModel(model)
+	if price, ok := c.config.Pricing[model]; ok {
+		return price, true
+	}
+	if price, ok := defaultPricing[model]; ok {
+		return price, true
+	}
+	// The longest prefix wins across both tables, so overriding gpt-4o leaves gpt-4o-mini alone
+	custom, builtin := longestPrefix(c.config.Pricing, model), longestPrefix(defaultPricing, model)
+	switch {
+	case custom != "" && len(custom) >= len(builtin):
+		return c.config.Pricing[custom], true
+	case builtin != "":
+		return defaultPricing[builtin], true
+	}
+	return ModelPrice{}, false
+}
+
+// EstimateCost returns the estimated USD cost of a call, or 0 for unknown models.
+// A model's PerRequest fee applies to calls that used any tokens.
+func (c *Client) EstimateCost(model string, promptTokens, completionTokens int) float64 {
+	return c.estimateCachedCost(model, promptTokens, 0, completionTokens)
+}
+
+// estimateCachedCost prices a call whose prompt tokens include cachedTokens read from the prompt cache
+func (c *Client) estimateCachedCost(model string, promptTokens, cachedTokens, completionTokens int) float64 {
+	price, ok := c.PriceFor(model)
+	if !ok {
+		return 0
+	}
+	return price.cost(promptTokens, cachedTokens, completionTokens)
+}
+
+func (price ModelPrice) cost(promptTokens, cachedTokens, completionTokens int) float64 {
+	cachedPrice := price.CachedInputPerMillion
+	if cachedPrice == 0 {
+		cachedPrice = price.InputPerMillion
+	}
+	input := float64(promptTokens-cachedTokens)*price.InputPerMillion + float64(cachedTokens)*cachedPrice
+	cost := (input + float64(completionTokens)*price.OutputPerMillion) / 1e6
+	if promptTokens+completionTokens > 0 {
+		// Cache hits record no tokens and never reached the provider
+		cost += price.PerRequest
+	}
+	return cost
+}
+
+// EstimateUnitCost returns the USD cost of quantity units of a model billed in
+// a BillingUnit, or 0 when the model has no unit price
+func (c *Client) EstimateUnitCost(model string, quantity float64) float64 {
+	price, ok := c.PriceFor(model)
+	if !ok {
+		return 0
+	}
+	return price.PerUnit * quantity
+}
+
+// CanonicalModel returns the Anthropic API name of a Claude model served through
+// Amazon Bedrock or Vertex AI, so usage from every cloud prices and aggregates as
+// one model; other model IDs are returned unchanged. For example:
+//
+//	anthropic.claude-3-5-sonnet-20241022-v2:0    -> claude-3-5-sonnet-20241022
+//	us.anthropic.claude-3-haiku-20240307-v1:0    -> claude-3-haiku-20240307
+//	arn:aws:bedrock:...:inference-profile/eu.anthropic.claude-3-5-sonnet-20240620-v1:0
+//	                                             -> claude-3-5-sonnet-20240620
+//	claude-3-5-sonnet-v2@20241022                -> claude-3-5-sonnet-20241022
+func CanonicalModel(model string) string {
+	id := model
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		// ARNs and Vertex resource names end in the model ID
+		id = id[i+1:]
+	}
+
+	// Bedrock: optional cross-region prefix (us., eu., apac.), anthropic., name, -v<n>:<n>
+	if i := strings.Index(id, "anthropic.claude-"); i >= 0 && !strings.Contains(id[:i], "anthropic") {
+		name := id[i+len("anthropic."):]
+		if j := strings.LastIndex(name, "-v"); j >= 0 && strings.Contains(name[j:], ":") && endsWithDate(name[:j]) {
+			name = name[:j]
+		}
+		return name
+	}
+
+	// Vertex AI: name[-v<n>]@date
+	if name, date, ok := strings.Cut(id, "@"); ok && strings.HasPrefix(name, "claude-") {
+		if j := strings.LastIndex(name, "-v"); j >= 0 && isDigits(name[j+2:]) {
+			name = name[:j]
+		}
+		return name + "-" + date
+	}
+	return model
+}
+
+// endsWithDate reports whether s ends in an eight-digit snapshot date
+func endsWithDate(s string) bool {
+	return len(s) > 9 && s[len(s)-9] == '-' && isDigits(s[len(s)-8:])
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func longestPrefix(table map[string]ModelPrice, model string) string {
+	best := ""
+	for name := range table {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	return best
+}
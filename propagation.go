@@ -0,0 +1,108 @@
+package agentbill
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Header names used to propagate AgentBill context between services
+const (
+	HeaderTraceID      = "X-AgentBill-Trace-Id"
+	HeaderSpanID       = "X-AgentBill-Span-Id"
+	HeaderSessionID    = "X-AgentBill-Session-Id"
+	HeaderExperiment   = "X-AgentBill-Experiment"
+	HeaderVariant      = "X-AgentBill-Variant"
+	HeaderOrganization = "X-AgentBill-Organization"
+	HeaderProject      = "X-AgentBill-Project"
+	HeaderUser         = "X-AgentBill-User"
+)
+
+var propagatedHeaders = []string{HeaderTraceID, HeaderSpanID, HeaderSessionID, HeaderExperiment, HeaderVariant, HeaderOrganization, HeaderProject, HeaderUser}
+
+// InjectHTTP writes the trace and attribution context from ctx into HTTP headers
+func InjectHTTP(ctx context.Context, header http.Header) {
+	inject(ctx, func(key, value string) { header.Set(key, value) })
+}
+
+// ExtractHTTP returns a context carrying the trace and attribution context found in HTTP headers
+func ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+	return extract(ctx, header.Get)
+}
+
+// InjectMetadata writes the trace and attribution context into gRPC-style metadata.
+// md is compatible with google.golang.org/grpc/metadata.MD.
+func InjectMetadata(ctx context.Context, md map[string][]string) {
+	inject(ctx, func(key, value string) { md[strings.ToLower(key)] = []string{value} })
+}
+
+// ExtractMetadata returns a context carrying the trace and attribution context found in gRPC-style metadata
+func ExtractMetadata(ctx context.Context, md map[string][]string) context.Context {
+	return extract(ctx, func(key string) string {
+		if values := md[strings.ToLower(key)]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	})
+}
+
+// Middleware extracts propagated AgentBill context from incoming requests
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(ExtractHTTP(r.Context(), r.Header)))
+	})
+}
+
+// PropagatingTransport injects AgentBill context into outgoing requests
+type PropagatingTransport struct {
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *PropagatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	req = req.Clone(req.Context())
+	InjectHTTP(req.Context(), req.Header)
+	return base.RoundTrip(req)
+}
+
+func inject(ctx context.Context, set func(key, value string)) {
+	tc := TraceContextFromContext(ctx)
+	attribution := AttributionFromContext(ctx)
+	values := []string{tc.TraceID, tc.SpanID, tc.SessionID, attribution.Experiment, attribution.Variant,
+		attribution.Organization, attribution.Project, attribution.User}
+	for i, key := range propagatedHeaders {
+		if values[i] != "" {
+			set(key, values[i])
+		}
+	}
+}
+
+func extract(ctx context.Context, get func(key string) string) context.Context {
+	tc := TraceContextFromContext(ctx)
+	if traceID := get(HeaderTraceID); traceID != "" {
+		tc.TraceID = traceID
+		tc.SpanID = get(HeaderSpanID)
+	}
+	if sessionID := get(HeaderSessionID); sessionID != "" {
+		tc.SessionID = sessionID
+	}
+	ctx = WithTraceContext(ctx, tc)
+
+	if experiment := get(HeaderExperiment); experiment != "" {
+		ctx = WithExperiment(ctx, experiment, get(HeaderVariant))
+	}
+	if organization := get(HeaderOrganization); organization != "" {
+		ctx = WithOrganization(ctx, organization)
+	}
+	if project := get(HeaderProject); project != "" {
+		ctx = WithProject(ctx, project)
+	}
+	if user := get(HeaderUser); user != "" {
+		ctx = WithUser(ctx, user)
+	}
+	return ctx
+}
@@ -0,0 +1,130 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Adjustment signal names emitted for plan changes
+const (
+	SignalProrationCredit = "proration_credit"
+	SignalProrationCharge = "proration_charge"
+)
+
+// PlanChange is a customer's move between plans part way through a billing period
+type PlanChange struct {
+	// CustomerID defaults to the client's customer
+	CustomerID string
+	FromPlan   string
+	ToPlan     string
+	// FromPriceUSD and ToPriceUSD are the plans' recurring prices for a full period
+	FromPriceUSD float64
+	ToPriceUSD   float64
+	// FromAllowance and ToAllowance are the usage each plan includes per full
+	// period, in any unit; both zero when the plans include no usage
+	FromAllowance float64
+	ToAllowance   float64
+	PeriodStart   time.Time
+	PeriodEnd     time.Time
+	// ChangedAt defaults to now
+	ChangedAt time.Time
+}
+
+// Proration is what a plan change credits and charges for the rest of the period
+type Proration struct {
+	// Remaining is the fraction of the period left at the change, from 0 to 1
+	Remaining float64
+	// CreditUSD refunds the unused part of the old plan
+	CreditUSD float64
+	// ChargeUSD bills the new plan for the rest of the period
+	ChargeUSD float64
+	// Allowance is the usage included for the whole period: the old plan's
+	// allowance for the time used plus the new plan's for the time remaining
+	Allowance float64
+}
+
+// NetUSD is the amount the change bills, negative when the customer is owed a credit
+func (p Proration) NetUSD() float64 {
+	return p.ChargeUSD - p.CreditUSD
+}
+
+// Prorate computes a plan change's proration by time remaining in the period.
+// Amounts are rounded to the cent.
+func Prorate(change PlanChange) (Proration, error) {
+	if !change.PeriodEnd.After(change.PeriodStart) {
+		return Proration{}, errors.New("proration: period end must be after its start")
+	}
+	if change.ChangedAt.Before(change.PeriodStart) || change.ChangedAt.After(change.PeriodEnd) {
+		return Proration{}, errors.New("proration: change falls outside the period")
+	}
+	remaining := float64(change.PeriodEnd.Sub(change.ChangedAt)) / float64(change.PeriodEnd.Sub(change.PeriodStart))
+	return Proration{
+		Remaining: remaining,
+		CreditUSD: roundCents(change.FromPriceUSD * remaining),
+		ChargeUSD: roundCents(change.ToPriceUSD * remaining),
+		Allowance: change.FromAllowance*(1-remaining) + change.ToAllowance*remaining,
+	}, nil
+}
+
+// TrackPlanChange prorates a plan change and records it as adjustment signals:
+// proration_credit with negative revenue for the unused part of the old plan
+// and proration_charge for the new plan's remainder, both recognized over the
+// rest of the period. A zero amount is not recorded. Both signals are keyed by
+// customer and change time, so a retried change is recorded once when
+// SignalDedupWindow is set.
+func (c *Client) TrackPlanChange(ctx context.Context, change PlanChange) (Proration, error) {
+	change.CustomerID = c.customerID(change.CustomerID)
+	if change.ChangedAt.IsZero() {
+		change.ChangedAt = c.config.clock().Now()
+	}
+	proration, err := Prorate(change)
+	if err != nil {
+		return Proration{}, err
+	}
+
+	data := func() map[string]interface{} {
+		return map[string]interface{}{
+			"from_plan":    change.FromPlan,
+			"to_plan":      change.ToPlan,
+			"remaining":    proration.Remaining,
+			"allowance":    proration.Allowance,
+			"period_start": change.PeriodStart.Unix(),
+			"period_end":   change.PeriodEnd.Unix(),
+			"changed_at":   change.ChangedAt.Unix(),
+		}
+	}
+	key := fmt.Sprintf("%s:%d", change.CustomerID, change.ChangedAt.UnixNano())
+
+	if proration.CreditUSD != 0 {
+		credit := Signal{
+			EventName:      SignalProrationCredit,
+			Revenue:        -proration.CreditUSD,
+			CustomerID:     change.CustomerID,
+			IdempotencyKey: "credit:" + key,
+			Data:           data(),
+		}
+		if err := c.TrackSignal(ctx, credit.RecognizeOver(change.ChangedAt, change.PeriodEnd)); err != nil {
+			return proration, err
+		}
+	}
+	if proration.ChargeUSD != 0 {
+		charge := Signal{
+			EventName:      SignalProrationCharge,
+			Revenue:        proration.ChargeUSD,
+			CustomerID:     change.CustomerID,
+			IdempotencyKey: "charge:" + key,
+			Data:           data(),
+		}
+		if err := c.TrackSignal(ctx, charge.RecognizeOver(change.ChangedAt, change.PeriodEnd)); err != nil {
+			return proration, err
+		}
+	}
+	return proration, nil
+}
+
+func roundCents(usd float64) float64 {
+	return math.Round(usd*100) / 100
+}
@@ -0,0 +1,357 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// chatProvider describes a provider HTTP API; most serve OpenAI-style chat completions
+type chatProvider struct {
+	// name is the span's provider attribute and span name prefix
+	name string
+	// service names the provider in APIErrors
+	service string
+	// baseURL includes the API version, e.g. https://api.openai.com/v1
+	baseURL string
+	keyEnv  string
+	// authHeader, when set, carries the raw API key in place of Authorization: Bearer
+	authHeader string
+	// authScheme replaces Bearer in the Authorization header, e.g. Deepgram's "Token"
+	authScheme string
+	// token, when set, supplies the bearer token in place of the keyEnv API key
+	token func(ctx context.Context) (string, error)
+	// headers are added to every request
+	headers map[string]string
+	// extra fields are added to every chat request body
+	extra map[string]interface{}
+	// record adds provider-specific response details to the span; optional
+	record func(span *Span, response map[string]interface{})
+	// finish adds details that depend on the call's duration before the span ends; optional
+	finish func(span *Span, elapsed time.Duration)
+	// price, when set, prices calls in place of the model's price
+	price *ModelPrice
+}
+
+var openAIProvider = &chatProvider{
+	name:    "openai",
+	service: "OpenAI",
+	baseURL: "https://api.openai.com/v1",
+	keyEnv:  "OPENAI_API_KEY",
+}
+
+// chatCompletion tracks a chat completion call to an OpenAI-compatible provider
+func (c *Client) chatCompletion(ctx context.Context, p *chatProvider, guardrail GuardrailHook, model string, messages []map[string]string) (map[string]interface{}, error) {
+	return c.chatCompletionRaw(ctx, p, guardrail, model, messages, nil)
+}
+
+// chatCompletionRaw is chatCompletion that also stores the provider's response
+// body in raw when raw is not nil; raw is left nil for cached responses
+func (c *Client) chatCompletionRaw(ctx context.Context, p *chatProvider, guardrail GuardrailHook, model string, messages []map[string]string, raw *[]byte) (map[string]interface{}, error) {
+	clock := c.config.stopwatch()
+	startTime := clock.Now()
+
+	span := c.tracer.startInternal(ctx, p.name+".chat.completion",
+		attribute.String("model", model),
+		attribute.String("provider", p.name),
+	)
+	ctx = c.withRequestTiming(ctx, span)
+
+	defer endProviderSpan(span, p, clock, startTime)
+
+	if guardrail != nil {
+		if err := checkGuardrail(ctx, guardrail, model, messages, span); err != nil {
+			span.SetStatus(1, err.Error())
+			return nil, err
+		}
+	}
+
+	cacheKey := CacheKey(model, messages)
+	if response, ok := c.cachedCompletion(ctx, cacheKey, span); ok {
+		c.recordUsage(span, model, p.name, 0, 0)
+		span.SetStatus(0, "")
+		return response, nil
+	}
+
+	// Build request payload
+	requestBody := map[string]interface{}{
+		"model":    model,
+		"messages": messages,
+	}
+	for k, v := range p.extra {
+		requestBody[k] = v
+	}
+	response, body, err := c.providerPostRaw(ctx, p, "/chat/completions", requestBody)
+	if err != nil {
+		recordCallError(span, err)
+		return nil, err
+	}
+	if raw != nil {
+		*raw = body
+	}
+
+	usage := readChatUsage(response)
+	usage.setAttributes(span)
+	if p.record != nil {
+		p.record(span, response)
+	}
+	c.recordProviderUsage(span, p, model, usage.promptTokens, usage.cachedTokens, usage.completionTokens)
+
+	c.storeCompletion(ctx, cacheKey, response)
+
+	span.SetStatus(0, "")
+	return response, nil
+}
+
+// embeddings tracks an embeddings call to an OpenAI-compatible provider
+func (c *Client) embeddings(ctx context.Context, p *chatProvider, model string, input []string) (map[string]interface{}, error) {
+	clock := c.config.stopwatch()
+	startTime := clock.Now()
+
+	span := c.tracer.startInternal(ctx, p.name+".embeddings",
+		attribute.String("model", model),
+		attribute.String("provider", p.name),
+		attribute.Int("request.inputs", len(input)),
+	)
+	ctx = c.withRequestTiming(ctx, span)
+
+	defer endProviderSpan(span, p, clock, startTime)
+
+	response, err := c.providerPost(ctx, p, "/embeddings", map[string]interface{}{
+		"model": model,
+		"input": input,
+	})
+	if err != nil {
+		recordCallError(span, err)
+		return nil, err
+	}
+
+	usage := readChatUsage(response)
+	if usage.promptTokens == 0 {
+		// Some embedding APIs (e.g. Voyage) report only total_tokens
+		usage.promptTokens = usage.totalTokens
+	}
+	usage.setAttributes(span)
+	if p.record != nil {
+		p.record(span, response)
+	}
+	c.recordProviderUsage(span, p, model, usage.promptTokens, 0, 0)
+
+	span.SetStatus(0, "")
+	return response, nil
+}
+
+// endProviderSpan records the call's latency and ends its span
+func endProviderSpan(span *Span, p *chatProvider, clock Clock, startTime time.Time) {
+	elapsed := clock.Now().Sub(startTime)
+	span.SetAttributes(attribute.Int64("latency_ms", elapsed.Milliseconds()))
+	if p.finish != nil {
+		p.finish(span, elapsed)
+	}
+	span.End()
+}
+
+// recordProviderUsage records a call's usage, priced at the provider's own price when it sets one
+func (c *Client) recordProviderUsage(span *Span, p *chatProvider, model string, promptTokens, cachedTokens, completionTokens int) {
+	if p.price == nil {
+		c.recordCachedUsage(span, model, p.name, promptTokens, cachedTokens, completionTokens)
+		return
+	}
+	c.recordPricedUsage(span, model, p.name, promptTokens, completionTokens, p.price.cost(promptTokens, cachedTokens, completionTokens))
+}
+
+// chatUsage is the token usage reported with a chat completion
+type chatUsage struct {
+	present          bool
+	promptTokens     int
+	completionTokens int
+	totalTokens      int
+	// cachedTokens is the part of promptTokens served from the provider's prompt cache
+	cachedTokens    int
+	reasoningTokens int
+}
+
+// readChatUsage reads the usage of an OpenAI-style response, including the cache
+// and reasoning details OpenAI and DeepSeek report in their own fields
+func readChatUsage(response map[string]interface{}) chatUsage {
+	usage, ok := response["usage"].(map[string]interface{})
+	if !ok {
+		return chatUsage{}
+	}
+	u := chatUsage{present: true}
+	number := func(m map[string]interface{}, key string) int {
+		v, _ := m[key].(float64)
+		return int(v)
+	}
+	u.promptTokens = number(usage, "prompt_tokens")
+	u.completionTokens = number(usage, "completion_tokens")
+	u.totalTokens = number(usage, "total_tokens")
+	if details, ok := usage["prompt_tokens_details"].(map[string]interface{}); ok {
+		u.cachedTokens = number(details, "cached_tokens")
+	}
+	if hits := number(usage, "prompt_cache_hit_tokens"); hits > 0 {
+		u.cachedTokens = hits
+	}
+	if details, ok := usage["completion_tokens_details"].(map[string]interface{}); ok {
+		u.reasoningTokens = number(details, "reasoning_tokens")
+	}
+	return u
+}
+
+func (u chatUsage) setAttributes(span *Span) {
+	if !u.present {
+		return
+	}
+	span.SetAttributes(
+		attribute.Int("response.prompt_tokens", u.promptTokens),
+		attribute.Int("response.completion_tokens", u.completionTokens),
+	)
+	if u.totalTokens > 0 {
+		span.SetAttributes(attribute.Int("response.total_tokens", u.totalTokens))
+	}
+	if u.cachedTokens > 0 {
+		span.SetAttributes(attribute.Int("response.cached_tokens", u.cachedTokens))
+	}
+	if u.reasoningTokens > 0 {
+		span.SetAttributes(attribute.Int("response.reasoning_tokens", u.reasoningTokens))
+	}
+}
+
+// providerPost sends a JSON request to a provider and decodes the JSON response
+func (c *Client) providerPost(ctx context.Context, p *chatProvider, path string, requestBody interface{}) (map[string]interface{}, error) {
+	response, _, err := c.providerPostRaw(ctx, p, path, requestBody)
+	return response, err
+}
+
+// providerPostRaw is providerPost that also returns the response body as received
+func (c *Client) providerPostRaw(ctx context.Context, p *chatProvider, path string, requestBody interface{}) (map[string]interface{}, []byte, error) {
+	resp, err := c.providerSend(ctx, c.providerHTTP, p, path, requestBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, nil, err
+	}
+	return response, data, nil
+}
+
+// providerSend POSTs a JSON request to a provider, returning the response only when it succeeded
+func (c *Client) providerSend(ctx context.Context, httpClient *http.Client, p *chatProvider, path string, requestBody interface{}) (*http.Response, error) {
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, err
+	}
+	return c.providerRequest(ctx, httpClient, p, "POST", p.baseURL+path, "application/json", bytes.NewBuffer(jsonData))
+}
+
+// providerGet fetches a JSON resource from a provider; url may be absolute or a path under baseURL
+func (c *Client) providerGet(ctx context.Context, p *chatProvider, url string) (map[string]interface{}, error) {
+	if strings.HasPrefix(url, "/") {
+		url = p.baseURL + url
+	}
+	resp, err := c.providerRequest(ctx, c.providerHTTP, p, "GET", url, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// maxRateLimitWait caps how long a call retried under Config.RateLimitRetries
+// waits; a longer Retry-After fails the call instead
+const maxRateLimitWait = time.Minute
+
+// providerRequest sends a request to a provider, retrying calls answered with a
+// retryable status as Config.Retry directs, after the Retry-After delay or, without
+// one, the policy's backoff.
+func (c *Client) providerRequest(ctx context.Context, httpClient *http.Client, p *chatProvider, method, url, contentType string, body io.Reader) (*http.Response, error) {
+	var apiKey string
+	var err error
+	switch {
+	case p.token == nil:
+		apiKey, err = c.providerKey(p.keyEnv)
+	case c.mock != nil:
+		apiKey = "mock"
+	default:
+		apiKey, err = p.token(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	traced, recordTiming := traceRequest(ctx)
+	req, err := http.NewRequestWithContext(traced, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case apiKey == "":
+		// Self-hosted endpoints may not require authentication
+	case p.authHeader != "":
+		req.Header.Set(p.authHeader, apiKey)
+	case p.authScheme != "":
+		req.Header.Set("Authorization", p.authScheme+" "+apiKey)
+	default:
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for key, value := range p.headers {
+		req.Header.Set(key, value)
+	}
+
+	policy := c.config.retryPolicy()
+	resp, err := httpClient.Do(req)
+	recordTiming()
+	for attempt := 1; err == nil && attempt < policy.MaxAttempts && policy.retryable(resp.StatusCode); attempt++ {
+		wait := retryAfter(resp.Header, c.config.clock().Now())
+		// A streamed upload can't be sent again
+		if wait > policy.MaxDelay || (req.Body != nil && req.GetBody == nil) {
+			break
+		}
+		if wait == 0 {
+			wait = policy.backoff(attempt - 1)
+		}
+		closeBody(resp.Body)
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+		retry := req.Clone(ctx)
+		if req.GetBody != nil {
+			if retry.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		resp, err = httpClient.Do(retry)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		closeBody(resp.Body)
+		return nil, newAPIError(p.service, resp, c.config.clock().Now())
+	}
+	return resp, nil
+}
@@ -0,0 +1,194 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Message is one span, signal or usage record bound for a message bus
+type Message struct {
+	// Type is "span", "signal" or "usage"
+	Type string
+	// Key is the customer ID the record belongs to, empty when it has none, so
+	// buses that partition by key keep each customer's records in order
+	Key string
+	// Value is the record as one JSON object, in the same form as an NDJSON
+	// export line: a "type" field followed by the span, signal or usage fields
+	Value []byte
+	// ID identifies the record and stays the same when it is published again
+	// after a failure: the span's trace and span IDs, a signal's idempotency key,
+	// or an ID given to the signal or usage record when it was created. Buses
+	// deduplicate on it, so identical but distinct records are all kept.
+	ID string
+}
+
+// Publisher delivers telemetry to a message bus in place of the AgentBill API.
+// Publish should return only once the bus has accepted every message; on error
+// the spans among them are re-queued and retried with the next flush.
+type Publisher interface {
+	Publish(ctx context.Context, messages []Message) error
+}
+
+// redirected reports whether telemetry goes to Config.ExportWriter or
+// Config.Publisher rather than AgentBill
+func (t *Tracer) redirected() bool {
+	return t.config.ExportWriter != nil || t.config.Publisher != nil
+}
+
+// deliver hands records to the configured publisher or export writer
+func (t *Tracer) deliver(ctx context.Context, spans []json.RawMessage, signals []Signal, usage []UsageRecord) error {
+	if t.config.Publisher == nil {
+		return t.writeLines(spans, signals, usage)
+	}
+	messages, err := newMessages(spans, signals, usage)
+	if err != nil {
+		return err
+	}
+	if err := t.config.Publisher.Publish(ctx, messages); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	return nil
+}
+
+// redirectExporter hands spans to the export writer or publisher
+type redirectExporter struct {
+	tracer *Tracer
+}
+
+func (e redirectExporter) export(ctx context.Context, spans []json.RawMessage) (exportResponse, error) {
+	if err := e.tracer.deliver(ctx, spans, nil, nil); err != nil {
+		return exportResponse{}, err
+	}
+	return exportResponse{status: http.StatusOK}, nil
+}
+
+func newMessages(spans []json.RawMessage, signals []Signal, usage []UsageRecord) ([]Message, error) {
+	messages := make([]Message, 0, len(spans)+len(signals)+len(usage))
+	var buf bytes.Buffer
+	add := func(kind, key, id string, object []byte) error {
+		buf.Reset()
+		if err := writeNDJSONLine(&buf, kind, object); err != nil {
+			return err
+		}
+		if id == "" {
+			id = uuid.New().String()
+		}
+		value := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		messages = append(messages, Message{Type: kind, Key: key, Value: append([]byte(nil), value...), ID: id})
+		return nil
+	}
+
+	for _, span := range spans {
+		customerID, id := spanMessageFields(span)
+		if err := add("span", customerID, id, span); err != nil {
+			return nil, err
+		}
+	}
+	for _, signal := range signals {
+		data, err := json.Marshal(signal)
+		if err != nil {
+			return nil, err
+		}
+		if err := add("signal", signal.CustomerID, signal.messageID, data); err != nil {
+			return nil, err
+		}
+	}
+	for _, record := range usage {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		if err := add("usage", record.CustomerID, record.messageID, data); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+// spanMessageFields reads the customer.id attribute of an encoded span and
+// builds its message ID from its trace and span IDs
+func spanMessageFields(span json.RawMessage) (customerID, id string) {
+	var decoded struct {
+		TraceID    string         `json:"traceId"`
+		SpanID     string         `json:"spanId"`
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	if json.Unmarshal(span, &decoded) != nil {
+		return "", ""
+	}
+	if decoded.SpanID != "" {
+		id = decoded.TraceID + "-" + decoded.SpanID
+	}
+	for _, kv := range decoded.Attributes {
+		if kv.Key == "customer.id" && kv.Value.StringValue != nil {
+			return *kv.Value.StringValue, id
+		}
+	}
+	return "", id
+}
+
+// signalMessageID identifies a signal by its idempotency key, so a signal
+// tracked again under the same key is deduplicated, or otherwise uniquely
+func signalMessageID(signal Signal) string {
+	if signal.IdempotencyKey == "" {
+		return uuid.New().String()
+	}
+	sum := sha256.Sum256([]byte(signalDedupKey(signal)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Forward sends message values taken off a bus to AgentBill in one bulk request,
+// for the process that drains what a Publisher wrote. Acknowledge the messages
+// only once Forward succeeds so the bus redelivers them otherwise.
+func (c *Client) Forward(ctx context.Context, values [][]byte) error {
+	var spans []json.RawMessage
+	var signals []Signal
+	var usage []UsageRecord
+	for _, value := range values {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(value, &fields); err != nil {
+			return err
+		}
+		var kind string
+		json.Unmarshal(fields["type"], &kind)
+		switch kind {
+		case "span":
+			delete(fields, "type")
+			span, err := json.Marshal(fields)
+			if err != nil {
+				return err
+			}
+			spans = append(spans, span)
+		case "signal":
+			var signal Signal
+			if err := json.Unmarshal(value, &signal); err != nil {
+				return err
+			}
+			signals = append(signals, signal)
+		case "usage":
+			var record UsageRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return err
+			}
+			usage = append(usage, record)
+		default:
+			return fmt.Errorf("forward: unknown message type %q", kind)
+		}
+	}
+
+	response, err := bulkExporter{tracer: c.tracer}.send(ctx, spans, signals, usage)
+	if err != nil {
+		return err
+	}
+	if response.status != http.StatusOK {
+		return response.apiError(c.config.clock().Now())
+	}
+	return nil
+}
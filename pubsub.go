@@ -0,0 +1,61 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+)
+
+// PubSubMessage is one message to publish to a Google Cloud Pub/Sub topic
+type PubSubMessage struct {
+	Data        []byte
+	Attributes  map[string]string
+	OrderingKey string
+}
+
+// PubSubTopic publishes messages and returns once Pub/Sub has accepted all of
+// them. Implement it with cloud.google.com/go/pubsub, with EnableMessageOrdering
+// set on the topic, e.g. by calling topic.Publish for each message and waiting on
+// every result's Get. On failure, call topic.ResumePublish for the affected
+// ordering keys before the next flush retries them.
+type PubSubTopic interface {
+	Publish(ctx context.Context, messages []PubSubMessage) error
+}
+
+// PubSubOptions configures a Pub/Sub publisher
+type PubSubOptions struct {
+	Topic PubSubTopic
+	// DisableOrdering leaves OrderingKey empty, for topics without message ordering
+	DisableOrdering bool
+}
+
+type pubSubPublisher struct {
+	opts PubSubOptions
+}
+
+// NewPubSubPublisher returns a Publisher that sends each record to a Pub/Sub
+// topic with the customer ID as ordering key, so subscribers with ordering enabled
+// receive each customer's records in the order they were recorded. Messages carry
+// "type" and "customer_id" attributes for subscription filters.
+func NewPubSubPublisher(opts PubSubOptions) (Publisher, error) {
+	if opts.Topic == nil {
+		return nil, errors.New("pubsub: Topic is required")
+	}
+	return pubSubPublisher{opts: opts}, nil
+}
+
+func (p pubSubPublisher) Publish(ctx context.Context, messages []Message) error {
+	batch := make([]PubSubMessage, len(messages))
+	for i, message := range messages {
+		batch[i] = PubSubMessage{
+			Data:       message.Value,
+			Attributes: map[string]string{"type": message.Type},
+		}
+		if message.Key != "" {
+			batch[i].Attributes["customer_id"] = message.Key
+		}
+		if !p.opts.DisableOrdering {
+			batch[i].OrderingKey = partitionKey(message)
+		}
+	}
+	return p.opts.Topic.Publish(ctx, batch)
+}
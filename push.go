@@ -0,0 +1,357 @@
+package agentbill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PushEventType names an event AgentBill pushes to subscribed clients
+type PushEventType string
+
+// Push event types
+const (
+	// PushBudgetExceeded is sent when a customer's spend passes a budget limit
+	PushBudgetExceeded PushEventType = "budget_exceeded"
+	// PushQuotaUpdated is sent when a customer's quota or remaining allowance changes
+	PushQuotaUpdated PushEventType = "quota_updated"
+	// PushKeyRevoked is sent when an API key is revoked
+	PushKeyRevoked PushEventType = "key_revoked"
+)
+
+// PushTransport selects how a subscription receives events
+type PushTransport int
+
+const (
+	// PushStream holds a server-sent event stream open
+	PushStream PushTransport = iota
+	// PushLongPoll repeats requests that the server holds open until an event
+	// arrives or PollInterval passes, for proxies that buffer or cut streams
+	PushLongPoll
+	// PushPoll asks for new events every PollInterval, for networks that allow
+	// neither streams nor long-held requests
+	PushPoll
+)
+
+const (
+	defaultPushReconnectDelay = time.Second
+	maxPushReconnectDelay     = 30 * time.Second
+	defaultPushPollInterval   = 30 * time.Second
+)
+
+// PushEvent is a budget, quota or key event pushed by AgentBill
+type PushEvent struct {
+	ID         string        `json:"id"`
+	Type       PushEventType `json:"type"`
+	CustomerID string        `json:"customer_id"`
+	Time       time.Time     `json:"time"`
+	// Limit and Used describe the budget or quota the event concerns, in Unit
+	// ("usd", "tokens" or "requests")
+	Limit      float64                `json:"limit,omitempty"`
+	Used       float64                `json:"used,omitempty"`
+	Unit       string                 `json:"unit,omitempty"`
+	KeyID      string                 `json:"key_id,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// PushHandler reacts to a push event. Handlers run one at a time, in delivery order,
+// so a slow handler delays later events.
+type PushHandler func(event PushEvent)
+
+// SubscribeOptions configures a push subscription
+type SubscribeOptions struct {
+	// CustomerID defaults to the client's customer
+	CustomerID string
+	// Types limits delivery to these event types; all types when empty
+	Types []PushEventType
+	// OnError is told about connection failures before each reconnect
+	OnError func(err error)
+
+	// Transport selects streaming (the default) or one of the polling fallbacks
+	Transport PushTransport
+	// PollInterval is how often PushPoll polls and how long PushLongPoll asks the
+	// server to hold each request (default 30s)
+	PollInterval time.Duration
+	// MaxStaleness bounds how long after last hearing from the server the
+	// subscription still counts as fresh (default twice PollInterval)
+	MaxStaleness time.Duration
+}
+
+// PushSubscription delivers server-pushed events to registered handlers, over a
+// server-sent event stream or by polling, resuming after the last event received
+// whenever a connection drops
+type PushSubscription struct {
+	client *Client
+	opts   SubscribeOptions
+
+	mu          sync.Mutex
+	handlers    map[PushEventType][]PushHandler
+	anyHandlers []PushHandler
+	lastEventID string
+	since       time.Time // lower bound for polls made before any event is received
+	lastSync    time.Time
+	streaming   bool
+}
+
+// Subscribe prepares a push subscription; register handlers with On and OnAny,
+// then call Run to connect
+func (c *Client) Subscribe(opts SubscribeOptions) *PushSubscription {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPushPollInterval
+	}
+	if opts.MaxStaleness <= 0 {
+		opts.MaxStaleness = 2 * opts.PollInterval
+	}
+	return &PushSubscription{
+		client:   c,
+		opts:     opts,
+		handlers: make(map[PushEventType][]PushHandler),
+		since:    c.config.clock().Now(),
+	}
+}
+
+// On registers handler for events of one type
+func (s *PushSubscription) On(eventType PushEventType, handler PushHandler) {
+	s.mu.Lock()
+	s.handlers[eventType] = append(s.handlers[eventType], handler)
+	s.mu.Unlock()
+}
+
+// OnAny registers handler for every event
+func (s *PushSubscription) OnAny(handler PushHandler) {
+	s.mu.Lock()
+	s.anyHandlers = append(s.anyHandlers, handler)
+	s.mu.Unlock()
+}
+
+// Fresh reports whether the subscription is connected or has heard from the server
+// within MaxStaleness, so enforcement can decide whether to trust what it has seen
+func (s *PushSubscription) Fresh() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.streaming {
+		return true
+	}
+	return !s.lastSync.IsZero() && s.client.config.clock().Now().Sub(s.lastSync) <= s.opts.MaxStaleness
+}
+
+// LastSync returns when the server was last heard from, or the zero time
+func (s *PushSubscription) LastSync() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSync
+}
+
+// Run receives events until ctx is canceled, reconnecting with exponential backoff.
+// It returns ctx's error, or an *APIError when the server rejects the subscription
+// as unauthorized, since retrying a revoked or invalid key cannot succeed.
+func (s *PushSubscription) Run(ctx context.Context) error {
+	backoff := defaultPushReconnectDelay
+	for {
+		var connected bool
+		var delay time.Duration
+		var err error
+		switch s.opts.Transport {
+		case PushLongPoll:
+			connected, err = s.poll(ctx, true)
+			// A server that answers at once instead of holding the request would otherwise be hammered
+			delay = defaultPushReconnectDelay
+		case PushPoll:
+			connected, err = s.poll(ctx, false)
+			delay = s.opts.PollInterval
+		default:
+			connected, delay, err = s.stream(ctx)
+			if delay == 0 {
+				delay = defaultPushReconnectDelay
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if apiErr, ok := err.(*APIError); ok && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+			return err
+		}
+		if err != nil && s.opts.OnError != nil {
+			s.opts.OnError(err)
+		}
+
+		if connected {
+			backoff = defaultPushReconnectDelay
+		} else {
+			delay = backoff
+			backoff *= 2
+			if backoff > maxPushReconnectDelay {
+				backoff = maxPushReconnectDelay
+			}
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// poll fetches the events after the last one received, asking the server to
+// hold the request for up to PollInterval when wait is set
+func (s *PushSubscription) poll(ctx context.Context, wait bool) (bool, error) {
+	params := s.params()
+	s.mu.Lock()
+	if s.lastEventID != "" {
+		params.Set("after", s.lastEventID)
+	} else {
+		params.Set("since", s.since.UTC().Format(time.RFC3339Nano))
+	}
+	s.mu.Unlock()
+
+	httpClient := s.client.apiHTTP
+	if wait {
+		seconds := int(s.opts.PollInterval / time.Second)
+		if seconds < 1 {
+			seconds = 1
+		}
+		params.Set("wait", strconv.Itoa(seconds))
+		httpClient = &http.Client{Transport: s.client.apiHTTP.Transport, Timeout: s.opts.PollInterval + s.client.apiHTTP.Timeout}
+	}
+	url := fmt.Sprintf("%s/functions/v1/push-events?%s", s.client.config.BaseURL, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.client.config.APIKey))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, newAPIError("AgentBill", resp, s.client.config.clock().Now())
+	}
+	var response struct {
+		Events []PushEvent `json:"events"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return false, err
+	}
+
+	s.touch()
+	for _, event := range response.Events {
+		s.dispatch(event, event.ID)
+	}
+	return true, nil
+}
+
+func (s *PushSubscription) params() url.Values {
+	params := url.Values{}
+	s.client.setCustomer(params, s.opts.CustomerID)
+	if len(s.opts.Types) > 0 {
+		types := make([]string, len(s.opts.Types))
+		for i, t := range s.opts.Types {
+			types[i] = string(t)
+		}
+		params.Set("types", strings.Join(types, ","))
+	}
+	return params
+}
+
+// touch records that the server was heard from
+func (s *PushSubscription) touch() {
+	now := s.client.config.clock().Now()
+	s.mu.Lock()
+	s.lastSync = now
+	s.mu.Unlock()
+}
+
+func (s *PushSubscription) setStreaming(streaming bool) {
+	s.mu.Lock()
+	s.streaming = streaming
+	s.mu.Unlock()
+}
+
+// stream holds one connection open, reporting whether it was established and
+// the reconnect delay the server asked for
+func (s *PushSubscription) stream(ctx context.Context) (connected bool, retry time.Duration, err error) {
+	url := fmt.Sprintf("%s/functions/v1/push-events?%s", s.client.config.BaseURL, s.params().Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.client.config.APIKey))
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	s.mu.Lock()
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+	s.mu.Unlock()
+
+	// The stream stays open indefinitely, so the pooled client's timeout can't apply
+	streamHTTP := &http.Client{Transport: s.client.apiHTTP.Transport}
+	resp, err := streamHTTP.Do(req)
+	if err != nil {
+		return false, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, 0, newAPIError("AgentBill", resp, s.client.config.clock().Now())
+	}
+	s.touch()
+	s.setStreaming(true)
+	defer func() {
+		s.touch()
+		s.setStreaming(false)
+	}()
+
+	err = readSSE(resp.Body, func(event sseEvent) error {
+		if event.Retry > 0 {
+			retry = event.Retry
+		}
+		s.touch()
+		if event.Data == "" {
+			return nil
+		}
+		var push PushEvent
+		if json.Unmarshal([]byte(event.Data), &push) != nil {
+			return nil
+		}
+		if push.Type == "" {
+			push.Type = PushEventType(event.Event)
+		}
+		if push.ID == "" {
+			push.ID = event.ID
+		}
+		s.dispatch(push, event.ID)
+		return nil
+	})
+	if err == nil {
+		err = fmt.Errorf("push event stream closed by server")
+	}
+	return true, retry, err
+}
+
+func (s *PushSubscription) dispatch(event PushEvent, streamID string) {
+	s.mu.Lock()
+	if streamID != "" {
+		s.lastEventID = streamID
+	}
+	handlers := append(append([]PushHandler(nil), s.handlers[event.Type]...), s.anyHandlers...)
+	s.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
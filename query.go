@@ -0,0 +1,109 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Event is a span, signal or usage record as stored by AgentBill
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"` // "span", "signal" or "usage"
+	Name       string                 `json:"name"`
+	CustomerID string                 `json:"customer_id"`
+	TraceID    string                 `json:"trace_id,omitempty"`
+	Time       time.Time              `json:"time"`
+	Model      string                 `json:"model,omitempty"`
+	CostUSD    float64                `json:"cost_usd,omitempty"`
+	Revenue    float64                `json:"revenue,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// EventQuery selects recent events
+type EventQuery struct {
+	// CustomerID defaults to the client's customer
+	CustomerID string
+	// Since returns events recorded after this time
+	Since time.Time
+	// Limit caps the number of events returned (server default when 0)
+	Limit int
+}
+
+// RecentEvents returns events recorded since q.Since, oldest first
+func (c *Client) RecentEvents(ctx context.Context, q EventQuery) ([]Event, error) {
+	params := url.Values{}
+	c.setCustomer(params, q.CustomerID)
+	if !q.Since.IsZero() {
+		params.Set("since", q.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+
+	var response struct {
+		Events []Event `json:"events"`
+	}
+	if err := c.getJSON(ctx, "recent-events", params, &response); err != nil {
+		return nil, err
+	}
+	return response.Events, nil
+}
+
+func (c *Client) setCustomer(params url.Values, customerID string) {
+	if customerID = c.customerID(customerID); customerID != "" {
+		params.Set("customer_id", customerID)
+	}
+}
+
+// getJSON queries a read-only AgentBill endpoint and decodes the JSON response into out
+func (c *Client) getJSON(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	return c.callJSON(ctx, "GET", endpoint, params, nil, out)
+}
+
+// callJSON sends body, when not nil, as JSON to an AgentBill endpoint and decodes
+// the response into out, when not nil
+func (c *Client) callJSON(ctx context.Context, method, endpoint string, params url.Values, body, out interface{}) error {
+	url := fmt.Sprintf("%s/functions/v1/%s", c.config.BaseURL, endpoint)
+	if len(params) > 0 {
+		url += "?" + params.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.apiHTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError("AgentBill", resp, c.config.clock().Now())
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,82 @@
+package agentbill
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QuotaUnit is what a plan's included allowance is measured in
+type QuotaUnit string
+
+// Quota units
+const (
+	QuotaTokens   QuotaUnit = "tokens"
+	QuotaRequests QuotaUnit = "requests"
+	QuotaCredits  QuotaUnit = "credits"
+)
+
+// Quota is a customer's included allowance of one unit for the current period
+type Quota struct {
+	CustomerID string    `json:"customer_id"`
+	Unit       QuotaUnit `json:"unit"`
+	Included   float64   `json:"included"`
+	Used       float64   `json:"used"`
+	ResetsAt   time.Time `json:"resets_at"`
+}
+
+// Remaining is the allowance left this period, never below zero
+func (q Quota) Remaining() float64 {
+	return math.Max(q.Included-q.Used, 0)
+}
+
+// String formats the quota for display, e.g. "12,000 of 100,000 tokens left"
+func (q Quota) String() string {
+	return fmt.Sprintf("%s of %s %s left", formatQuantity(q.Remaining()), formatQuantity(q.Included), q.Unit)
+}
+
+// RemainingQuota returns a customer's included allowances for the current
+// period, one per unit the plan includes. customerID defaults to the client's customer.
+func (c *Client) RemainingQuota(ctx context.Context, customerID string) ([]Quota, error) {
+	params := url.Values{}
+	c.setCustomer(params, customerID)
+
+	var response struct {
+		Quotas []Quota `json:"quotas"`
+	}
+	if err := c.getJSON(ctx, "quota-remaining", params, &response); err != nil {
+		return nil, err
+	}
+	return response.Quotas, nil
+}
+
+// formatQuantity formats n with thousands separators, keeping two decimals
+// only when n is fractional, e.g. 12000 as "12,000" and 12.5 as "12.50"
+func formatQuantity(n float64) string {
+	decimals := 0
+	if n != math.Trunc(n) {
+		decimals = 2
+	}
+	s := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	whole, fraction, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	if n < 0 {
+		b.WriteByte('-')
+	}
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteByte('.')
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
@@ -0,0 +1,299 @@
+package agentbill
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+const defaultRealtimeUsageInterval = time.Minute
+
+// RealtimeOptions configures metering of a Realtime API session
+type RealtimeOptions struct {
+	// UsageInterval is how often a "realtime_usage" signal reports the usage
+	// accumulated since the previous one (default 1 minute; negative disables)
+	UsageInterval time.Duration
+}
+
+// RealtimeSession meters one OpenAI Realtime API (WebSocket) session. Feed it
+// every message your WebSocket client sends and receives; a span covering the
+// whole session is recorded by End.
+type RealtimeSession struct {
+	wrapper *OpenAIWrapper
+	ctx     context.Context
+	model   string
+	started time.Time
+	stop    chan struct{}
+	done    sync.WaitGroup
+
+	mu           sync.Mutex
+	ended        bool
+	sessionID    string
+	inputFormat  string
+	outputFormat string
+	total        realtimeUsage
+	reported     realtimeUsage
+}
+
+type realtimeUsage struct {
+	responses          int
+	inputTextTokens    int
+	inputAudioTokens   int
+	outputTextTokens   int
+	outputAudioTokens  int
+	inputAudioSeconds  float64
+	outputAudioSeconds float64
+}
+
+func (u realtimeUsage) inputTokens() int  { return u.inputTextTokens + u.inputAudioTokens }
+func (u realtimeUsage) outputTokens() int { return u.outputTextTokens + u.outputAudioTokens }
+
+func (u realtimeUsage) sub(v realtimeUsage) realtimeUsage {
+	return realtimeUsage{
+		responses:          u.responses - v.responses,
+		inputTextTokens:    u.inputTextTokens - v.inputTextTokens,
+		inputAudioTokens:   u.inputAudioTokens - v.inputAudioTokens,
+		outputTextTokens:   u.outputTextTokens - v.outputTextTokens,
+		outputAudioTokens:  u.outputAudioTokens - v.outputAudioTokens,
+		inputAudioSeconds:  u.inputAudioSeconds - v.inputAudioSeconds,
+		outputAudioSeconds: u.outputAudioSeconds - v.outputAudioSeconds,
+	}
+}
+
+// StartRealtimeSession begins metering a Realtime session for model
+func (w *OpenAIWrapper) StartRealtimeSession(ctx context.Context, model string, opts RealtimeOptions) *RealtimeSession {
+	// The span is created when the session ends, so it joins a trace chosen now
+	tc := TraceContextFromContext(ctx)
+	if tc.TraceID == "" {
+		tc.TraceID = w.client.config.idGenerator().NewTraceID()
+		ctx = WithTraceContext(ctx, tc)
+	}
+
+	s := &RealtimeSession{
+		wrapper:      w,
+		ctx:          ctx,
+		model:        model,
+		started:      w.client.config.clock().Now(),
+		stop:         make(chan struct{}),
+		inputFormat:  "pcm16",
+		outputFormat: "pcm16",
+	}
+
+	interval := opts.UsageInterval
+	if interval == 0 {
+		interval = defaultRealtimeUsageInterval
+	}
+	if interval > 0 {
+		s.done.Add(1)
+		go s.reportPeriodically(interval)
+	}
+	return s
+}
+
+// ObserveClientEvent records a message sent to the Realtime API
+func (s *RealtimeSession) ObserveClientEvent(message []byte) {
+	var event struct {
+		Type    string          `json:"type"`
+		Audio   string          `json:"audio"`
+		Session json.RawMessage `json:"session"`
+	}
+	if json.Unmarshal(message, &event) != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch event.Type {
+	case "input_audio_buffer.append":
+		s.total.inputAudioSeconds += audioSeconds(event.Audio, s.inputFormat)
+	case "session.update":
+		s.applySession(event.Session)
+	}
+}
+
+// ObserveServerEvent records a message received from the Realtime API
+func (s *RealtimeSession) ObserveServerEvent(message []byte) {
+	var event struct {
+		Type     string          `json:"type"`
+		Delta    string          `json:"delta"`
+		Session  json.RawMessage `json:"session"`
+		Response struct {
+			Usage struct {
+				InputTokenDetails struct {
+					TextTokens  int `json:"text_tokens"`
+					AudioTokens int `json:"audio_tokens"`
+				} `json:"input_token_details"`
+				OutputTokenDetails struct {
+					TextTokens  int `json:"text_tokens"`
+					AudioTokens int `json:"audio_tokens"`
+				} `json:"output_token_details"`
+			} `json:"usage"`
+		} `json:"response"`
+	}
+	if json.Unmarshal(message, &event) != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch event.Type {
+	case "session.created", "session.updated":
+		s.applySession(event.Session)
+	case "response.audio.delta", "response.output_audio.delta":
+		s.total.outputAudioSeconds += audioSeconds(event.Delta, s.outputFormat)
+	case "response.done":
+		usage := event.Response.Usage
+		s.total.responses++
+		s.total.inputTextTokens += usage.InputTokenDetails.TextTokens
+		s.total.inputAudioTokens += usage.InputTokenDetails.AudioTokens
+		s.total.outputTextTokens += usage.OutputTokenDetails.TextTokens
+		s.total.outputAudioTokens += usage.OutputTokenDetails.AudioTokens
+	}
+}
+
+func (s *RealtimeSession) applySession(data json.RawMessage) {
+	var session struct {
+		ID                string `json:"id"`
+		InputAudioFormat  string `json:"input_audio_format"`
+		OutputAudioFormat string `json:"output_audio_format"`
+	}
+	if json.Unmarshal(data, &session) != nil {
+		return
+	}
+	if session.ID != "" {
+		s.sessionID = session.ID
+	}
+	if session.InputAudioFormat != "" {
+		s.inputFormat = session.InputAudioFormat
+	}
+	if session.OutputAudioFormat != "" {
+		s.outputFormat = session.OutputAudioFormat
+	}
+}
+
+// audioSeconds returns the duration of a base64 audio chunk. pcm16 is 24 kHz
+// 16-bit mono; the G.711 formats are 8 kHz with one byte per sample.
+func audioSeconds(encoded, format string) float64 {
+	if encoded == "" {
+		return 0
+	}
+	size := base64.StdEncoding.DecodedLen(len(encoded))
+	if n := len(encoded); n > 0 && encoded[n-1] == '=' {
+		size--
+		if n > 1 && encoded[n-2] == '=' {
+			size--
+		}
+	}
+	switch format {
+	case "g711_ulaw", "g711_alaw":
+		return float64(size) / 8000
+	default:
+		return float64(size) / (2 * 24000)
+	}
+}
+
+func (s *RealtimeSession) reportPeriodically(interval time.Duration) {
+	defer s.done.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.reportUsage()
+		}
+	}
+}
+
+// reportUsage sends the usage accumulated since the last report as a signal
+func (s *RealtimeSession) reportUsage() {
+	s.mu.Lock()
+	delta := s.total.sub(s.reported)
+	s.reported = s.total
+	sessionID := s.sessionID
+	s.mu.Unlock()
+
+	if delta == (realtimeUsage{}) {
+		return
+	}
+	client := s.wrapper.client
+	err := client.TrackSignal(s.ctx, Signal{
+		EventName: "realtime_usage",
+		TraceID:   TraceContextFromContext(s.ctx).TraceID,
+		Data: map[string]interface{}{
+			"model":                s.model,
+			"realtime.session_id":  sessionID,
+			"responses":            delta.responses,
+			"input_text_tokens":    delta.inputTextTokens,
+			"input_audio_tokens":   delta.inputAudioTokens,
+			"output_text_tokens":   delta.outputTextTokens,
+			"output_audio_tokens":  delta.outputAudioTokens,
+			"input_audio_seconds":  delta.inputAudioSeconds,
+			"output_audio_seconds": delta.outputAudioSeconds,
+			"cost_usd":             client.EstimateCost(s.model, delta.inputTokens(), delta.outputTokens()),
+		},
+	})
+	if err != nil {
+		// Put the usage back so the next report or the session span still counts it
+		s.mu.Lock()
+		s.reported = s.reported.sub(delta)
+		s.mu.Unlock()
+	}
+}
+
+// End stops periodic reporting and records the session span; later calls have no effect
+func (s *RealtimeSession) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	s.done.Wait()
+
+	s.mu.Lock()
+	total := s.total
+	sessionID := s.sessionID
+	s.mu.Unlock()
+
+	client := s.wrapper.client
+	span := client.tracer.startInternal(s.ctx, "openai.realtime.session",
+		attribute.String("model", s.model),
+		attribute.String("provider", "openai"),
+	)
+	// Backdate the span to the session start, keeping the monotonic reading
+	span.mu.Lock()
+	span.started = s.started
+	span.StartTime = s.started.UnixNano()
+	span.version++
+	span.mu.Unlock()
+
+	attrs := []attribute.KeyValue{
+		attribute.Int64("realtime.duration_ms", client.config.clock().Now().Sub(s.started).Milliseconds()),
+		attribute.Int("realtime.responses", total.responses),
+		attribute.Int("realtime.input_text_tokens", total.inputTextTokens),
+		attribute.Int("realtime.input_audio_tokens", total.inputAudioTokens),
+		attribute.Int("realtime.output_text_tokens", total.outputTextTokens),
+		attribute.Int("realtime.output_audio_tokens", total.outputAudioTokens),
+		attribute.Float64("realtime.input_audio_seconds", total.inputAudioSeconds),
+		attribute.Float64("realtime.output_audio_seconds", total.outputAudioSeconds),
+		attribute.Int("response.prompt_tokens", total.inputTokens()),
+		attribute.Int("response.completion_tokens", total.outputTokens()),
+		attribute.Int("response.total_tokens", total.inputTokens()+total.outputTokens()),
+	}
+	if sessionID != "" {
+		attrs = append(attrs, attribute.String("realtime.session_id", sessionID))
+	}
+	span.SetAttributes(attrs...)
+	client.recordUsage(span, s.model, "openai", total.inputTokens(), total.outputTokens())
+	span.SetStatus(0, "")
+	span.End()
+}
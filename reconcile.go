@@ -0,0 +1,124 @@
+package agentbill
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// ledgerRetention is how long per-minute local totals are kept for reconciliation
+const ledgerRetention = 48 * time.Hour
+
+// localLedger keeps per-minute totals of every provider call this process made,
+// sampled or not, so they can be checked against what the server received
+type localLedger struct {
+	mu      sync.Mutex
+	buckets map[int64]*CostTotals
+}
+
+func newLocalLedger() *localLedger {
+	return &localLedger{buckets: make(map[int64]*CostTotals)}
+}
+
+func (l *localLedger) add(minute int64, totals CostTotals) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	bucket, ok := l.buckets[minute]
+	if !ok {
+		bucket = &CostTotals{}
+		l.buckets[minute] = bucket
+		cutoff := minute - int64(ledgerRetention/time.Second)
+		for m := range l.buckets {
+			if m < cutoff {
+				delete(l.buckets, m)
+			}
+		}
+	}
+	bucket.CostUSD += totals.CostUSD
+	bucket.Requests += totals.Requests
+	bucket.PromptTokens += totals.PromptTokens
+	bucket.CompletionTokens += totals.CompletionTokens
+}
+
+// totals sums the minutes starting within [start, end)
+func (l *localLedger) totals(start, end time.Time) CostTotals {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var sum CostTotals
+	for minute, bucket := range l.buckets {
+		t := time.Unix(minute, 0)
+		if t.Before(start) || !t.Before(end) {
+			continue
+		}
+		sum.CostUSD += bucket.CostUSD
+		sum.Requests += bucket.Requests
+		sum.PromptTokens += bucket.PromptTokens
+		sum.CompletionTokens += bucket.CompletionTokens
+	}
+	return sum
+}
+
+// LocalTotals returns the requests, tokens and estimated cost of the provider
+// calls this client made in period, counted per minute and kept for 48 hours
+func (c *Client) LocalTotals(period Period) CostTotals {
+	end := period.End
+	if end.IsZero() {
+		end = c.config.clock().Now()
+	}
+	return c.ledger.totals(period.Start, end)
+}
+
+// Discrepancy is a total that differs between the client and the server
+type Discrepancy struct {
+	// Field is "requests", "prompt_tokens", "completion_tokens" or "cost_usd"
+	Field  string
+	Local  float64
+	Server float64
+}
+
+// Reconciliation compares local and server totals for a period
+type Reconciliation struct {
+	Period        Period
+	Local         CostTotals
+	Server        CostTotals
+	Discrepancies []Discrepancy
+}
+
+// OK reports whether the server's totals match the local ones
+func (r *Reconciliation) OK() bool {
+	return len(r.Discrepancies) == 0
+}
+
+// Reconcile compares what this client counted in period with what the server
+// recorded for the client's customer, to detect dropped telemetry. It assumes
+// this process is the customer's only source of calls; with several replicas,
+// sum their LocalTotals and call ReconcileTotals. Flush first, and choose a
+// period that ended a few minutes ago, so ingestion has caught up.
+func (c *Client) Reconcile(ctx context.Context, period Period) (*Reconciliation, error) {
+	if period.End.IsZero() {
+		period.End = c.config.clock().Now()
+	}
+	return c.ReconcileTotals(ctx, period, c.LocalTotals(period))
+}
+
+// ReconcileTotals compares local totals gathered by the caller with the server's
+// totals for period
+func (c *Client) ReconcileTotals(ctx context.Context, period Period, local CostTotals) (*Reconciliation, error) {
+	summary, err := c.GetCostSummary(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reconciliation{Period: period, Local: local, Server: summary.Total}
+	compare := func(field string, local, server float64, tolerance float64) {
+		if math.Abs(local-server) > tolerance {
+			r.Discrepancies = append(r.Discrepancies, Discrepancy{Field: field, Local: local, Server: server})
+		}
+	}
+	compare("requests", float64(local.Requests), float64(summary.Total.Requests), 0)
+	compare("prompt_tokens", float64(local.PromptTokens), float64(summary.Total.PromptTokens), 0)
+	compare("completion_tokens", float64(local.CompletionTokens), float64(summary.Total.CompletionTokens), 0)
+	// Costs are summed in floating point on both sides
+	compare("cost_usd", local.CostUSD, summary.Total.CostUSD, 1e-6*math.Max(1, local.CostUSD))
+	return r, nil
+}
@@ -0,0 +1,94 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Signal names of revenue reversals
+const (
+	SignalRefund     = "refund"
+	SignalCreditNote = "credit_note"
+)
+
+// Refund returns money paid for an earlier revenue signal
+type Refund struct {
+	// OriginalKey is the idempotency key of the signal being refunded
+	OriginalKey string
+	// Amount is the positive amount returned, excluding tax; Tax is the tax returned with it
+	Amount float64
+	Tax    float64
+	// Currency is the ISO 4217 code of Amount and Tax, USD when empty; give the
+	// refunded signal's currency so Config.FXRates converts it the same way
+	Currency string
+	Reason   string
+	// CustomerID defaults to the client's customer
+	CustomerID string
+	// IdempotencyKey identifies the refund; it defaults to one derived from
+	// OriginalKey, so set it when refunding a signal in several parts
+	IdempotencyKey string
+}
+
+// CreditNote reduces the amount owed for an earlier revenue signal without a
+// payment being returned, e.g. a service credit against an invoice
+type CreditNote struct {
+	// ID is the credit note's number, which keys the signal
+	ID string
+	// OriginalKey is the idempotency key of the signal being credited
+	OriginalKey string
+	// Amount is the positive amount credited, excluding tax; Tax is the tax credited with it
+	Amount float64
+	Tax    float64
+	// Currency is the ISO 4217 code of Amount and Tax, USD when empty
+	Currency string
+	Reason   string
+	// CustomerID defaults to the client's customer
+	CustomerID string
+}
+
+// TrackRefund records a refund as a refund signal with negative revenue and
+// tax, referencing the original signal in its original_idempotency_key data
+func (c *Client) TrackRefund(ctx context.Context, refund Refund) error {
+	key := refund.IdempotencyKey
+	if key == "" {
+		key = "refund:" + refund.OriginalKey
+	}
+	return c.trackReversal(ctx, SignalRefund, key, refund.OriginalKey, refund.CustomerID, refund.Reason, refund.Currency, refund.Amount, refund.Tax)
+}
+
+// TrackCreditNote records a credit note as a credit_note signal with negative
+// revenue and tax, referencing the original signal in its original_idempotency_key data
+func (c *Client) TrackCreditNote(ctx context.Context, note CreditNote) error {
+	if note.ID == "" {
+		return errors.New("credit note ID is required")
+	}
+	key := "credit_note:" + note.ID
+	return c.trackReversal(ctx, SignalCreditNote, key, note.OriginalKey, note.CustomerID, note.Reason, note.Currency, note.Amount, note.Tax)
+}
+
+func (c *Client) trackReversal(ctx context.Context, eventName, key, originalKey, customerID, reason, currency string, amount, tax float64) error {
+	if originalKey == "" {
+		return fmt.Errorf("%s: original idempotency key is required", eventName)
+	}
+	if amount < 0 || tax < 0 {
+		return fmt.Errorf("%s: amounts must be positive; they are recorded as negative revenue", eventName)
+	}
+	if amount == 0 && tax == 0 {
+		return fmt.Errorf("%s: amount is required", eventName)
+	}
+
+	data := map[string]interface{}{"original_idempotency_key": originalKey}
+	if reason != "" {
+		data["reason"] = reason
+	}
+	return c.TrackSignal(ctx, Signal{
+		EventName:      eventName,
+		Revenue:        -amount,
+		TaxAmount:      -tax,
+		Currency:       currency,
+		CustomerID:     customerID,
+		IdempotencyKey: key,
+		Data:           data,
+	})
+}
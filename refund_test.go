@@ -0,0 +1,48 @@
+package agentbill
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestReversalsConvertCurrency(t *testing.T) {
+	client := Init(Config{APIKey: "mock", CustomerID: "customer-123", Disabled: true,
+		FXRates: StaticFXRates{"JPY": 0.0067, "EUR": 1.08}})
+	ctx := context.Background()
+
+	if err := client.TrackRefund(ctx, Refund{OriginalKey: "order-1", Amount: 5000, Tax: 500, Currency: "jpy"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.TrackCreditNote(ctx, CreditNote{ID: "CN-1", OriginalKey: "order-2", Amount: 10, Currency: "EUR"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.TrackRefund(ctx, Refund{OriginalKey: "order-3", Amount: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	signals := client.Mock().Signals()
+	if len(signals) != 3 {
+		t.Fatalf("got %d signals, want 3", len(signals))
+	}
+	cases := []struct {
+		revenue, tax, original float64
+		originalCurrency       string
+	}{
+		{-33.5, -3.35, -5000, "JPY"},
+		{-10.8, 0, -10, "EUR"},
+		{-7, 0, 0, ""},
+	}
+	for i, want := range cases {
+		got := signals[i]
+		if math.Abs(got.Revenue-want.revenue) > 1e-9 || math.Abs(got.TaxAmount-want.tax) > 1e-9 {
+			t.Errorf("signal %d revenue %v tax %v, want %v and %v", i, got.Revenue, got.TaxAmount, want.revenue, want.tax)
+		}
+		if got.OriginalRevenue != want.original || got.OriginalCurrency != want.originalCurrency {
+			t.Errorf("signal %d original %v %s, want %v %s", i, got.OriginalRevenue, got.OriginalCurrency, want.original, want.originalCurrency)
+		}
+		if want.originalCurrency != "" && got.Currency != "USD" {
+			t.Errorf("signal %d currency = %s, want USD", i, got.Currency)
+		}
+	}
+}
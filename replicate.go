@@ -0,0 +1,160 @@
+package agentbill
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+const defaultReplicatePollInterval = time.Second
+
+var replicateProvider = &chatProvider{
+	name:    "replicate",
+	service: "Replicate",
+	baseURL: "https://api.replicate.com/v1",
+	keyEnv:  "REPLICATE_API_TOKEN",
+}
+
+// ReplicateHardwarePrices is the USD price per second of Replicate's public
+// hardware, by the SKU shown on each model's page
+var ReplicateHardwarePrices = map[string]float64{
+	"cpu":            0.000100,
+	"gpu-t4":         0.000225,
+	"gpu-l40s":       0.000975,
+	"gpu-a40-small":  0.000575,
+	"gpu-a40-large":  0.000725,
+	"gpu-a100-large": 0.001400,
+	"gpu-h100":       0.001525,
+}
+
+// ReplicatePrediction is a prediction to run on Replicate
+type ReplicatePrediction struct {
+	// Model is "owner/name" for official models or "owner/name:version"
+	Model string
+	Input map[string]interface{}
+	// Hardware is the SKU the model runs on, e.g. "gpu-a40-large", used to price
+	// billed time with ReplicateHardwarePrices
+	Hardware string
+	// PricePerSecond overrides the hardware price, e.g. for private deployments
+	PricePerSecond float64
+}
+
+// ReplicateWrapper wraps Replicate prediction calls
+type ReplicateWrapper struct {
+	client       *Client
+	pollInterval time.Duration
+}
+
+// WrapReplicate wraps the Replicate API for tracking. The API token is read from REPLICATE_API_TOKEN.
+func (c *Client) WrapReplicate() *ReplicateWrapper {
+	return &ReplicateWrapper{client: c, pollInterval: defaultReplicatePollInterval}
+}
+
+// WithPollInterval returns a copy of the wrapper that checks running predictions every interval
+func (w *ReplicateWrapper) WithPollInterval(interval time.Duration) *ReplicateWrapper {
+	wrapped := *w
+	wrapped.pollInterval = interval
+	return &wrapped
+}
+
+// Run creates a prediction and polls until it succeeds, fails or is canceled,
+// returning the final prediction object. Replicate bills compute time rather
+// than tokens, so the span meters the prediction's predict_time in GPU seconds
+// (usage.unit "gpu_seconds") priced by the prediction's hardware. A failed or
+// canceled prediction is returned with an error and still metered.
+func (w *ReplicateWrapper) Run(ctx context.Context, prediction ReplicatePrediction) (map[string]interface{}, error) {
+	c := w.client
+	clock := c.config.stopwatch()
+	startTime := clock.Now()
+
+	path := "/models/" + prediction.Model + "/predictions"
+	requestBody := map[string]interface{}{"input": prediction.Input}
+	model, version, pinned := strings.Cut(prediction.Model, ":")
+	if pinned {
+		path = "/predictions"
+		requestBody["version"] = version
+	}
+
+	span := c.tracer.startInternal(ctx, "replicate.prediction",
+		attribute.String("model", model),
+		attribute.String("provider", replicateProvider.name),
+	)
+	ctx = c.withRequestTiming(ctx, span)
+	if pinned {
+		span.SetAttributes(attribute.String("replicate.version", version))
+	}
+	if prediction.Hardware != "" {
+		span.SetAttributes(attribute.String("replicate.hardware", prediction.Hardware))
+	}
+
+	defer func() {
+		latency := clock.Now().Sub(startTime).Milliseconds()
+		span.SetAttributes(attribute.Int64("latency_ms", latency))
+		span.End()
+	}()
+
+	response, err := c.providerPost(ctx, replicateProvider, path, requestBody)
+	if err != nil {
+		recordCallError(span, err)
+		return nil, err
+	}
+	if id, ok := response["id"].(string); ok {
+		span.SetAttributes(attribute.String("replicate.prediction_id", id))
+	}
+
+	for replicateRunning(response) {
+		timer := time.NewTimer(w.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			recordCallError(span, ctx.Err())
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		response, err = c.providerGet(ctx, replicateProvider, replicateGetURL(response))
+		if err != nil {
+			recordCallError(span, err)
+			return nil, err
+		}
+	}
+
+	status, _ := response["status"].(string)
+	if status != "" {
+		span.SetAttributes(attribute.String("replicate.status", status))
+	}
+	metrics, _ := response["metrics"].(map[string]interface{})
+	seconds, _ := metrics["predict_time"].(float64)
+	price := prediction.PricePerSecond
+	if price == 0 {
+		price = ReplicateHardwarePrices[prediction.Hardware]
+	}
+	c.recordMeteredUsage(span, model, replicateProvider.name, UnitGPUSeconds, seconds, seconds*price)
+
+	if status == "failed" || status == "canceled" {
+		message, _ := response["error"].(string)
+		err := fmt.Errorf("replicate: prediction %s: %s", status, message)
+		span.SetStatus(1, err.Error())
+		return response, err
+	}
+	span.SetStatus(0, "")
+	return response, nil
+}
+
+// replicateRunning reports whether a prediction has yet to reach a terminal status
+func replicateRunning(prediction map[string]interface{}) bool {
+	status, _ := prediction["status"].(string)
+	return status == "starting" || status == "processing"
+}
+
+func replicateGetURL(prediction map[string]interface{}) string {
+	urls, _ := prediction["urls"].(map[string]interface{})
+	if get, ok := urls["get"].(string); ok && get != "" {
+		return get
+	}
+	id, _ := prediction["id"].(string)
+	return "/predictions/" + id
+}
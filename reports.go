@@ -0,0 +1,464 @@
+package agentbill
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period is a reporting window from Start up to End. A zero End means now.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// LastPeriod returns the window of length d ending now
+func LastPeriod(d time.Duration) Period {
+	now := time.Now()
+	return Period{Start: now.Add(-d), End: now}
+}
+
+// MonthToDate returns the window from the start of the current UTC month until now
+func MonthToDate() Period {
+	now := time.Now().UTC()
+	return Period{Start: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (p Period) set(params url.Values) {
+	if !p.Start.IsZero() {
+		params.Set("start", p.Start.UTC().Format(time.RFC3339Nano))
+	}
+	if !p.End.IsZero() {
+		params.Set("end", p.End.UTC().Format(time.RFC3339Nano))
+	}
+}
+
+// Dimension names an attribute reports can break totals down by
+type Dimension string
+
+// Report dimensions
+const (
+	ByModel    Dimension = "model"
+	ByProvider Dimension = "provider"
+	ByCustomer Dimension = "customer"
+	// ByOrganization, ByProject and ByUser break a customer's totals down by
+	// the hierarchy set with WithOrganization, WithProject and WithUser
+	ByOrganization Dimension = "organization"
+	ByProject      Dimension = "project"
+	ByUser         Dimension = "user"
+)
+
+// ByTag breaks totals down by the value of a tag set with WithTags, e.g. ByTag("feature")
+func ByTag(key string) Dimension {
+	return Dimension("tag:" + key)
+}
+
+func setDimensions(params url.Values, dimensions []Dimension) {
+	if len(dimensions) == 0 {
+		return
+	}
+	names := make([]string, len(dimensions))
+	for i, d := range dimensions {
+		names[i] = string(d)
+	}
+	params.Set("group_by", strings.Join(names, ","))
+}
+
+// CostTotals are the amounts a report sums over a period
+type CostTotals struct {
+	CostUSD          float64 `json:"cost_usd"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+}
+
+// CostGroup is the totals for one combination of dimension values, keyed by dimension
+type CostGroup struct {
+	Keys map[Dimension]string `json:"keys"`
+	CostTotals
+}
+
+// CostSummary is the cost of a period, overall and broken down by the requested dimensions
+type CostSummary struct {
+	Start  time.Time   `json:"start"`
+	End    time.Time   `json:"end"`
+	Total  CostTotals  `json:"total"`
+	Groups []CostGroup `json:"groups"`
+}
+
+// GetCostSummary returns total cost for period, with a group for each combination
+// of values of the groupBy dimensions. It covers every customer the API key can
+// see unless the client has a CustomerID.
+func (c *Client) GetCostSummary(ctx context.Context, period Period, groupBy ...Dimension) (*CostSummary, error) {
+	params := url.Values{}
+	c.setCustomer(params, "")
+	period.set(params)
+	setDimensions(params, groupBy)
+
+	var summary CostSummary
+	if err := c.getJSON(ctx, "cost-summary", params, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// ModelUsage is one model's usage over a period
+type ModelUsage struct {
+	Model    string `json:"model"`
+	Provider string `json:"provider"`
+	// BaseModel is the model a fine-tuned model was trained from, empty otherwise
+	BaseModel string `json:"base_model,omitempty"`
+	CostTotals
+}
+
+// FineTuned reports whether the usage is of a fine-tuned model
+func (u ModelUsage) FineTuned() bool {
+	return u.BaseModel != ""
+}
+
+// GetModelUsage returns tokens, requests and cost per model over period, highest
+// cost first. Fine-tuned models are listed under their own IDs.
+func (c *Client) GetModelUsage(ctx context.Context, period Period) ([]ModelUsage, error) {
+	params := url.Values{}
+	c.setCustomer(params, "")
+	period.set(params)
+
+	var response struct {
+		Models []ModelUsage `json:"models"`
+	}
+	if err := c.getJSON(ctx, "model-usage", params, &response); err != nil {
+		return nil, err
+	}
+	for i := range response.Models {
+		if response.Models[i].BaseModel == "" {
+			response.Models[i].BaseModel = fineTunedBase(response.Models[i].Model)
+		}
+	}
+	return response.Models, nil
+}
+
+// fineTunedBase returns the base of an OpenAI fine-tuned model ID such as
+// ft:gpt-4o-mini-2024-07-18:acme::9abc, or "" for other IDs
+func fineTunedBase(model string) string {
+	rest, ok := strings.CutPrefix(model, "ft:")
+	if !ok {
+		return ""
+	}
+	base, _, _ := strings.Cut(rest, ":")
+	return base
+}
+
+// SpendForecast projects spend to the end of the current billing month
+type SpendForecast struct {
+	// CustomerID is empty for a forecast across all customers
+	CustomerID  string    `json:"customer_id,omitempty"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	// SpentUSD is spend so far this period
+	SpentUSD float64 `json:"spent_usd"`
+	// ProjectedUSD is the expected spend at PeriodEnd, likely between LowUSD and HighUSD
+	ProjectedUSD float64   `json:"projected_usd"`
+	LowUSD       float64   `json:"low_usd"`
+	HighUSD      float64   `json:"high_usd"`
+	AsOf         time.Time `json:"as_of"`
+}
+
+// GetSpendForecast returns the projected end-of-month spend of the client's
+// customer, or of all customers when the client has no CustomerID
+func (c *Client) GetSpendForecast(ctx context.Context) (*SpendForecast, error) {
+	return c.GetCustomerForecast(ctx, "")
+}
+
+// GetCustomerForecast returns one customer's projected end-of-month spend
+func (c *Client) GetCustomerForecast(ctx context.Context, customerID string) (*SpendForecast, error) {
+	params := url.Values{}
+	c.setCustomer(params, customerID)
+
+	var forecast SpendForecast
+	if err := c.getJSON(ctx, "spend-forecast", params, &forecast); err != nil {
+		return nil, err
+	}
+	return &forecast, nil
+}
+
+// ListCustomerForecasts returns the projected end-of-month spend of every
+// customer, highest first
+func (c *Client) ListCustomerForecasts(ctx context.Context) ([]SpendForecast, error) {
+	params := url.Values{"group_by": {string(ByCustomer)}}
+
+	var response struct {
+		Forecasts []SpendForecast `json:"forecasts"`
+	}
+	if err := c.getJSON(ctx, "spend-forecast", params, &response); err != nil {
+		return nil, err
+	}
+	return response.Forecasts, nil
+}
+
+// RankBy orders customers in a ranking
+type RankBy string
+
+// Ranking orders
+const (
+	RankByCost   RankBy = "cost"
+	RankByTokens RankBy = "tokens"
+)
+
+// TopCustomersQuery selects one page of a customer ranking
+type TopCustomersQuery struct {
+	Period Period
+	// By defaults to RankByCost
+	By RankBy
+	// Limit is the page size (server default when 0)
+	Limit int
+	// Cursor continues from a previous page's NextCursor
+	Cursor string
+}
+
+// CustomerRank is one customer's place in a ranking
+type CustomerRank struct {
+	Rank       int    `json:"rank"`
+	CustomerID string `json:"customer_id"`
+	CostTotals
+}
+
+// TopCustomersPage is one page of a customer ranking; NextCursor is empty on the last page
+type TopCustomersPage struct {
+	Customers  []CustomerRank `json:"customers"`
+	NextCursor string         `json:"next_cursor"`
+}
+
+// TopCustomers ranks customers by spend or tokens over a period, highest first
+func (c *Client) TopCustomers(ctx context.Context, q TopCustomersQuery) (*TopCustomersPage, error) {
+	if q.By == "" {
+		q.By = RankByCost
+	}
+	params := url.Values{"by": {string(q.By)}}
+	q.Period.set(params)
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Cursor != "" {
+		params.Set("cursor", q.Cursor)
+	}
+
+	var page TopCustomersPage
+	if err := c.getJSON(ctx, "top-customers", params, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// TagCost is the cost attributed to one combination of tag values. A tag the
+// calls did not carry has the value "".
+type TagCost struct {
+	Tags map[string]string
+	CostTotals
+}
+
+// AllocateCost splits the cost of a period across the values of the given tag
+// keys, for chargeback by feature, team, environment or any other tag
+func (c *Client) AllocateCost(ctx context.Context, period Period, keys ...string) ([]TagCost, error) {
+	dimensions := make([]Dimension, len(keys))
+	for i, key := range keys {
+		dimensions[i] = ByTag(key)
+	}
+	summary, err := c.GetCostSummary(ctx, period, dimensions...)
+	if err != nil {
+		return nil, err
+	}
+
+	costs := make([]TagCost, len(summary.Groups))
+	for i, group := range summary.Groups {
+		costs[i] = TagCost{Tags: make(map[string]string, len(keys)), CostTotals: group.CostTotals}
+		for j, key := range keys {
+			costs[i].Tags[key] = group.Keys[dimensions[j]]
+		}
+	}
+	return costs, nil
+}
+
+// Percentiles summarizes a duration distribution
+type Percentiles struct {
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+}
+
+// UnmarshalJSON reads percentiles reported in milliseconds
+func (p *Percentiles) UnmarshalJSON(data []byte) error {
+	var ms struct {
+		P50 float64 `json:"p50"`
+		P95 float64 `json:"p95"`
+		P99 float64 `json:"p99"`
+	}
+	if err := json.Unmarshal(data, &ms); err != nil {
+		return err
+	}
+	toDuration := func(v float64) time.Duration { return time.Duration(v * float64(time.Millisecond)) }
+	*p = Percentiles{P50: toDuration(ms.P50), P95: toDuration(ms.P95), P99: toDuration(ms.P99)}
+	return nil
+}
+
+// LatencyReport is the latency of one model and provider over a period
+type LatencyReport struct {
+	Model    string `json:"model"`
+	Provider string `json:"provider"`
+	Requests int64  `json:"requests"`
+	// Latency is the full call duration
+	Latency Percentiles `json:"latency_ms"`
+	// TTFT is time to first token over streamed calls; zero when none streamed
+	TTFT Percentiles `json:"ttft_ms"`
+}
+
+// GetLatency returns p50, p95 and p99 latency and time to first token per model
+// and provider over period
+func (c *Client) GetLatency(ctx context.Context, period Period) ([]LatencyReport, error) {
+	params := url.Values{}
+	c.setCustomer(params, "")
+	period.set(params)
+	setDimensions(params, []Dimension{ByModel, ByProvider})
+
+	var response struct {
+		Groups []LatencyReport `json:"groups"`
+	}
+	if err := c.getJSON(ctx, "latency-report", params, &response); err != nil {
+		return nil, err
+	}
+	return response.Groups, nil
+}
+
+// ErrorRateQuery selects provider error rates over time
+type ErrorRateQuery struct {
+	Period Period
+	// Step is the width of each point (default one hour)
+	Step time.Duration
+	// GroupBy defaults to ByModel; ByCustomer and ByProvider also apply
+	GroupBy []Dimension
+}
+
+// ErrorRatePoint counts calls and failures by cause in one step
+type ErrorRatePoint struct {
+	Time         time.Time `json:"time"`
+	Requests     int64     `json:"requests"`
+	RateLimited  int64     `json:"rate_limited"`  // 429 responses
+	ServerErrors int64     `json:"server_errors"` // 5xx responses
+	Timeouts     int64     `json:"timeouts"`
+	OtherErrors  int64     `json:"other_errors"`
+}
+
+// ErrorRate is the fraction of calls in the step that failed for any cause
+func (p ErrorRatePoint) ErrorRate() float64 {
+	if p.Requests == 0 {
+		return 0
+	}
+	return float64(p.RateLimited+p.ServerErrors+p.Timeouts+p.OtherErrors) / float64(p.Requests)
+}
+
+// ErrorRateSeries is the error counts of one group over time, keyed by dimension
+type ErrorRateSeries struct {
+	Keys   map[Dimension]string `json:"keys"`
+	Points []ErrorRatePoint     `json:"points"`
+}
+
+// GetErrorRates returns provider error counts per step, derived from the status
+// of provider call spans
+func (c *Client) GetErrorRates(ctx context.Context, q ErrorRateQuery) ([]ErrorRateSeries, error) {
+	if q.Step <= 0 {
+		q.Step = time.Hour
+	}
+	if len(q.GroupBy) == 0 {
+		q.GroupBy = []Dimension{ByModel}
+	}
+	params := url.Values{"step": {strconv.FormatInt(int64(q.Step/time.Second), 10)}}
+	c.setCustomer(params, "")
+	q.Period.set(params)
+	setDimensions(params, q.GroupBy)
+
+	var response struct {
+		Series []ErrorRateSeries `json:"series"`
+	}
+	if err := c.getJSON(ctx, "error-rates", params, &response); err != nil {
+		return nil, err
+	}
+	return response.Series, nil
+}
+
+// MarginTotals sets tracked revenue against LLM cost
+type MarginTotals struct {
+	RevenueUSD float64 `json:"revenue_usd"`
+	CostUSD    float64 `json:"cost_usd"`
+	// GrossMarginUSD is RevenueUSD less CostUSD
+	GrossMarginUSD float64 `json:"gross_margin_usd"`
+}
+
+// MarginRatio is gross margin as a fraction of revenue, or 0 without revenue
+func (m MarginTotals) MarginRatio() float64 {
+	if m.RevenueUSD == 0 {
+		return 0
+	}
+	return m.GrossMarginUSD / m.RevenueUSD
+}
+
+// MarginGroup is the margin of one combination of dimension values
+type MarginGroup struct {
+	Keys map[Dimension]string `json:"keys"`
+	MarginTotals
+}
+
+// MarginReport is gross margin over a period, overall and by the requested dimensions
+type MarginReport struct {
+	Start  time.Time     `json:"start"`
+	End    time.Time     `json:"end"`
+	Total  MarginTotals  `json:"total"`
+	Groups []MarginGroup `json:"groups"`
+}
+
+// GetMarginReport combines revenue from tracked signals with LLM cost to give
+// gross margin over period, e.g. per customer with ByCustomer or per feature
+// with ByTag("feature")
+func (c *Client) GetMarginReport(ctx context.Context, period Period, groupBy ...Dimension) (*MarginReport, error) {
+	params := url.Values{}
+	c.setCustomer(params, "")
+	period.set(params)
+	setDimensions(params, groupBy)
+
+	var report MarginReport
+	if err := c.getJSON(ctx, "margin-report", params, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// DailyDigest is the content of the daily usage digest email
+type DailyDigest struct {
+	Date  time.Time  `json:"date"`
+	Total CostTotals `json:"total"`
+	// CostChange is the change in cost from the previous day, as a fraction
+	CostChange   float64        `json:"cost_change"`
+	RevenueUSD   float64        `json:"revenue_usd"`
+	TopModels    []ModelUsage   `json:"top_models"`
+	TopCustomers []CustomerRank `json:"top_customers"`
+	// Events are the budget, quota and key events of the day
+	Events   []PushEvent    `json:"events"`
+	Forecast *SpendForecast `json:"forecast,omitempty"`
+}
+
+// GetDailyDigest returns the digest for the UTC day containing date, or for
+// yesterday when date is zero
+func (c *Client) GetDailyDigest(ctx context.Context, date time.Time) (*DailyDigest, error) {
+	if date.IsZero() {
+		date = c.config.clock().Now().UTC().AddDate(0, 0, -1)
+	}
+	params := url.Values{"date": {date.UTC().Format("2006-01-02")}}
+	c.setCustomer(params, "")
+
+	var digest DailyDigest
+	if err := c.getJSON(ctx, "daily-digest", params, &digest); err != nil {
+		return nil, err
+	}
+	return &digest, nil
+}
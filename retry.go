@@ -0,0 +1,105 @@
+package agentbill
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy controls how failed provider calls and span exports are retried.
+// The zero policy makes a single attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the number of tries including the first
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry (default 500ms), doubling with
+	// each retry up to MaxDelay (default 30s). A Retry-After the server sends
+	// replaces the backoff; one longer than MaxDelay ends the retries.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter spreads each backoff by up to this fraction of it either way, from
+	// 0 to 1, so clients that failed together do not retry in lockstep
+	Jitter float64
+	// RetryableStatus lists the statuses worth retrying (default 429, 502, 503 and 504).
+	// Exports are also retried after transport errors; provider calls are not,
+	// since the provider may already have processed them.
+	RetryableStatus []int
+}
+
+// retryPolicy returns Config.Retry with defaults filled in or, when it is unset,
+// the policy RateLimitRetries stands for
+func (c Config) retryPolicy() RetryPolicy {
+	policy := c.Retry
+	if policy.MaxAttempts <= 0 {
+		if c.RateLimitRetries <= 0 {
+			return RetryPolicy{MaxAttempts: 1}
+		}
+		return RetryPolicy{
+			MaxAttempts:     c.RateLimitRetries + 1,
+			BaseDelay:       time.Second,
+			MaxDelay:        maxRateLimitWait,
+			RetryableStatus: []int{http.StatusTooManyRequests},
+		}
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaultRetryBaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = defaultRetryMaxDelay
+	}
+	if policy.Jitter < 0 {
+		policy.Jitter = 0
+	} else if policy.Jitter > 1 {
+		policy.Jitter = 1
+	}
+	if len(policy.RetryableStatus) == 0 {
+		policy.RetryableStatus = []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+	return policy
+}
+
+func (p RetryPolicy) retryable(status int) bool {
+	for _, s := range p.RetryableStatus {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff is the wait before retry number retry, counting from 0
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// sleepContext waits for d, returning ctx's error if it ends first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
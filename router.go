@@ -0,0 +1,135 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// ChatFunc performs a chat completion against a single model
+type ChatFunc func(ctx context.Context, model string, messages []map[string]string) (map[string]interface{}, error)
+
+// RouterConfig configures model routing with fallback
+type RouterConfig struct {
+	// Models lists the primary model first, followed by fallbacks
+	Models []string
+	// CostAware orders fallbacks cheapest first using the client's pricing table
+	CostAware bool
+	// Chat performs each attempt; defaults to the OpenAI wrapper
+	Chat ChatFunc
+	// ShouldFallback decides whether an error moves on to the next model
+	ShouldFallback func(err error) bool
+}
+
+// Route describes which model served a routed request
+type Route struct {
+	Model          string
+	Attempts       int
+	FallbackReason string
+}
+
+// Router tries a primary model and falls back to alternatives on failure
+type Router struct {
+	client *Client
+	config RouterConfig
+	models []string
+}
+
+// NewRouter creates a router over the configured models
+func (c *Client) NewRouter(config RouterConfig) *Router {
+	if config.Chat == nil {
+		config.Chat = c.WrapOpenAI().ChatCompletion
+	}
+	if config.ShouldFallback == nil {
+		config.ShouldFallback = DefaultShouldFallback
+	}
+
+	models := append([]string(nil), config.Models...)
+	if config.CostAware && len(models) > 2 {
+		fallbacks := models[1:]
+		sort.SliceStable(fallbacks, func(i, j int) bool {
+			return c.routeCost(fallbacks[i]) < c.routeCost(fallbacks[j])
+		})
+	}
+
+	return &Router{client: c, config: config, models: models}
+}
+
+// ChatCompletion runs the request against each model in turn until one succeeds
+func (r *Router) ChatCompletion(ctx context.Context, messages []map[string]string) (map[string]interface{}, Route, error) {
+	if len(r.models) == 0 {
+		return nil, Route{}, fmt.Errorf("router has no models configured")
+	}
+
+	route := Route{}
+	var lastErr error
+	for i, model := range r.models {
+		route.Model = model
+		route.Attempts = i + 1
+
+		attrs := []attribute.KeyValue{
+			attribute.String("route.primary", r.models[0]),
+			attribute.String("route.model", model),
+			attribute.Int("route.attempt", i+1),
+		}
+		if route.FallbackReason != "" {
+			attrs = append(attrs, attribute.String("route.fallback_reason", route.FallbackReason))
+		}
+
+		response, err := r.config.Chat(withSpanAttributes(ctx, attrs...), model, messages)
+		if err == nil {
+			if r.client.config.Debug && i > 0 {
+				fmt.Printf("[AgentBill] Routed to fallback %s (%s)\n", model, route.FallbackReason)
+			}
+			return response, route, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil || !r.config.ShouldFallback(err) {
+			break
+		}
+		route.FallbackReason = fallbackReason(err)
+	}
+
+	return nil, route, lastErr
+}
+
+// DefaultShouldFallback falls back on rate limits, timeouts, missing models and server errors
+func DefaultShouldFallback(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == 404 || apiErr.StatusCode == 408 || apiErr.StatusCode == 429 || apiErr.StatusCode >= 500
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+func fallbackReason(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == 429:
+			return "rate_limited"
+		case apiErr.StatusCode == 404:
+			return "model_not_found"
+		case apiErr.StatusCode >= 500:
+			return "server_error"
+		}
+		return fmt.Sprintf("status_%d", apiErr.StatusCode)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	return "error"
+}
+
+// routeCost ranks a model by list price, placing unknown models last
+func (c *Client) routeCost(model string) float64 {
+	price, ok := c.PriceFor(model)
+	if !ok {
+		return 1e18
+	}
+	return price.InputPerMillion + price.OutputPerMillion
+}
@@ -0,0 +1,17 @@
+package agentbill
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// shouldSample decides by trace ID so every service keeps or drops a trace together
+func (t *Tracer) shouldSample(traceID string) bool {
+	rate := t.config.SampleRate
+	if rate <= 0 || rate >= 1 {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(traceID))
+	return float64(h.Sum64()) < rate*float64(math.MaxUint64)
+}
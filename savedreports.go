@@ -0,0 +1,66 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+)
+
+// ReportDefinition is a saved cost report
+type ReportDefinition struct {
+	ID         string      `json:"id,omitempty"`
+	Name       string      `json:"name"`
+	Dimensions []Dimension `json:"dimensions"`
+	// Filters limits the report to calls with these dimension values, e.g.
+	// {ByProvider: "openai", ByTag("team"): "growth"}
+	Filters map[Dimension]string `json:"filters,omitempty"`
+	// WindowDays is how many days before each run the report covers (default 30)
+	WindowDays int `json:"window_days,omitempty"`
+	// Schedule is a cron expression, in UTC, for delivering the report to
+	// Channels; empty runs it on demand only
+	Schedule  string         `json:"schedule,omitempty"`
+	Channels  []AlertChannel `json:"channels,omitempty"`
+	CreatedAt time.Time      `json:"created_at,omitempty"`
+}
+
+// CreateReport saves a report definition and returns it with its ID
+func (c *Client) CreateReport(ctx context.Context, report ReportDefinition) (*ReportDefinition, error) {
+	if report.Schedule != "" && len(report.Channels) == 0 {
+		return nil, errors.New("scheduled report needs at least one channel")
+	}
+	var created ReportDefinition
+	if err := c.callJSON(ctx, "POST", "saved-reports", nil, report, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ListReports returns the saved report definitions
+func (c *Client) ListReports(ctx context.Context) ([]ReportDefinition, error) {
+	var response struct {
+		Reports []ReportDefinition `json:"reports"`
+	}
+	if err := c.getJSON(ctx, "saved-reports", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Reports, nil
+}
+
+// DeleteReport deletes a saved report definition by ID
+func (c *Client) DeleteReport(ctx context.Context, id string) error {
+	return c.callJSON(ctx, "DELETE", "saved-reports", url.Values{"id": {id}}, nil, nil)
+}
+
+// RunReport executes a saved report over period, or over its own window when
+// period is zero
+func (c *Client) RunReport(ctx context.Context, id string, period Period) (*CostSummary, error) {
+	params := url.Values{"id": {id}}
+	period.set(params)
+
+	var summary CostSummary
+	if err := c.getJSON(ctx, "run-report", params, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
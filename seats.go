@@ -0,0 +1,65 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SignalSeatSnapshot is the signal name of seat count snapshots
+const SignalSeatSnapshot = "seat_snapshot"
+
+// SeatSnapshot is a customer's seat count at a point in time, recorded
+// alongside usage for plans that charge per seat plus usage
+type SeatSnapshot struct {
+	// CustomerID defaults to the client's customer
+	CustomerID string
+	// Seats is the number of billable seats
+	Seats int
+	// ActiveSeats is the number of seats used during the period; optional
+	ActiveSeats int
+	Plan        string
+	// PricePerSeat is the seat price for the period, recorded for reference;
+	// the snapshot itself carries no revenue
+	PricePerSeat float64
+	// Period labels the billing period the snapshot is for, e.g. "2025-03". When
+	// set, one snapshot per customer and period is recorded if SignalDedupWindow is set.
+	Period string
+	// AsOf defaults to now
+	AsOf time.Time
+}
+
+// TrackSeats records a seat_snapshot signal, so seat-based and usage-based
+// charges for a customer can be billed from the same SDK
+func (c *Client) TrackSeats(ctx context.Context, snapshot SeatSnapshot) error {
+	if snapshot.Seats < 0 || snapshot.ActiveSeats < 0 {
+		return errors.New("seat counts must not be negative")
+	}
+	if snapshot.AsOf.IsZero() {
+		snapshot.AsOf = c.config.clock().Now()
+	}
+
+	data := map[string]interface{}{
+		"seats": snapshot.Seats,
+		"as_of": snapshot.AsOf.Unix(),
+	}
+	if snapshot.ActiveSeats > 0 {
+		data["active_seats"] = snapshot.ActiveSeats
+	}
+	if snapshot.Plan != "" {
+		data["plan"] = snapshot.Plan
+	}
+	if snapshot.PricePerSeat > 0 {
+		data["price_per_seat"] = snapshot.PricePerSeat
+	}
+	signal := Signal{
+		EventName:  SignalSeatSnapshot,
+		CustomerID: snapshot.CustomerID,
+		Data:       data,
+	}
+	if snapshot.Period != "" {
+		data["period"] = snapshot.Period
+		signal.IdempotencyKey = "seats:" + snapshot.Period
+	}
+	return c.TrackSignal(ctx, signal)
+}
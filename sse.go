@@ -0,0 +1,78 @@
+package agentbill
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errStopEvents ends readSSE early without reporting an error
+var errStopEvents = errors.New("stop reading events")
+
+// sseEvent is one dispatched server-sent event
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// readSSE parses a text/event-stream body, calling dispatch for each event.
+// A final event not followed by a blank line is still dispatched at EOF.
+func readSSE(body io.Reader, dispatch func(sseEvent) error) error {
+	reader := bufio.NewReader(body)
+	var event sseEvent
+	var data []string
+	flush := func() error {
+		if len(data) == 0 && event.Event == "" && event.Retry == 0 {
+			return nil
+		}
+		event.Data = strings.Join(data, "\n")
+		err := dispatch(event)
+		event, data = sseEvent{ID: event.ID}, nil
+		return err
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" && err == nil {
+			if dispatchErr := flush(); dispatchErr != nil {
+				return stopped(dispatchErr)
+			}
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data = append(data, value)
+		case "event":
+			event.Event = value
+		case "id":
+			event.ID = value
+		case "retry":
+			if ms, convErr := strconv.Atoi(value); convErr == nil {
+				event.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+
+		if err == io.EOF {
+			return stopped(flush())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func stopped(err error) error {
+	if err == errStopEvents {
+		return nil
+	}
+	return err
+}
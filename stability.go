@@ -0,0 +1,44 @@
+package agentbill
+
+import "context"
+
+const defaultStabilitySteps = 30
+
+var stabilityProvider = &chatProvider{
+	name:    "stability",
+	service: "Stability AI",
+	baseURL: "https://api.stability.ai/v1",
+	keyEnv:  "STABILITY_API_KEY",
+	headers: map[string]string{"Accept": "application/json"},
+}
+
+// StabilityWrapper wraps Stability AI image generation calls
+type StabilityWrapper struct {
+	client *Client
+}
+
+// WrapStability wraps the Stability AI API for tracking. The API key is read from STABILITY_API_KEY.
+func (c *Client) WrapStability() *StabilityWrapper {
+	return &StabilityWrapper{client: c}
+}
+
+// TextToImage tracks a Stability text-to-image call to an engine such as
+// "stable-diffusion-xl-1024-v1-0". Its span matches OpenAIWrapper.GenerateImage's,
+// adding the step count, so image costs compare across providers.
+func (w *StabilityWrapper) TextToImage(ctx context.Context, r ImageRequest) (map[string]interface{}, error) {
+	r.setDefaults()
+	if r.Steps == 0 {
+		r.Steps = defaultStabilitySteps
+	}
+	body := map[string]interface{}{
+		"text_prompts": []map[string]interface{}{{"text": r.Prompt}},
+		"width":        r.Width,
+		"height":       r.Height,
+		"samples":      r.Count,
+		"steps":        r.Steps,
+	}
+	return w.client.generateImages(ctx, stabilityProvider, r, "/generation/"+r.Model+"/text-to-image", body, func(response map[string]interface{}) int {
+		artifacts, _ := response["artifacts"].([]interface{})
+		return len(artifacts)
+	})
+}
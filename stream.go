@@ -0,0 +1,294 @@
+package agentbill
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// StreamDelta is one incremental piece of a streamed chat completion
+type StreamDelta struct {
+	Index        int
+	Content      string
+	FinishReason string
+	// Reasoning is reasoning text streamed ahead of the answer, e.g. DeepSeek's reasoning_content
+	Reasoning string
+	// Elapsed is the time since the request was sent
+	Elapsed time.Duration
+}
+
+// StreamCallback receives each delta as it arrives; returning an error aborts the stream
+type StreamCallback func(delta StreamDelta) error
+
+// ChatCompletionStream streams an OpenAI chat completion, invoking onDelta for every
+// content delta. The span records time to first token, stream duration and output
+// tokens per second; the assembled response is returned in the non-streaming shape.
+func (w *OpenAIWrapper) ChatCompletionStream(ctx context.Context, model string, messages []map[string]string, onDelta StreamCallback) (map[string]interface{}, error) {
+	return w.client.chatCompletionStream(ctx, openAIProvider, w.guardrail, model, messages, onDelta)
+}
+
+// chatCompletionStream streams a chat completion from an OpenAI-compatible provider
+func (c *Client) chatCompletionStream(ctx context.Context, p *chatProvider, guardrail GuardrailHook, model string, messages []map[string]string, onDelta StreamCallback) (map[string]interface{}, error) {
+	clock := c.config.stopwatch()
+	startTime := clock.Now()
+
+	span := c.tracer.startInternal(ctx, p.name+".chat.completion",
+		attribute.String("model", model),
+		attribute.String("provider", p.name),
+		attribute.Bool("stream", true),
+	)
+	ctx = c.withRequestTiming(ctx, span)
+
+	defer endProviderSpan(span, p, clock, startTime)
+
+	if guardrail != nil {
+		if err := checkGuardrail(ctx, guardrail, model, messages, span); err != nil {
+			span.SetStatus(1, err.Error())
+			return nil, err
+		}
+	}
+
+	requestBody := map[string]interface{}{
+		"model":          model,
+		"messages":       messages,
+		"stream":         true,
+		"stream_options": map[string]interface{}{"include_usage": true},
+	}
+	for k, v := range p.extra {
+		requestBody[k] = v
+	}
+	// Streams outlive the pooled client's overall timeout; ctx bounds them instead
+	streamHTTP := &http.Client{Transport: c.providerHTTP.Transport}
+	resp, err := c.providerSend(ctx, streamHTTP, p, "/chat/completions", requestBody)
+	if err != nil {
+		recordCallError(span, err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	acc := &streamAccumulator{start: startTime, clock: clock, onDelta: onDelta}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		// Servers and mocks that ignore "stream" answer with a complete response
+		err = acc.readResponse(resp.Body)
+	} else {
+		err = acc.readEvents(resp.Body)
+	}
+	if err != nil {
+		recordCallError(span, err)
+		return nil, err
+	}
+
+	response := acc.response(model)
+	attrs := []attribute.KeyValue{
+		attribute.Int64("stream.duration_ms", acc.duration.Milliseconds()),
+		attribute.Int("stream.chunks", acc.chunks),
+	}
+	if acc.firstToken > 0 {
+		attrs = append(attrs, attribute.Int64("stream.ttft_ms", acc.firstToken.Milliseconds()))
+	}
+	if generation := (acc.duration - acc.firstToken).Seconds(); acc.completionTokens > 0 && generation > 0 {
+		attrs = append(attrs, attribute.Float64("stream.tokens_per_second", float64(acc.completionTokens)/generation))
+	}
+	usage := readChatUsage(map[string]interface{}{"usage": acc.usage})
+	if acc.usage != nil {
+		attrs = append(attrs,
+			attribute.Int("response.prompt_tokens", acc.promptTokens),
+			attribute.Int("response.completion_tokens", acc.completionTokens),
+			attribute.Int("response.total_tokens", acc.promptTokens+acc.completionTokens),
+		)
+		if usage.cachedTokens > 0 {
+			attrs = append(attrs, attribute.Int("response.cached_tokens", usage.cachedTokens))
+		}
+		if usage.reasoningTokens > 0 {
+			attrs = append(attrs, attribute.Int("response.reasoning_tokens", usage.reasoningTokens))
+		}
+	}
+	span.SetAttributes(attrs...)
+	if p.record != nil {
+		p.record(span, response)
+	}
+	c.recordProviderUsage(span, p, model, acc.promptTokens, usage.cachedTokens, acc.completionTokens)
+
+	span.SetStatus(0, "")
+	return response, nil
+}
+
+// streamMetadata are top-level chunk fields kept in the assembled response
+var streamMetadata = []string{"citations", "search_results", "perf_metrics"}
+
+// streamAccumulator assembles streamed chunks and times the stream
+type streamAccumulator struct {
+	start   time.Time
+	clock   Clock
+	onDelta StreamCallback
+
+	id         string
+	content    map[int]*strings.Builder
+	reasoning  map[int]*strings.Builder
+	finish     map[int]string
+	usage      map[string]interface{}
+	metadata   map[string]interface{}
+	chunks     int
+	firstToken time.Duration
+	duration   time.Duration
+
+	promptTokens     int
+	completionTokens int
+}
+
+func (a *streamAccumulator) readEvents(body io.Reader) error {
+	err := readSSE(body, func(event sseEvent) error {
+		data := strings.TrimSpace(event.Data)
+		if data == "[DONE]" {
+			return errStopEvents
+		}
+		var chunk map[string]interface{}
+		if json.Unmarshal([]byte(data), &chunk) != nil {
+			return nil
+		}
+		return a.addChunk(chunk)
+	})
+	if err != nil {
+		return err
+	}
+	a.duration = a.clock.Now().Sub(a.start)
+	return nil
+}
+
+func (a *streamAccumulator) readResponse(body io.Reader) error {
+	var response map[string]interface{}
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return err
+	}
+	// Present each choice as a single delta so callbacks still fire
+	choices, _ := response["choices"].([]interface{})
+	for _, c := range choices {
+		choice, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		message, _ := choice["message"].(map[string]interface{})
+		choice["delta"] = message
+		delete(choice, "message")
+	}
+	if err := a.addChunk(response); err != nil {
+		return err
+	}
+	a.duration = a.clock.Now().Sub(a.start)
+	return nil
+}
+
+func (a *streamAccumulator) addChunk(chunk map[string]interface{}) error {
+	a.chunks++
+	if id, ok := chunk["id"].(string); ok && a.id == "" {
+		a.id = id
+	}
+	for _, key := range streamMetadata {
+		if v, ok := chunk[key]; ok {
+			if a.metadata == nil {
+				a.metadata = make(map[string]interface{})
+			}
+			a.metadata[key] = v
+		}
+	}
+	if usage, ok := chunk["usage"].(map[string]interface{}); ok {
+		a.usage = usage
+		if v, ok := usage["prompt_tokens"].(float64); ok {
+			a.promptTokens = int(v)
+		}
+		if v, ok := usage["completion_tokens"].(float64); ok {
+			a.completionTokens = int(v)
+		}
+	}
+
+	choices, _ := chunk["choices"].([]interface{})
+	for _, c := range choices {
+		choice, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		index := 0
+		if v, ok := choice["index"].(float64); ok {
+			index = int(v)
+		}
+		delta, _ := choice["delta"].(map[string]interface{})
+		content, _ := delta["content"].(string)
+		reasoning, _ := delta["reasoning_content"].(string)
+		finishReason, _ := choice["finish_reason"].(string)
+		if content == "" && reasoning == "" && finishReason == "" {
+			continue
+		}
+
+		elapsed := a.clock.Now().Sub(a.start)
+		if content != "" && a.firstToken == 0 {
+			a.firstToken = elapsed
+		}
+		if a.content == nil {
+			a.content = make(map[int]*strings.Builder)
+			a.reasoning = make(map[int]*strings.Builder)
+			a.finish = make(map[int]string)
+		}
+		if a.content[index] == nil {
+			a.content[index] = &strings.Builder{}
+		}
+		a.content[index].WriteString(content)
+		if reasoning != "" {
+			if a.reasoning[index] == nil {
+				a.reasoning[index] = &strings.Builder{}
+			}
+			a.reasoning[index].WriteString(reasoning)
+		}
+		if finishReason != "" {
+			a.finish[index] = finishReason
+		}
+
+		if a.onDelta != nil {
+			if err := a.onDelta(StreamDelta{Index: index, Content: content, FinishReason: finishReason, Reasoning: reasoning, Elapsed: elapsed}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// response rebuilds the stream as a non-streaming chat completion
+func (a *streamAccumulator) response(model string) map[string]interface{} {
+	indexes := make([]int, 0, len(a.content))
+	for index := range a.content {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	choices := make([]interface{}, 0, len(indexes))
+	for _, index := range indexes {
+		message := map[string]interface{}{"role": "assistant", "content": a.content[index].String()}
+		if reasoning := a.reasoning[index]; reasoning != nil {
+			message["reasoning_content"] = reasoning.String()
+		}
+		choices = append(choices, map[string]interface{}{
+			"index":         float64(index),
+			"message":       message,
+			"finish_reason": a.finish[index],
+		})
+	}
+
+	response := map[string]interface{}{
+		"id":      a.id,
+		"object":  "chat.completion",
+		"model":   model,
+		"choices": choices,
+	}
+	if a.usage != nil {
+		response["usage"] = a.usage
+	}
+	for key, v := range a.metadata {
+		response[key] = v
+	}
+	return response
+}
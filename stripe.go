@@ -0,0 +1,270 @@
+package agentbill
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// stripeSignatureTolerance bounds the age of a webhook's signature timestamp
+	stripeSignatureTolerance = 5 * time.Minute
+	maxStripeWebhookBytes    = 1 << 20
+	// stripeCustomerMetadata is the metadata key that names the AgentBill customer
+	// of a Stripe object; without it the Stripe customer ID is used
+	stripeCustomerMetadata = "agentbill_customer_id"
+)
+
+// Stripe event types converted to revenue signals
+const (
+	StripeInvoicePaid     = "invoice.paid"
+	StripeChargeSucceeded = "charge.succeeded"
+)
+
+// stripeZeroDecimal lists currencies Stripe amounts in whole units rather than cents
+var stripeZeroDecimal = map[string]bool{
+	"bif": true, "clp": true, "djf": true, "gnf": true, "jpy": true, "kmf": true,
+	"krw": true, "mga": true, "pyg": true, "rwf": true, "ugx": true, "vnd": true,
+	"vuv": true, "xaf": true, "xof": true, "xpf": true,
+}
+
+// StripeEvent is a Stripe webhook event
+type StripeEvent struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Created  int64  `json:"created"`
+	Livemode bool   `json:"livemode"`
+	Data     struct {
+		Object map[string]interface{} `json:"object"`
+	} `json:"data"`
+}
+
+// StripeSignal converts an invoice.paid or charge.succeeded event to a revenue
+// signal named after the event type, keyed by the event ID so redelivered
+// events are deduplicated. It reports false for other events, and for charges
+// that pay an invoice, whose revenue the invoice.paid event records.
+//
+// An invoice's tax is split from its revenue into the signal's tax fields, with
+// the effective rate and the customer's address as the jurisdiction, and its
+// discounts are recorded with the coupon that gave them. Its period becomes the
+// signal's service period.
+//
+// The signal's customer is the agentbill_customer_id metadata of the invoice or
+// charge when set, else the Stripe customer ID.
+func StripeSignal(event StripeEvent) (Signal, bool) {
+	object := event.Data.Object
+	str := func(key string) string {
+		v, _ := object[key].(string)
+		return v
+	}
+
+	var amountKey string
+	switch event.Type {
+	case StripeInvoicePaid:
+		amountKey = "amount_paid"
+	case StripeChargeSucceeded:
+		if str("invoice") != "" {
+			return Signal{}, false
+		}
+		amountKey = "amount"
+	default:
+		return Signal{}, false
+	}
+
+	currency := strings.ToLower(str("currency"))
+	toUnits := func(amount float64) float64 {
+		if stripeZeroDecimal[currency] {
+			return amount
+		}
+		return amount / 100
+	}
+	amount, _ := object[amountKey].(float64)
+	tax := stripeInvoiceTax(object)
+
+	customer := str("customer")
+	data := map[string]interface{}{
+		"stripe.event_id": event.ID,
+		"currency":        currency,
+		"livemode":        event.Livemode,
+	}
+	if customer != "" {
+		data["stripe.customer"] = customer
+	}
+	if id := str("id"); id != "" {
+		data["stripe."+strings.SplitN(event.Type, ".", 2)[0]] = id
+	}
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		if id, _ := metadata[stripeCustomerMetadata].(string); id != "" {
+			customer = id
+		}
+	}
+
+	signal := Signal{
+		EventName:      event.Type,
+		Revenue:        toUnits(amount - tax),
+		Currency:       strings.ToUpper(currency),
+		CustomerID:     customer,
+		IdempotencyKey: "stripe:" + event.ID,
+		Data:           data,
+	}
+	start, _ := object["period_start"].(float64)
+	end, _ := object["period_end"].(float64)
+	if end > start {
+		signal.ServicePeriodStart = int64(start)
+		signal.ServicePeriodEnd = int64(end)
+	}
+	if discount := stripeInvoiceDiscount(object); discount > 0 {
+		signal.DiscountAmount = toUnits(discount)
+		if coupon, ok := stripeInvoiceCoupon(object); ok {
+			signal.CouponCode, _ = coupon["id"].(string)
+			signal.DiscountPercent, _ = coupon["percent_off"].(float64)
+		}
+	}
+	if tax > 0 {
+		signal.TaxAmount = toUnits(tax)
+		if amount > tax {
+			signal.TaxRate = tax / (amount - tax)
+		}
+		if address, ok := object["customer_address"].(map[string]interface{}); ok {
+			country, _ := address["country"].(string)
+			state, _ := address["state"].(string)
+			signal.TaxJurisdiction = country
+			if country != "" && state != "" {
+				signal.TaxJurisdiction += "-" + state
+			}
+		}
+	}
+	return signal, true
+}
+
+// stripeInvoiceTax is the tax included in an invoice's amount, from total_taxes
+// or, in older API versions, tax; zero for charges
+func stripeInvoiceTax(invoice map[string]interface{}) float64 {
+	if taxes, ok := invoice["total_taxes"].([]interface{}); ok {
+		var total float64
+		for _, t := range taxes {
+			tax, _ := t.(map[string]interface{})
+			amount, _ := tax["amount"].(float64)
+			total += amount
+		}
+		return total
+	}
+	tax, _ := invoice["tax"].(float64)
+	return tax
+}
+
+// stripeInvoiceDiscount is the total discount applied to an invoice; zero for charges
+func stripeInvoiceDiscount(invoice map[string]interface{}) float64 {
+	discounts, _ := invoice["total_discount_amounts"].([]interface{})
+	var total float64
+	for _, d := range discounts {
+		discount, _ := d.(map[string]interface{})
+		amount, _ := discount["amount"].(float64)
+		total += amount
+	}
+	return total
+}
+
+// stripeInvoiceCoupon is the coupon of an invoice's discount, or of its first
+// expanded discounts entry in API versions where discount is null
+func stripeInvoiceCoupon(invoice map[string]interface{}) (map[string]interface{}, bool) {
+	discount, _ := invoice["discount"].(map[string]interface{})
+	if discount == nil {
+		discounts, _ := invoice["discounts"].([]interface{})
+		if len(discounts) > 0 {
+			discount, _ = discounts[0].(map[string]interface{})
+		}
+	}
+	coupon, ok := discount["coupon"].(map[string]interface{})
+	return coupon, ok
+}
+
+// TrackStripeEvent records the revenue of an invoice.paid or charge.succeeded
+// event, see StripeSignal. Other events are ignored.
+func (c *Client) TrackStripeEvent(ctx context.Context, event StripeEvent) error {
+	signal, ok := StripeSignal(event)
+	if !ok {
+		return nil
+	}
+	return c.TrackSignal(ctx, signal)
+}
+
+// StripeWebhookHandler receives Stripe webhooks and records paid invoices and
+// charges as revenue signals. Requests must carry a Stripe-Signature made with
+// the endpoint's signing secret (whsec_...) within the last five minutes. A
+// failed send responds 500 so Stripe redelivers the event.
+func (c *Client) StripeWebhookHandler(secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		payload, err := io.ReadAll(io.LimitReader(r.Body, maxStripeWebhookBytes))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		now := c.config.clock().Now()
+		if err := verifyStripeSignature(payload, r.Header.Get("Stripe-Signature"), secret, now); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var event StripeEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := c.TrackStripeEvent(r.Context(), event); err != nil {
+			if c.config.Debug {
+				fmt.Printf("[AgentBill] Stripe event %s not recorded: %v\n", event.ID, err)
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// verifyStripeSignature checks a Stripe-Signature header, "t=<unix>,v1=<hex>",
+// whose v1 values are HMAC-SHA256 signatures of "<t>.<payload>"
+func verifyStripeSignature(payload []byte, header, secret string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return errors.New("stripe: malformed signature header")
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+		return errors.New("stripe: signature timestamp outside tolerance")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		if decoded, err := hex.DecodeString(signature); err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return errors.New("stripe: no matching signature")
+}
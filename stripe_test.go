@@ -0,0 +1,93 @@
+package agentbill
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signStripe(payload []byte, secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyStripeSignature(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1","type":"invoice.paid"}`)
+	now := time.Unix(1700000000, 0)
+	ts := now.Unix()
+	valid := signStripe(payload, secret, ts)
+	wrongSecret := signStripe(payload, "whsec_other", ts)
+
+	cases := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{"valid", fmt.Sprintf("t=%d,v1=%s", ts, valid), false},
+		{"valid with spaces and v0", fmt.Sprintf("t=%d, v0=deadbeef, v1=%s", ts, valid), false},
+		{"second of several v1", fmt.Sprintf("t=%d,v1=%s,v1=%s", ts, wrongSecret, valid), false},
+		{"first of several v1", fmt.Sprintf("t=%d,v1=%s,v1=not-hex", ts, valid), false},
+		{"wrong secret", fmt.Sprintf("t=%d,v1=%s", ts, wrongSecret), true},
+		{"tampered payload", fmt.Sprintf("t=%d,v1=%s", ts, signStripe([]byte(`{}`), secret, ts)), true},
+		{"signature for other timestamp", fmt.Sprintf("t=%d,v1=%s", ts, signStripe(payload, secret, ts-1)), true},
+		{"no v1", fmt.Sprintf("t=%d,v0=%s", ts, valid), true},
+		{"no timestamp", "v1=" + valid, true},
+		{"empty", "", true},
+		{"at tolerance in past", fmt.Sprintf("t=%d,v1=%s", ts-300, signStripe(payload, secret, ts-300)), false},
+		{"at tolerance in future", fmt.Sprintf("t=%d,v1=%s", ts+300, signStripe(payload, secret, ts+300)), false},
+		{"too old", fmt.Sprintf("t=%d,v1=%s", ts-301, signStripe(payload, secret, ts-301)), true},
+		{"too far in future", fmt.Sprintf("t=%d,v1=%s", ts+301, signStripe(payload, secret, ts+301)), true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyStripeSignature(payload, tc.header, secret, now)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("verifyStripeSignature() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestStripeSignalDiscount(t *testing.T) {
+	coupon := `{"coupon":{"id":"SPRING","percent_off":20}}`
+	cases := []struct {
+		name        string
+		discount    string
+		wantCoupon  string
+		wantPercent float64
+	}{
+		{"discount object", `"discount":` + coupon, "SPRING", 20},
+		{"discount null", `"discount":null`, "", 0},
+		{"discount absent", `"currency":"usd"`, "", 0},
+		{"expanded discounts", `"discount":null,"discounts":[` + coupon + `]`, "SPRING", 20},
+		{"discount ids", `"discount":null,"discounts":["di_123"]`, "", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var event StripeEvent
+			body := `{"id":"evt_1","type":"invoice.paid","data":{"object":{"id":"in_1","currency":"usd",` +
+				`"amount_paid":400,"total_discount_amounts":[{"amount":100}],` + tc.discount + `}}}`
+			if err := json.Unmarshal([]byte(body), &event); err != nil {
+				t.Fatal(err)
+			}
+			signal, ok := StripeSignal(event)
+			if !ok {
+				t.Fatal("StripeSignal ignored invoice.paid")
+			}
+			if signal.DiscountAmount != 1 {
+				t.Errorf("DiscountAmount = %v, want 1", signal.DiscountAmount)
+			}
+			if signal.CouponCode != tc.wantCoupon || signal.DiscountPercent != tc.wantPercent {
+				t.Errorf("coupon = %q %v%%, want %q %v%%", signal.CouponCode, signal.DiscountPercent, tc.wantCoupon, tc.wantPercent)
+			}
+		})
+	}
+}
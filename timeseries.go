@@ -0,0 +1,111 @@
+package agentbill
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metric names a quantity a time-series query can return
+type Metric string
+
+// Time-series metrics
+const (
+	MetricCost             Metric = "cost_usd"
+	MetricRequests         Metric = "requests"
+	MetricPromptTokens     Metric = "prompt_tokens"
+	MetricCompletionTokens Metric = "completion_tokens"
+	MetricErrors           Metric = "errors"
+	MetricLatencyP95       Metric = "latency_p95_ms"
+	MetricRevenue          Metric = "revenue_usd"
+)
+
+// TimeSeriesQuery selects one metric over time
+type TimeSeriesQuery struct {
+	Metric Metric
+	Period Period
+	// Step is the width of each point (default one hour)
+	Step time.Duration
+	// Filters limits the query to calls with these dimension values
+	Filters map[Dimension]string
+	// GroupBy returns one series per combination of these dimensions' values
+	GroupBy []Dimension
+}
+
+// TimeSeriesPoint is a metric's value over the step starting at Time
+type TimeSeriesPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// TimeSeries is one metric over time for one group, keyed by dimension
+type TimeSeries struct {
+	Metric Metric               `json:"metric"`
+	Keys   map[Dimension]string `json:"keys"`
+	Points []TimeSeriesPoint    `json:"points"`
+}
+
+// GrafanaTimeSeries is a series in the response format of Grafana's JSON
+// datasource: datapoints are [value, unix milliseconds] pairs
+type GrafanaTimeSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// Grafana converts the series for a Grafana JSON datasource /query response.
+// The target is the metric followed by the group's values in dimension order.
+func (s TimeSeries) Grafana() GrafanaTimeSeries {
+	dimensions := make([]string, 0, len(s.Keys))
+	for d := range s.Keys {
+		dimensions = append(dimensions, string(d))
+	}
+	sort.Strings(dimensions)
+	target := []string{string(s.Metric)}
+	for _, d := range dimensions {
+		target = append(target, s.Keys[Dimension(d)])
+	}
+
+	datapoints := make([][2]float64, len(s.Points))
+	for i, p := range s.Points {
+		datapoints[i] = [2]float64{p.Value, float64(p.Time.UnixMilli())}
+	}
+	return GrafanaTimeSeries{Target: strings.Join(target, " "), Datapoints: datapoints}
+}
+
+// QueryTimeSeries returns a metric per step over the query's period
+func (c *Client) QueryTimeSeries(ctx context.Context, q TimeSeriesQuery) ([]TimeSeries, error) {
+	if q.Step <= 0 {
+		q.Step = time.Hour
+	}
+	params := url.Values{
+		"metric": {string(q.Metric)},
+		"step":   {strconv.FormatInt(int64(q.Step/time.Second), 10)},
+	}
+	c.setCustomer(params, "")
+	q.Period.set(params)
+	setDimensions(params, q.GroupBy)
+	if len(q.Filters) > 0 {
+		filters, err := json.Marshal(q.Filters)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("filters", string(filters))
+	}
+
+	var response struct {
+		Series []TimeSeries `json:"series"`
+	}
+	if err := c.getJSON(ctx, "timeseries", params, &response); err != nil {
+		return nil, err
+	}
+	for i := range response.Series {
+		if response.Series[i].Metric == "" {
+			response.Series[i].Metric = q.Metric
+		}
+	}
+	return response.Series, nil
+}
@@ -0,0 +1,84 @@
+package agentbill
+
+import (
+	"context"
+	"io"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+// TranscriptionRequest is an audio transcription job. Set AudioURL for hosted
+// audio, or Audio with its ContentType to upload it.
+type TranscriptionRequest struct {
+	// Model is the provider's model tier, e.g. Deepgram's "nova-2" or AssemblyAI's "best"
+	Model       string
+	AudioURL    string
+	Audio       io.Reader
+	ContentType string
+}
+
+// transcriptionResult is what a provider reports about a finished job
+type transcriptionResult struct {
+	response map[string]interface{}
+	id       string
+	// model is the tier the provider ran, when it reports one
+	model   string
+	seconds float64
+}
+
+// trackTranscription tracks a transcription job run by transcribe. The span is
+// metered in audio minutes (usage.unit "audio_minutes") priced per minute for
+// the model tier (priced as "<provider>/<model>"); with signals set, each job also sends a transcription_usage
+// signal.
+func (c *Client) trackTranscription(ctx context.Context, p *chatProvider, r TranscriptionRequest, signals bool, transcribe func(ctx context.Context) (transcriptionResult, error)) (map[string]interface{}, error) {
+	clock := c.config.stopwatch()
+	startTime := clock.Now()
+
+	span := c.tracer.startInternal(ctx, p.name+".transcription",
+		attribute.String("model", r.Model),
+		attribute.String("provider", p.name),
+	)
+	ctx = c.withRequestTiming(ctx, span)
+
+	defer func() {
+		latency := clock.Now().Sub(startTime).Milliseconds()
+		span.SetAttributes(attribute.Int64("latency_ms", latency))
+		span.End()
+	}()
+
+	result, err := transcribe(ctx)
+	if err != nil {
+		recordCallError(span, err)
+		return result.response, err
+	}
+
+	model := r.Model
+	if result.model != "" {
+		model = result.model
+		span.SetAttributes(attribute.String("model", model))
+	}
+	if result.id != "" {
+		span.SetAttributes(attribute.String("transcription.id", result.id))
+	}
+	minutes := result.seconds / 60
+	cost := c.EstimateUnitCost(p.name+"/"+model, minutes)
+	span.SetAttributes(attribute.Float64("audio.seconds", result.seconds))
+	c.recordMeteredUsage(span, model, p.name, UnitAudioMinutes, minutes, cost)
+
+	if signals {
+		c.TrackSignal(ctx, Signal{
+			EventName: "transcription_usage",
+			TraceID:   span.TraceID,
+			Data: map[string]interface{}{
+				"model":            model,
+				"provider":         p.name,
+				"transcription.id": result.id,
+				"audio_minutes":    minutes,
+				"cost_usd":         cost,
+			},
+		})
+	}
+
+	span.SetStatus(0, "")
+	return result.response, nil
+}
@@ -0,0 +1,162 @@
+package agentbill
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// TransportPreset selects connection pool defaults suited to a deployment environment
+type TransportPreset string
+
+// Transport presets; explicit TransportConfig fields override the preset's values
+const (
+	// PresetDefault suits typical long-running services
+	PresetDefault TransportPreset = "default"
+	// PresetHighConcurrency keeps large pools for workers issuing many parallel calls
+	PresetHighConcurrency TransportPreset = "high-concurrency"
+	// PresetServerless keeps few, short-lived idle connections for functions that freeze between invocations
+	PresetServerless TransportPreset = "serverless"
+)
+
+// TransportConfig tunes the pooled HTTP connections shared by all calls
+type TransportConfig struct {
+	// Preset picks base settings; when empty, AGENTBILL_TRANSPORT_PRESET is consulted
+	Preset TransportPreset
+
+	// MaxIdleConns caps idle connections across all hosts (default 100)
+	MaxIdleConns int
+	// MaxIdleConnsPerHost caps idle connections kept per host (default 10)
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps concurrent connections per host, 0 meaning unlimited
+	MaxConnsPerHost int
+	// IdleConnTimeout closes idle connections after this long (default 90s)
+	IdleConnTimeout time.Duration
+	// KeepAlive is the TCP keep-alive probe interval (default 30s)
+	KeepAlive time.Duration
+	// DisableKeepAlives opens a new connection for every request
+	DisableKeepAlives bool
+	// DisableHTTP2 restricts connections to HTTP/1.1
+	DisableHTTP2 bool
+
+	// socket is the unix socket every connection dials, from a unix:// BaseURL
+	socket string
+}
+
+// unixSocketURL replaces a unix:// BaseURL in request URLs; the host is never resolved
+const unixSocketURL = "http://agentbill.sock"
+
+// useUnixSocket points AgentBill traffic at the socket named by a unix:// BaseURL,
+// e.g. unix:///var/run/agentbill/agent.sock for a sidecar agent
+func (c *Config) useUnixSocket() {
+	path, ok := strings.CutPrefix(c.BaseURL, "unix://")
+	if !ok {
+		return
+	}
+	c.BaseURL = unixSocketURL
+	c.Transport.socket = path
+}
+
+func presetTransportConfig(preset TransportPreset) TransportConfig {
+	switch preset {
+	case PresetHighConcurrency:
+		return TransportConfig{
+			MaxIdleConns:        1000,
+			MaxIdleConnsPerHost: 256,
+			IdleConnTimeout:     120 * time.Second,
+			KeepAlive:           30 * time.Second,
+		}
+	case PresetServerless:
+		return TransportConfig{
+			MaxIdleConns:        10,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     10 * time.Second,
+			KeepAlive:           15 * time.Second,
+		}
+	default:
+		return TransportConfig{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+			KeepAlive:           30 * time.Second,
+		}
+	}
+}
+
+// resolve overlays explicitly set fields on top of the preset
+func (c TransportConfig) resolve() TransportConfig {
+	preset := c.Preset
+	if preset == "" {
+		preset = TransportPreset(os.Getenv("AGENTBILL_TRANSPORT_PRESET"))
+	}
+	resolved := presetTransportConfig(preset)
+	resolved.Preset = preset
+
+	if c.MaxIdleConns > 0 {
+		resolved.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost > 0 {
+		resolved.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.MaxConnsPerHost > 0 {
+		resolved.MaxConnsPerHost = c.MaxConnsPerHost
+	}
+	if c.IdleConnTimeout > 0 {
+		resolved.IdleConnTimeout = c.IdleConnTimeout
+	}
+	if c.KeepAlive > 0 {
+		resolved.KeepAlive = c.KeepAlive
+	}
+	resolved.DisableKeepAlives = c.DisableKeepAlives
+	resolved.DisableHTTP2 = c.DisableHTTP2
+	resolved.socket = c.socket
+	return resolved
+}
+
+func newHTTPTransport(config TransportConfig) *http.Transport {
+	config = config.resolve()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: config.KeepAlive,
+	}
+	transport.DialContext = dialer.DialContext
+	if config.socket != "" {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", config.socket)
+		}
+	}
+	transport.MaxIdleConns = config.MaxIdleConns
+	transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = config.MaxConnsPerHost
+	transport.IdleConnTimeout = config.IdleConnTimeout
+	transport.DisableKeepAlives = config.DisableKeepAlives
+	if config.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return transport
+}
+
+func newHTTPClient(config TransportConfig, timeout time.Duration) *http.Client {
+	return &http.Client{Transport: newHTTPTransport(config), Timeout: timeout}
+}
+
+// maxDrainBytes bounds how much of an unread response body is discarded to keep
+// its connection; larger bodies are cheaper to abandon than to read
+const maxDrainBytes = 512 << 10
+
+// closeBody reads what remains of a response body, up to maxDrainBytes, and closes
+// it. The transport only returns a connection to the idle pool once its body has
+// been read to EOF.
+func closeBody(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, maxDrainBytes))
+	body.Close()
+}
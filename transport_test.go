@@ -0,0 +1,40 @@
+package agentbill
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestErrorResponsesKeepConnections(t *testing.T) {
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Larger than the transport reads on its own when a body is closed early
+		http.Error(w, strings.Repeat("unavailable ", 40000), http.StatusServiceUnavailable)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := Init(Config{APIKey: "test", BaseURL: server.URL, CustomerID: "customer-123"})
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := client.TrackSignal(ctx, Signal{EventName: "signup"}); err == nil {
+			t.Fatal("TrackSignal succeeded against a failing server")
+		}
+		if err := client.sendUsage(ctx, []UsageRecord{{CustomerID: "customer-123", Model: "gpt-4o"}}); err == nil {
+			t.Fatal("sendUsage succeeded against a failing server")
+		}
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("opened %d connections, want 1 reused for every request", n)
+	}
+}
@@ -0,0 +1,121 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Trial is a customer's free trial of a plan
+type Trial struct {
+	// CustomerID defaults to the client's customer
+	CustomerID string    `json:"customer_id"`
+	Plan       string    `json:"plan,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	// EndedAt is set once the trial is ended early or converted
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	Converted bool       `json:"converted,omitempty"`
+}
+
+// Active reports whether the trial covers the time at
+func (t Trial) Active(at time.Time) bool {
+	if t.EndedAt != nil && !at.Before(*t.EndedAt) {
+		return false
+	}
+	return !at.Before(t.StartedAt) && at.Before(t.EndsAt)
+}
+
+// trialTable holds the trials known to the client, by customer
+type trialTable struct {
+	mu     sync.RWMutex
+	trials map[string]Trial
+}
+
+func newTrialTable() *trialTable {
+	return &trialTable{trials: make(map[string]Trial)}
+}
+
+func (t *trialTable) set(trial Trial) {
+	t.mu.Lock()
+	t.trials[trial.CustomerID] = trial
+	t.mu.Unlock()
+}
+
+func (t *trialTable) get(customerID string) Trial {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.trials[customerID]
+}
+
+func (t *trialTable) active(customerID string, at time.Time) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	trial, ok := t.trials[customerID]
+	return ok && trial.Active(at)
+}
+
+// StartTrial starts a trial lasting until trial.EndsAt, from trial.StartedAt or
+// now. While it is active, usage the client records for the customer is marked
+// as trial usage (trial on usage records, billing.trial on spans), so trial
+// consumption is reported apart from billable usage. A client records usage for
+// Config.CustomerID only, so trials of other customers are stored with AgentBill
+// but mark no usage here; use a client configured for that customer.
+func (c *Client) StartTrial(ctx context.Context, trial Trial) (*Trial, error) {
+	trial.CustomerID = c.customerID(trial.CustomerID)
+	if trial.StartedAt.IsZero() {
+		trial.StartedAt = c.config.clock().Now()
+	}
+	if !trial.EndsAt.After(trial.StartedAt) {
+		return nil, errors.New("trial must end after it starts")
+	}
+	if err := c.callJSON(ctx, "POST", "trials", nil, trial, nil); err != nil {
+		return nil, err
+	}
+	c.trials.set(trial)
+	return &trial, nil
+}
+
+// EndTrial ends a customer's trial now, noting whether it converted to a paid
+// plan. customerID defaults to the client's customer.
+func (c *Client) EndTrial(ctx context.Context, customerID string, converted bool) (*Trial, error) {
+	customerID = c.customerID(customerID)
+	trial := c.trials.get(customerID)
+	trial.CustomerID = customerID
+	endedAt := c.config.clock().Now()
+	trial.EndedAt = &endedAt
+	trial.Converted = converted
+	body := map[string]interface{}{
+		"customer_id": trial.CustomerID,
+		"ended_at":    endedAt,
+		"converted":   converted,
+	}
+	// The response fills in the rest of the trial
+	if err := c.callJSON(ctx, "POST", "end-trial", nil, body, &trial); err != nil {
+		return nil, err
+	}
+	c.trials.set(trial)
+	return &trial, nil
+}
+
+// GetTrial returns a customer's current or most recent trial, or nil when it
+// has none, and starts marking the customer's usage while the trial is active.
+// Call it at startup for trials started by other services. customerID defaults
+// to the client's customer.
+func (c *Client) GetTrial(ctx context.Context, customerID string) (*Trial, error) {
+	params := url.Values{}
+	c.setCustomer(params, customerID)
+
+	var response struct {
+		Trial *Trial `json:"trial"`
+	}
+	if err := c.getJSON(ctx, "trials", params, &response); err != nil {
+		return nil, err
+	}
+	if response.Trial != nil {
+		c.trials.set(*response.Trial)
+	}
+	return response.Trial, nil
+}
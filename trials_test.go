@@ -0,0 +1,45 @@
+package agentbill
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrialActive(t *testing.T) {
+	start := time.Unix(1700000000, 0).UTC()
+	ended := start.Add(24 * time.Hour)
+	trial := Trial{CustomerID: "customer-123", StartedAt: start, EndsAt: start.Add(14 * 24 * time.Hour)}
+
+	data, err := json.Marshal(trial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "ended_at") {
+		t.Errorf("active trial encodes ended_at: %s", data)
+	}
+
+	cases := []struct {
+		name    string
+		endedAt *time.Time
+		at      time.Time
+		want    bool
+	}{
+		{"before start", nil, start.Add(-time.Second), false},
+		{"at start", nil, start, true},
+		{"before ends", nil, trial.EndsAt.Add(-time.Second), true},
+		{"at ends", nil, trial.EndsAt, false},
+		{"before ended early", &ended, ended.Add(-time.Second), true},
+		{"after ended early", &ended, ended, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			trial := trial
+			trial.EndedAt = tc.endedAt
+			if got := trial.Active(tc.at); got != tc.want {
+				t.Errorf("Active(%v) = %v, want %v", tc.at, got, tc.want)
+			}
+		})
+	}
+}
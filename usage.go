@@ -0,0 +1,223 @@
+package agentbill
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agentbill/agentbill-go/attribute"
+	"github.com/google/uuid"
+)
+
+// UsageRecord is the usage total for one customer, model and minute.
+// Records are additive: the same period may be reported more than once.
+type UsageRecord struct {
+	CustomerID       string  `json:"customer_id"`
+	Model            string  `json:"model"`
+	Provider         string  `json:"provider"`
+	PeriodStart      int64   `json:"period_start"`
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	// Trial marks usage during the customer's trial, reported apart from billable usage
+	Trial bool `json:"trial,omitempty"`
+
+	// messageID identifies the record to message buses across publish attempts
+	messageID string
+}
+
+type usageKey struct {
+	customerID string
+	model      string
+	provider   string
+	minute     int64
+	trial      bool
+}
+
+// usageAggregator sums usage for calls that aren't exported as individual spans
+type usageAggregator struct {
+	mu      sync.Mutex
+	records map[usageKey]*UsageRecord
+}
+
+func newUsageAggregator() *usageAggregator {
+	return &usageAggregator{records: make(map[usageKey]*UsageRecord)}
+}
+
+func (a *usageAggregator) add(record UsageRecord) {
+	key := usageKey{
+		customerID: record.CustomerID,
+		model:      record.Model,
+		provider:   record.Provider,
+		minute:     record.PeriodStart,
+		trial:      record.Trial,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	existing, ok := a.records[key]
+	if !ok {
+		a.records[key] = &record
+		return
+	}
+	// A record restored after a failed send no longer matches what was published
+	existing.messageID = ""
+	existing.Requests += record.Requests
+	existing.PromptTokens += record.PromptTokens
+	existing.CompletionTokens += record.CompletionTokens
+	existing.CostUSD += record.CostUSD
+}
+
+// drain removes and returns all records, oldest period first. Records get a
+// message ID here that they keep if they are restored unchanged.
+func (a *usageAggregator) drain() []UsageRecord {
+	a.mu.Lock()
+	records := make([]UsageRecord, 0, len(a.records))
+	for _, record := range a.records {
+		if record.messageID == "" {
+			record.messageID = uuid.New().String()
+		}
+		records = append(records, *record)
+	}
+	a.records = make(map[usageKey]*UsageRecord)
+	a.mu.Unlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].PeriodStart < records[j].PeriodStart
+	})
+	return records
+}
+
+// recordUsage counts a call in the local ledger and folds its usage into the
+// aggregates when its span isn't exported
+func (c *Client) recordUsage(span *Span, model, provider string, promptTokens, completionTokens int) {
+	c.recordCachedUsage(span, model, provider, promptTokens, 0, completionTokens)
+}
+
+// recordCachedUsage is recordUsage for a call whose prompt tokens include
+// cachedTokens read from the provider's prompt cache
+func (c *Client) recordCachedUsage(span *Span, model, provider string, promptTokens, cachedTokens, completionTokens int) {
+	cost := c.estimateCachedCost(model, promptTokens, cachedTokens, completionTokens)
+	c.recordPricedUsage(span, model, provider, promptTokens, completionTokens, cost)
+}
+
+// recordPricedUsage is recordUsage for a call already priced at costUSD
+func (c *Client) recordPricedUsage(span *Span, model, provider string, promptTokens, completionTokens int, costUSD float64) {
+	c.addUsage(span, UsageRecord{
+		CustomerID:       c.customerID(""),
+		Model:            canonicalizeModel(span, model),
+		Provider:         provider,
+		PeriodStart:      c.config.clock().Now().Truncate(time.Minute).Unix(),
+		Requests:         1,
+		PromptTokens:     int64(promptTokens),
+		CompletionTokens: int64(completionTokens),
+		CostUSD:          costUSD,
+	})
+}
+
+// recordMeteredUsage records a call billed in a unit other than tokens, such as
+// GPU seconds. The span carries the unit and quantity; the cost is counted like
+// a token-priced call's.
+func (c *Client) recordMeteredUsage(span *Span, model, provider string, unit BillingUnit, quantity, costUSD float64) {
+	model = canonicalizeModel(span, model)
+	span.SetAttributes(
+		attribute.String("usage.unit", string(unit)),
+		attribute.Float64("usage.quantity", quantity),
+		attribute.Float64("usage.cost_usd", costUSD),
+	)
+	c.addUsage(span, UsageRecord{
+		CustomerID:  c.customerID(""),
+		Model:       model,
+		Provider:    provider,
+		PeriodStart: c.config.clock().Now().Truncate(time.Minute).Unix(),
+		Requests:    1,
+		CostUSD:     costUSD,
+	})
+}
+
+// canonicalizeModel labels the span with the canonical model, keeping a cloud-specific ID as model.id
+func canonicalizeModel(span *Span, model string) string {
+	canonical := CanonicalModel(model)
+	if canonical != model {
+		span.SetAttributes(
+			attribute.String("model", canonical),
+			attribute.String("model.id", model),
+		)
+	}
+	return canonical
+}
+
+func (c *Client) addUsage(span *Span, record UsageRecord) {
+	if c.trials.active(record.CustomerID, c.config.clock().Now()) {
+		record.Trial = true
+		span.SetAttributes(attribute.Bool("billing.trial", true))
+	}
+	c.ledger.add(record.PeriodStart, CostTotals{
+		CostUSD:          record.CostUSD,
+		Requests:         record.Requests,
+		PromptTokens:     record.PromptTokens,
+		CompletionTokens: record.CompletionTokens,
+	})
+	if span.sampled {
+		return
+	}
+	c.usage.add(record)
+}
+
+// flushUsage sends aggregated usage records, restoring them if the send fails
+func (c *Client) flushUsage(ctx context.Context) error {
+	records := c.usage.drain()
+	if len(records) == 0 {
+		return nil
+	}
+
+	err := c.sendUsage(ctx, records)
+	if err != nil {
+		for _, record := range records {
+			c.usage.add(record)
+		}
+	}
+	return err
+}
+
+func (c *Client) sendUsage(ctx context.Context, records []UsageRecord) error {
+	if c.tracer.redirected() {
+		return c.tracer.deliver(ctx, nil, nil, records)
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/functions/v1/record-usage", c.config.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.APIKey))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.apiHTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return newAPIError("AgentBill", resp, c.config.clock().Now())
+	}
+
+	if c.config.Debug {
+		fmt.Printf("[AgentBill] Usage records flushed: %d\n", len(records))
+	}
+	return nil
+}
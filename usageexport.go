@@ -0,0 +1,131 @@
+package agentbill
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// UsageFormat is a file format for exported usage
+type UsageFormat string
+
+// Usage export formats
+const (
+	UsageCSV     UsageFormat = "csv"
+	UsageParquet UsageFormat = "parquet"
+)
+
+// UsageQuery selects historical usage records
+type UsageQuery struct {
+	// CustomerID defaults to the client's customer; all customers when neither is set
+	CustomerID string
+	Period     Period
+	// Model limits the export to one model
+	Model string
+	// PageSize is the number of records fetched per request (server default when 0)
+	PageSize int
+}
+
+// usageColumns are the columns of a usage export, in order
+var usageColumns = []parquetColumn{
+	{name: "customer_id", typ: parquetByteArray, converted: parquetUTF8},
+	{name: "model", typ: parquetByteArray, converted: parquetUTF8},
+	{name: "provider", typ: parquetByteArray, converted: parquetUTF8},
+	{name: "period_start", typ: parquetInt64, converted: parquetTimestampMillis},
+	{name: "requests", typ: parquetInt64, converted: parquetNoConversion},
+	{name: "prompt_tokens", typ: parquetInt64, converted: parquetNoConversion},
+	{name: "completion_tokens", typ: parquetInt64, converted: parquetNoConversion},
+	{name: "cost_usd", typ: parquetDouble, converted: parquetNoConversion},
+}
+
+// ExportUsage writes the per-minute usage records matching q to w as CSV or
+// Parquet, fetching them page by page so large ranges are never held in memory.
+// Each page becomes one Parquet row group.
+func (c *Client) ExportUsage(ctx context.Context, q UsageQuery, format UsageFormat, w io.Writer) error {
+	var writePage func([]UsageRecord) error
+	var finish func() error
+	switch format {
+	case UsageCSV:
+		out := csv.NewWriter(w)
+		header := make([]string, len(usageColumns))
+		for i, column := range usageColumns {
+			header[i] = column.name
+		}
+		if err := out.Write(header); err != nil {
+			return err
+		}
+		writePage = func(records []UsageRecord) error {
+			for _, r := range records {
+				out.Write([]string{
+					r.CustomerID,
+					r.Model,
+					r.Provider,
+					time.Unix(r.PeriodStart, 0).UTC().Format(time.RFC3339),
+					strconv.FormatInt(r.Requests, 10),
+					strconv.FormatInt(r.PromptTokens, 10),
+					strconv.FormatInt(r.CompletionTokens, 10),
+					strconv.FormatFloat(r.CostUSD, 'f', -1, 64),
+				})
+			}
+			out.Flush()
+			return out.Error()
+		}
+		finish = func() error { return nil }
+	case UsageParquet:
+		out := newParquetWriter(w, usageColumns)
+		writePage = func(records []UsageRecord) error {
+			return out.writeRowGroup(len(records), usageParquetPages(records))
+		}
+		finish = out.close
+	default:
+		return fmt.Errorf("unsupported usage export format %q", format)
+	}
+
+	params := url.Values{}
+	c.setCustomer(params, q.CustomerID)
+	q.Period.set(params)
+	if q.Model != "" {
+		params.Set("model", q.Model)
+	}
+	if q.PageSize > 0 {
+		params.Set("limit", strconv.Itoa(q.PageSize))
+	}
+	for {
+		var page struct {
+			Records    []UsageRecord `json:"records"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		if err := c.getJSON(ctx, "usage-records", params, &page); err != nil {
+			return err
+		}
+		if len(page.Records) > 0 {
+			if err := writePage(page.Records); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return finish()
+		}
+		params.Set("cursor", page.NextCursor)
+	}
+}
+
+// usageParquetPages encodes records column by column in usageColumns order
+func usageParquetPages(records []UsageRecord) [][]byte {
+	pages := make([][]byte, len(usageColumns))
+	for _, r := range records {
+		pages[0] = appendParquetString(pages[0], r.CustomerID)
+		pages[1] = appendParquetString(pages[1], r.Model)
+		pages[2] = appendParquetString(pages[2], r.Provider)
+		pages[3] = appendParquetInt64(pages[3], r.PeriodStart*1000)
+		pages[4] = appendParquetInt64(pages[4], r.Requests)
+		pages[5] = appendParquetInt64(pages[5], r.PromptTokens)
+		pages[6] = appendParquetInt64(pages[6], r.CompletionTokens)
+		pages[7] = appendParquetDouble(pages[7], r.CostUSD)
+	}
+	return pages
+}
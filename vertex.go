@@ -0,0 +1,255 @@
+package agentbill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+const defaultVertexRegion = "us-central1"
+
+// VertexOptions configures a Vertex AI wrapper
+type VertexOptions struct {
+	// Project defaults to GOOGLE_CLOUD_PROJECT, then the credentials file's project
+	Project string
+	// Region defaults to GOOGLE_CLOUD_REGION, then us-central1
+	Region string
+	// TokenSource supplies OAuth access tokens; by default they come from
+	// Application Default Credentials
+	TokenSource func(ctx context.Context) (string, error)
+}
+
+// VertexWrapper wraps Vertex AI calls to Gemini and partner models
+type VertexWrapper struct {
+	client    *Client
+	guardrail GuardrailHook
+	project   string
+	// publisher serves publisher model endpoints; openAI serves the OpenAI-compatible endpoint
+	publisher *chatProvider
+	openAI    *chatProvider
+}
+
+// WrapVertex wraps Vertex AI for tracking. Requests are authorized with
+// Application Default Credentials instead of an API key: the file named by
+// GOOGLE_APPLICATION_CREDENTIALS, gcloud's application-default login, or the
+// metadata server when running on Google Cloud.
+func (c *Client) WrapVertex(opts VertexOptions) *VertexWrapper {
+	token := opts.TokenSource
+	project := opts.Project
+	if project == "" {
+		project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if token == nil {
+		adc := &googleTokenSource{httpClient: c.providerHTTP, clock: c.config.clock()}
+		token = adc.Token
+		if project == "" {
+			project = adc.project()
+		}
+	}
+	region := opts.Region
+	if region == "" {
+		region = os.Getenv("GOOGLE_CLOUD_REGION")
+	}
+	if region == "" {
+		region = defaultVertexRegion
+	}
+
+	host := region + "-aiplatform.googleapis.com"
+	if region == "global" {
+		host = "aiplatform.googleapis.com"
+	}
+	base := fmt.Sprintf("https://%s/v1/projects/%s/locations/%s", host, project, region)
+	record := func(span *Span, response map[string]interface{}) {
+		span.SetAttributes(
+			attribute.String("gcp.project", project),
+			attribute.String("gcp.region", region),
+		)
+	}
+
+	w := &VertexWrapper{client: c, project: project}
+	w.publisher = &chatProvider{name: "vertex", service: "Vertex AI", baseURL: base, token: token, record: record}
+	w.openAI = &chatProvider{name: "vertex", service: "Vertex AI", baseURL: base + "/endpoints/openapi", token: token, record: record}
+	return w
+}
+
+// WithGuardrail returns a copy of the wrapper that runs hook before every call
+func (w *VertexWrapper) WithGuardrail(hook GuardrailHook) *VertexWrapper {
+	wrapped := *w
+	wrapped.guardrail = hook
+	return &wrapped
+}
+
+// GenerateContent tracks a Gemini generateContent call. Messages use the chat
+// roles of the other wrappers: "system" becomes the system instruction and
+// "assistant" the model role. The span records usageMetadata, including cached
+// and thinking tokens, with the GCP project and region.
+func (w *VertexWrapper) GenerateContent(ctx context.Context, model string, messages []map[string]string) (map[string]interface{}, error) {
+	path := "/publishers/google/models/" + model + ":generateContent"
+	return w.predict(ctx, "vertex.generate_content", model, messages, path, geminiRequest(messages), readGeminiUsage)
+}
+
+// ClaudeMessages tracks a call to an Anthropic Claude model on Vertex AI, e.g.
+// "claude-3-5-sonnet-v2@20241022". Usage is recorded under the Anthropic model
+// name (see CanonicalModel), with prompt cache reads priced at the cached rate.
+func (w *VertexWrapper) ClaudeMessages(ctx context.Context, model string, messages []map[string]string, maxTokens int) (map[string]interface{}, error) {
+	path := "/publishers/anthropic/models/" + model + ":rawPredict"
+	return w.predict(ctx, "vertex.claude.messages", model, messages, path, anthropicRequest(messages, maxTokens), readAnthropicUsage)
+}
+
+// predict tracks a call to a publisher model endpoint
+func (w *VertexWrapper) predict(ctx context.Context, name, model string, messages []map[string]string, path string, requestBody interface{}, readUsage func(map[string]interface{}) chatUsage) (map[string]interface{}, error) {
+	c := w.client
+	clock := c.config.stopwatch()
+	startTime := clock.Now()
+
+	span := c.tracer.startInternal(ctx, name,
+		attribute.String("model", model),
+		attribute.String("provider", w.publisher.name),
+	)
+	ctx = c.withRequestTiming(ctx, span)
+
+	defer func() {
+		latency := clock.Now().Sub(startTime).Milliseconds()
+		span.SetAttributes(attribute.Int64("latency_ms", latency))
+		span.End()
+	}()
+
+	if w.guardrail != nil {
+		if err := checkGuardrail(ctx, w.guardrail, model, messages, span); err != nil {
+			span.SetStatus(1, err.Error())
+			return nil, err
+		}
+	}
+	if w.project == "" {
+		err := errors.New("vertex: no project; set VertexOptions.Project or GOOGLE_CLOUD_PROJECT")
+		span.SetStatus(1, err.Error())
+		return nil, err
+	}
+
+	cacheKey := CacheKey(model, messages)
+	if response, ok := c.cachedCompletion(ctx, cacheKey, span); ok {
+		c.recordUsage(span, model, w.publisher.name, 0, 0)
+		span.SetStatus(0, "")
+		return response, nil
+	}
+
+	response, err := c.providerPost(ctx, w.publisher, path, requestBody)
+	if err != nil {
+		recordCallError(span, err)
+		return nil, err
+	}
+
+	usage := readUsage(response)
+	usage.setAttributes(span)
+	w.publisher.record(span, response)
+	c.recordCachedUsage(span, model, w.publisher.name, usage.promptTokens, usage.cachedTokens, usage.completionTokens)
+
+	c.storeCompletion(ctx, cacheKey, response)
+
+	span.SetStatus(0, "")
+	return response, nil
+}
+
+// ChatCompletion tracks a chat completion through Vertex AI's OpenAI-compatible
+// endpoint, which serves Gemini as "google/<model>" and partner models such as
+// "meta/llama-3.1-405b-instruct-maas"
+func (w *VertexWrapper) ChatCompletion(ctx context.Context, model string, messages []map[string]string) (map[string]interface{}, error) {
+	if w.project == "" {
+		return nil, errors.New("vertex: no project; set VertexOptions.Project or GOOGLE_CLOUD_PROJECT")
+	}
+	return w.client.chatCompletion(ctx, w.openAI, w.guardrail, model, messages)
+}
+
+// geminiRequest converts chat messages to a generateContent request body
+func geminiRequest(messages []map[string]string) map[string]interface{} {
+	var system []interface{}
+	contents := make([]interface{}, 0, len(messages))
+	for _, message := range messages {
+		part := map[string]interface{}{"text": message["content"]}
+		switch message["role"] {
+		case "system":
+			system = append(system, part)
+		case "assistant", "model":
+			contents = append(contents, map[string]interface{}{"role": "model", "parts": []interface{}{part}})
+		default:
+			contents = append(contents, map[string]interface{}{"role": "user", "parts": []interface{}{part}})
+		}
+	}
+	request := map[string]interface{}{"contents": contents}
+	if len(system) > 0 {
+		request["systemInstruction"] = map[string]interface{}{"parts": system}
+	}
+	return request
+}
+
+// readGeminiUsage reads a generateContent response's usageMetadata. Thinking
+// tokens are billed as output, so they count toward completion tokens.
+func readGeminiUsage(response map[string]interface{}) chatUsage {
+	metadata, ok := response["usageMetadata"].(map[string]interface{})
+	if !ok {
+		return chatUsage{}
+	}
+	number := func(key string) int {
+		v, _ := metadata[key].(float64)
+		return int(v)
+	}
+	u := chatUsage{
+		present:         true,
+		promptTokens:    number("promptTokenCount"),
+		totalTokens:     number("totalTokenCount"),
+		cachedTokens:    number("cachedContentTokenCount"),
+		reasoningTokens: number("thoughtsTokenCount"),
+	}
+	u.completionTokens = number("candidatesTokenCount") + u.reasoningTokens
+	return u
+}
+
+// anthropicRequest converts chat messages to an Anthropic Messages request body
+func anthropicRequest(messages []map[string]string, maxTokens int) map[string]interface{} {
+	var system []string
+	turns := make([]interface{}, 0, len(messages))
+	for _, message := range messages {
+		switch message["role"] {
+		case "system":
+			system = append(system, message["content"])
+		case "assistant":
+			turns = append(turns, map[string]interface{}{"role": "assistant", "content": message["content"]})
+		default:
+			turns = append(turns, map[string]interface{}{"role": "user", "content": message["content"]})
+		}
+	}
+	request := map[string]interface{}{
+		"anthropic_version": "vertex-2023-10-16",
+		"messages":          turns,
+		"max_tokens":        maxTokens,
+	}
+	if len(system) > 0 {
+		request["system"] = strings.Join(system, "\n\n")
+	}
+	return request
+}
+
+// readAnthropicUsage reads an Anthropic Messages response's usage. Anthropic
+// counts cache reads and writes apart from input_tokens; all three are prompt tokens.
+func readAnthropicUsage(response map[string]interface{}) chatUsage {
+	usage, ok := response["usage"].(map[string]interface{})
+	if !ok {
+		return chatUsage{}
+	}
+	number := func(key string) int {
+		v, _ := usage[key].(float64)
+		return int(v)
+	}
+	u := chatUsage{
+		present:          true,
+		cachedTokens:     number("cache_read_input_tokens"),
+		completionTokens: number("output_tokens"),
+	}
+	u.promptTokens = number("input_tokens") + u.cachedTokens + number("cache_creation_input_tokens")
+	u.totalTokens = u.promptTokens + u.completionTokens
+	return u
+}
@@ -0,0 +1,51 @@
+package agentbill
+
+import (
+	"context"
+
+	"github.com/agentbill/agentbill-go/attribute"
+)
+
+var xAIProvider = &chatProvider{
+	name:    "xai",
+	service: "xAI",
+	baseURL: "https://api.x.ai/v1",
+	keyEnv:  "XAI_API_KEY",
+	record:  recordXAI,
+}
+
+// XAIWrapper wraps xAI (Grok) chat calls
+type XAIWrapper struct {
+	client    *Client
+	guardrail GuardrailHook
+}
+
+// WrapXAI wraps the xAI API for tracking. The API key is read from XAI_API_KEY.
+func (c *Client) WrapXAI() *XAIWrapper {
+	return &XAIWrapper{client: c}
+}
+
+// WithGuardrail returns a copy of the wrapper that runs hook before every call
+func (w *XAIWrapper) WithGuardrail(hook GuardrailHook) *XAIWrapper {
+	wrapped := *w
+	wrapped.guardrail = hook
+	return &wrapped
+}
+
+// ChatCompletion tracks an xAI chat completion call
+func (w *XAIWrapper) ChatCompletion(ctx context.Context, model string, messages []map[string]string) (map[string]interface{}, error) {
+	return w.client.chatCompletion(ctx, xAIProvider, w.guardrail, model, messages)
+}
+
+// ChatCompletionStream streams an xAI chat completion, see OpenAIWrapper.ChatCompletionStream
+func (w *XAIWrapper) ChatCompletionStream(ctx context.Context, model string, messages []map[string]string, onDelta StreamCallback) (map[string]interface{}, error) {
+	return w.client.chatCompletionStream(ctx, xAIProvider, w.guardrail, model, messages, onDelta)
+}
+
+// recordXAI records the live search sources xAI reports using, which it bills per source
+func recordXAI(span *Span, response map[string]interface{}) {
+	usage, _ := response["usage"].(map[string]interface{})
+	if sources, ok := usage["num_sources_used"].(float64); ok && sources > 0 {
+		span.SetAttributes(attribute.Int("response.sources_used", int(sources)))
+	}
+}